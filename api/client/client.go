@@ -1,17 +1,23 @@
 package client
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/NetSys/quilt/api"
 	"github.com/NetSys/quilt/api/pb"
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/engine"
+	"github.com/NetSys/quilt/minion/docker"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+
+	log "github.com/Sirupsen/logrus"
 )
 
 const (
@@ -36,8 +42,60 @@ type Client interface {
 	// QueryEtcd retrieves the etcd information tracked by the Quilt daemon.
 	QueryEtcd() ([]db.Etcd, error)
 
-	// RunStitch makes a request to the Quilt daemon to execute the given stitch.
-	RunStitch(stitch string) error
+	// QueryLabels retrieves the labels tracked by the Quilt daemon.
+	QueryLabels() ([]db.Label, error)
+
+	// QueryConnections retrieves the connections tracked by the Quilt daemon.
+	QueryConnections() ([]db.Connection, error)
+
+	// QueryPlacements retrieves the placements tracked by the Quilt daemon.
+	QueryPlacements() ([]db.Placement, error)
+
+	// QueryMinions retrieves the minions tracked by the Quilt daemon.
+	QueryMinions() ([]db.Minion, error)
+
+	// QueryClusters retrieves the clusters tracked by the Quilt daemon.
+	QueryClusters() ([]db.Cluster, error)
+
+	// QueryRollouts retrieves the progress of the rolling updates tracked by the
+	// Quilt daemon.
+	QueryRollouts() ([]db.Rollout, error)
+
+	// WatchMachines streams the machines tracked by the Quilt daemon, sending the
+	// full list every time it changes.  The channel is closed when the stream ends.
+	WatchMachines() (<-chan []db.Machine, error)
+
+	// WatchContainers streams the containers tracked by the Quilt daemon, sending
+	// the full list every time it changes.  The channel is closed when the stream
+	// ends.
+	WatchContainers() (<-chan []db.Container, error)
+
+	// RunStitch makes a request to the Quilt daemon to execute the given stitch.  If
+	// `namespace` isn't empty, it overrides the namespace the stitch declares.
+	RunStitch(stitch, namespace string) error
+
+	// PlanStitch asks the Quilt daemon what running the given stitch would change,
+	// without running it.
+	PlanStitch(stitch, namespace string) (engine.Plan, error)
+
+	// SetSecret stores the value of the secret `name` in the Quilt daemon.
+	SetSecret(name, value string) error
+
+	// Cordon sets whether new containers may be placed on the machine `machineID`,
+	// and whether its containers should be moved elsewhere.  Draining a machine
+	// also makes it unschedulable.
+	Cordon(machineID int, unschedulable, drain bool) error
+
+	// ContainerLogs writes the output of the container `stitchID` to `w`.  Only
+	// output produced after `since` is written, unless it's the zero time.  If
+	// `follow` is true, it keeps writing output until the container exits.  The
+	// daemon must be the one on the minion running the container.
+	ContainerLogs(stitchID int, follow bool, since time.Time, w io.Writer) error
+
+	// Exec runs a command in the container `stitchID`, connected to the streams in
+	// `opts`, and returns its exit code.  The daemon must be the one on the minion
+	// running the container.
+	Exec(stitchID int, opts docker.ExecOptions) (int, error)
 }
 
 type clientImpl struct {
@@ -45,17 +103,27 @@ type clientImpl struct {
 	cc       *grpc.ClientConn
 }
 
-// New creates a new Quilt client connected to `lAddr`.
-func New(lAddr string) (Client, error) {
+// New creates a new Quilt client connected to `lAddr`.  Connections over TCP are
+// mutually authenticated with `creds`, while Unix sockets rely on their file
+// permissions and ignore them.
+func New(lAddr string, creds certs.Credentials) (Client, error) {
 	proto, addr, err := api.ParseListenAddress(lAddr)
 	if err != nil {
 		return nil, err
 	}
 
+	security := grpc.WithInsecure()
+	if proto == "tcp" {
+		security, err = creds.DialOption()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	dialer := func(dialAddr string, t time.Duration) (net.Conn, error) {
 		return net.DialTimeout(proto, dialAddr, t)
 	}
-	cc, err := grpc.Dial(addr, grpc.WithDialer(dialer), grpc.WithInsecure(),
+	cc, err := grpc.Dial(addr, grpc.WithDialer(dialer), security,
 		grpc.WithBlock(), grpc.WithTimeout(connectTimeout))
 	if err != nil {
 		return nil, err
@@ -68,36 +136,36 @@ func New(lAddr string) (Client, error) {
 	}, nil
 }
 
-func query(pbClient pb.APIClient, table db.TableType) (interface{}, error) {
+func query(pbClient pb.APIClient, table db.TableType) (*pb.QueryReply, error) {
 	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
-	reply, err := pbClient.Query(ctx, &pb.DBQuery{Table: string(table)})
+	return pbClient.Query(ctx, &pb.DBQuery{Table: string(table)})
+}
+
+// watch streams the contents of `table` each time it changes.  The returned channel is
+// closed when the stream ends.
+func watch(pbClient pb.APIClient, table db.TableType) (<-chan *pb.QueryReply, error) {
+	stream, err := pbClient.Watch(context.Background(),
+		&pb.DBQuery{Table: string(table)})
 	if err != nil {
 		return nil, err
 	}
 
-	replyBytes := []byte(reply.TableContents)
-	switch table {
-	case db.MachineTable:
-		var machines []db.Machine
-		if err := json.Unmarshal(replyBytes, &machines); err != nil {
-			return nil, err
-		}
-		return machines, nil
-	case db.ContainerTable:
-		var containers []db.Container
-		if err := json.Unmarshal(replyBytes, &containers); err != nil {
-			return nil, err
-		}
-		return containers, nil
-	case db.EtcdTable:
-		var etcds []db.Etcd
-		if err := json.Unmarshal(replyBytes, &etcds); err != nil {
-			return nil, err
+	replyChan := make(chan *pb.QueryReply)
+	go func() {
+		defer close(replyChan)
+		for {
+			reply, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					log.WithError(err).Debug("Watch stream ended.")
+				}
+				return
+			}
+			replyChan <- reply
 		}
-		return etcds, nil
-	default:
-		panic(fmt.Sprintf("unsupported table type: %s", table))
-	}
+	}()
+
+	return replyChan, nil
 }
 
 // Close the grpc connection.
@@ -107,37 +175,299 @@ func (c clientImpl) Close() error {
 
 // QueryMachines retrieves the machines tracked by the Quilt daemon.
 func (c clientImpl) QueryMachines() ([]db.Machine, error) {
-	rows, err := query(c.pbClient, db.MachineTable)
+	reply, err := query(c.pbClient, db.MachineTable)
 	if err != nil {
 		return nil, err
 	}
 
-	return rows.([]db.Machine), nil
+	return machinesFromPB(reply.Machines), nil
 }
 
 // QueryContainers retrieves the containers tracked by the Quilt daemon.
 func (c clientImpl) QueryContainers() ([]db.Container, error) {
-	rows, err := query(c.pbClient, db.ContainerTable)
+	reply, err := query(c.pbClient, db.ContainerTable)
 	if err != nil {
 		return nil, err
 	}
 
-	return rows.([]db.Container), nil
+	return containersFromPB(reply.Containers), nil
 }
 
 // QueryEtcd retrieves the etcd information tracked by the Quilt daemon.
 func (c clientImpl) QueryEtcd() ([]db.Etcd, error) {
-	rows, err := query(c.pbClient, db.EtcdTable)
+	reply, err := query(c.pbClient, db.EtcdTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return etcdsFromPB(reply.Etcds), nil
+}
+
+// QueryLabels retrieves the labels tracked by the Quilt daemon.
+func (c clientImpl) QueryLabels() ([]db.Label, error) {
+	reply, err := query(c.pbClient, db.LabelTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return labelsFromPB(reply.Labels), nil
+}
+
+// QueryConnections retrieves the connections tracked by the Quilt daemon.
+func (c clientImpl) QueryConnections() ([]db.Connection, error) {
+	reply, err := query(c.pbClient, db.ConnectionTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return connectionsFromPB(reply.Connections), nil
+}
+
+// QueryPlacements retrieves the placements tracked by the Quilt daemon.
+func (c clientImpl) QueryPlacements() ([]db.Placement, error) {
+	reply, err := query(c.pbClient, db.PlacementTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return placementsFromPB(reply.Placements), nil
+}
+
+// QueryMinions retrieves the minions tracked by the Quilt daemon.
+func (c clientImpl) QueryMinions() ([]db.Minion, error) {
+	reply, err := query(c.pbClient, db.MinionTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return minionsFromPB(reply.Minions), nil
+}
+
+// QueryClusters retrieves the clusters tracked by the Quilt daemon.
+func (c clientImpl) QueryClusters() ([]db.Cluster, error) {
+	reply, err := query(c.pbClient, db.ClusterTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return clustersFromPB(reply.Clusters), nil
+}
+
+// QueryRollouts retrieves the progress of the rolling updates tracked by the Quilt
+// daemon.
+func (c clientImpl) QueryRollouts() ([]db.Rollout, error) {
+	reply, err := query(c.pbClient, db.RolloutTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return rolloutsFromPB(reply.Rollouts), nil
+}
+
+// WatchMachines streams the machines tracked by the Quilt daemon, sending the full
+// list every time it changes.  The channel is closed when the stream ends.
+func (c clientImpl) WatchMachines() (<-chan []db.Machine, error) {
+	replyChan, err := watch(c.pbClient, db.MachineTable)
 	if err != nil {
 		return nil, err
 	}
 
-	return rows.([]db.Etcd), nil
+	machinesChan := make(chan []db.Machine)
+	go func() {
+		defer close(machinesChan)
+		for reply := range replyChan {
+			machinesChan <- machinesFromPB(reply.Machines)
+		}
+	}()
+	return machinesChan, nil
+}
+
+// WatchContainers streams the containers tracked by the Quilt daemon, sending the full
+// list every time it changes.  The channel is closed when the stream ends.
+func (c clientImpl) WatchContainers() (<-chan []db.Container, error) {
+	replyChan, err := watch(c.pbClient, db.ContainerTable)
+	if err != nil {
+		return nil, err
+	}
+
+	containersChan := make(chan []db.Container)
+	go func() {
+		defer close(containersChan)
+		for reply := range replyChan {
+			containersChan <- containersFromPB(reply.Containers)
+		}
+	}()
+	return containersChan, nil
 }
 
 // RunStitch makes a request to the Quilt daemon to execute the given stitch.
-func (c clientImpl) RunStitch(stitch string) error {
+func (c clientImpl) RunStitch(stitch, namespace string) error {
+	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
+	_, err := c.pbClient.Run(ctx, &pb.RunRequest{
+		Stitch:    stitch,
+		Namespace: namespace,
+	})
+	return err
+}
+
+// PlanStitch asks the Quilt daemon what running the given stitch would change,
+// without running it.
+func (c clientImpl) PlanStitch(stitch, namespace string) (engine.Plan, error) {
 	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
-	_, err := c.pbClient.Run(ctx, &pb.RunRequest{Stitch: stitch})
+	reply, err := c.pbClient.Plan(ctx, &pb.RunRequest{
+		Stitch:    stitch,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return engine.Plan{}, err
+	}
+
+	return engine.Plan{
+		BootMachines:      machinesFromPB(reply.BootMachines),
+		TerminateMachines: machinesFromPB(reply.TerminateMachines),
+		StartContainers:   containersFromPB(reply.StartContainers),
+		StopContainers:    containersFromPB(reply.StopContainers),
+	}, nil
+}
+
+// SetSecret stores the value of the secret `name` in the Quilt daemon.
+func (c clientImpl) SetSecret(name, value string) error {
+	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
+	_, err := c.pbClient.SetSecret(ctx, &pb.Secret{Name: name, Value: value})
+	return err
+}
+
+// Cordon sets whether the machine `machineID` is unschedulable or draining.
+func (c clientImpl) Cordon(machineID int, unschedulable, drain bool) error {
+	ctx, _ := context.WithTimeout(context.Background(), requestTimeout)
+	_, err := c.pbClient.Cordon(ctx, &pb.CordonRequest{
+		MachineID:     int32(machineID),
+		Unschedulable: unschedulable,
+		Drain:         drain,
+	})
+	return err
+}
+
+// ContainerLogs writes the output of the container `stitchID` to `w`.
+func (c clientImpl) ContainerLogs(stitchID int, follow bool, since time.Time,
+	w io.Writer) error {
+
+	req := pb.LogsRequest{StitchID: int32(stitchID), Follow: follow}
+	if !since.IsZero() {
+		req.Since = since.Unix()
+	}
+
+	stream, err := c.pbClient.ContainerLogs(context.Background(), &req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(reply.Output); err != nil {
+			return err
+		}
+	}
+}
+
+// Exec runs a command in the container `stitchID` and returns its exit code.
+func (c clientImpl) Exec(stitchID int, opts docker.ExecOptions) (int, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.pbClient.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Input and resizes are sent concurrently, but gRPC streams don't support
+	// concurrent sends.
+	var sendLock sync.Mutex
+	send := func(req *pb.ExecRequest) error {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		return stream.Send(req)
+	}
+
+	err = send(&pb.ExecRequest{
+		StitchID:   int32(stitchID),
+		Command:    opts.Cmd,
+		TTY:        opts.TTY,
+		CloseStdin: opts.Stdin == nil,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if opts.Stdin != nil {
+		go func() {
+			buf := make([]byte, 4096)
+			for {
+				n, err := opts.Stdin.Read(buf)
+				if n > 0 {
+					input := append([]byte{}, buf[:n]...)
+					if send(&pb.ExecRequest{Stdin: input}) != nil {
+						return
+					}
+				}
+
+				if err != nil {
+					send(&pb.ExecRequest{CloseStdin: true})
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for {
+			select {
+			case size, ok := <-opts.Resize:
+				if !ok {
+					return
+				}
+				send(&pb.ExecRequest{
+					Height: int32(size.Height),
+					Width:  int32(size.Width),
+				})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			return 0, errors.New("exec ended without an exit code")
+		} else if err != nil {
+			return 0, err
+		}
+
+		if reply.Exited {
+			return int(reply.ExitCode), nil
+		}
+
+		if err := write(opts.Stdout, reply.Stdout); err != nil {
+			return 0, err
+		}
+
+		if err := write(opts.Stderr, reply.Stderr); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func write(w io.Writer, p []byte) error {
+	if w == nil || len(p) == 0 {
+		return nil
+	}
+	_, err := w.Write(p)
 	return err
 }
@@ -1,26 +1,35 @@
 package client
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 
 	"github.com/NetSys/quilt/api/pb"
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/engine"
+	"github.com/NetSys/quilt/minion/docker"
 )
 
 type mockAPIClient struct {
-	mockResponse string
-	mockError    error
+	mockReply        *pb.QueryReply
+	mockPlanReply    *pb.PlanReply
+	mockWatchReplies []*pb.QueryReply
+	mockLogs         []string
+	mockExec         *mockExecClient
+	mockError        error
 }
 
 func (c mockAPIClient) Query(ctx context.Context, in *pb.DBQuery,
 	opts ...grpc.CallOption) (*pb.QueryReply, error) {
 
-	return &pb.QueryReply{TableContents: c.mockResponse}, c.mockError
+	return c.mockReply, c.mockError
 }
 
 func (c mockAPIClient) Run(ctx context.Context, in *pb.RunRequest,
@@ -29,13 +38,137 @@ func (c mockAPIClient) Run(ctx context.Context, in *pb.RunRequest,
 	return &pb.RunReply{}, nil
 }
 
-func TestUnmarshalMachine(t *testing.T) {
+func (c mockAPIClient) Plan(ctx context.Context, in *pb.RunRequest,
+	opts ...grpc.CallOption) (*pb.PlanReply, error) {
+
+	return c.mockPlanReply, c.mockError
+}
+
+func (c mockAPIClient) SetSecret(ctx context.Context, in *pb.Secret,
+	opts ...grpc.CallOption) (*pb.SetSecretReply, error) {
+
+	return &pb.SetSecretReply{}, c.mockError
+}
+
+func (c mockAPIClient) Cordon(ctx context.Context, in *pb.CordonRequest,
+	opts ...grpc.CallOption) (*pb.CordonReply, error) {
+
+	return &pb.CordonReply{}, c.mockError
+}
+
+func (c mockAPIClient) Watch(ctx context.Context, in *pb.DBQuery,
+	opts ...grpc.CallOption) (pb.API_WatchClient, error) {
+
+	return &mockWatchClient{replies: c.mockWatchReplies}, c.mockError
+}
+
+func (c mockAPIClient) ContainerLogs(ctx context.Context, in *pb.LogsRequest,
+	opts ...grpc.CallOption) (pb.API_ContainerLogsClient, error) {
+
+	return &mockLogsClient{output: c.mockLogs}, c.mockError
+}
+
+type mockLogsClient struct {
+	grpc.ClientStream
+	output []string
+}
+
+func (c *mockLogsClient) Recv() (*pb.LogsReply, error) {
+	if len(c.output) == 0 {
+		return nil, io.EOF
+	}
+
+	reply := &pb.LogsReply{Output: []byte(c.output[0])}
+	c.output = c.output[1:]
+	return reply, nil
+}
+
+func (c mockAPIClient) Exec(ctx context.Context, opts ...grpc.CallOption) (
+	pb.API_ExecClient, error) {
+
+	return c.mockExec, c.mockError
+}
+
+type mockExecClient struct {
+	grpc.ClientStream
+	requests []*pb.ExecRequest
+	replies  []*pb.ExecReply
+}
+
+func (c *mockExecClient) Send(req *pb.ExecRequest) error {
+	c.requests = append(c.requests, req)
+	return nil
+}
+
+func (c *mockExecClient) Recv() (*pb.ExecReply, error) {
+	if len(c.replies) == 0 {
+		return nil, io.EOF
+	}
+
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+	return reply, nil
+}
+
+type mockWatchClient struct {
+	grpc.ClientStream
+	replies []*pb.QueryReply
+}
+
+func (c *mockWatchClient) Recv() (*pb.QueryReply, error) {
+	if len(c.replies) == 0 {
+		return nil, io.EOF
+	}
+
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+	return reply, nil
+}
+
+func TestWatchMachines(t *testing.T) {
 	t.Parallel()
 
+	master := &pb.Machine{ID: 1, Role: "Master"}
+	worker := &pb.Machine{ID: 2, Role: "Worker"}
 	apiClient := mockAPIClient{
-		mockResponse: `[{"ID":1,"Role":"Master","Provider":"Amazon",` +
-			`"Region":"","Size":"size","DiskSize":0,"SSHKeys":null,` +
-			`"CloudID":"","PublicIP":"8.8.8.8","PrivateIP":"9.9.9.9"}]`,
+		mockWatchReplies: []*pb.QueryReply{
+			{Machines: []*pb.Machine{master}},
+			{Machines: []*pb.Machine{master, worker}},
+		},
+	}
+	c := clientImpl{pbClient: apiClient}
+	machinesChan, err := c.WatchMachines()
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+		return
+	}
+
+	var res [][]db.Machine
+	for machines := range machinesChan {
+		res = append(res, machines)
+	}
+
+	exp := [][]db.Machine{
+		{{ID: 1, Role: db.Master}},
+		{{ID: 1, Role: db.Master}, {ID: 2, Role: db.Worker}},
+	}
+	if !reflect.DeepEqual(exp, res) {
+		t.Errorf("Bad watched machines: expected %v, got %v.", exp, res)
+	}
+}
+
+func TestQueryMachine(t *testing.T) {
+	t.Parallel()
+
+	apiClient := mockAPIClient{
+		mockReply: &pb.QueryReply{Machines: []*pb.Machine{{
+			ID:        1,
+			Role:      "Master",
+			Provider:  "Amazon",
+			Size:      "size",
+			PublicIP:  "8.8.8.8",
+			PrivateIP: "9.9.9.9",
+		}}},
 	}
 	c := clientImpl{pbClient: apiClient}
 	res, err := c.QueryMachines()
@@ -56,19 +189,23 @@ func TestUnmarshalMachine(t *testing.T) {
 	}
 
 	if !reflect.DeepEqual(exp, res) {
-		t.Errorf("Bad unmarshalling of machines: expected %v, got %v.",
+		t.Errorf("Bad conversion of machines: expected %v, got %v.",
 			exp, res)
 	}
 }
 
-func TestUnmarshalContainer(t *testing.T) {
+func TestQueryContainer(t *testing.T) {
 	t.Parallel()
 
 	apiClient := mockAPIClient{
-		mockResponse: `[{"ID":1,"Pid":0,"IP":"","Mac":"","Minion":"",` +
-			`"DockerID":"docker-id","StitchID":0,"Image":"image",` +
-			`"Command":["cmd","arg"],"Labels":["labelA","labelB"],` +
-			`"Env":null}]`,
+		mockReply: &pb.QueryReply{Containers: []*pb.Container{{
+			ID:       1,
+			DockerID: "docker-id",
+			Image:    "image",
+			Command:  []string{"cmd", "arg"},
+			Labels:   []string{"labelA", "labelB"},
+			Env:      map[string]string{"key": "value"},
+		}}},
 	}
 	c := clientImpl{pbClient: apiClient}
 	res, err := c.QueryContainers()
@@ -84,32 +221,74 @@ func TestUnmarshalContainer(t *testing.T) {
 			Image:    "image",
 			Command:  []string{"cmd", "arg"},
 			Labels:   []string{"labelA", "labelB"},
+			Env:      map[string]string{"key": "value"},
 		},
 	}
 
 	if !reflect.DeepEqual(exp, res) {
-		t.Errorf("Bad unmarshalling of containers: expected %v, got %v.",
+		t.Errorf("Bad conversion of containers: expected %v, got %v.",
 			exp, res)
 	}
 }
 
-func TestUnmarshalError(t *testing.T) {
+func TestQueryLabel(t *testing.T) {
 	t.Parallel()
 
 	apiClient := mockAPIClient{
-		mockResponse: `[{"ID":1`,
+		mockReply: &pb.QueryReply{Labels: []*pb.Label{{
+			ID:           1,
+			Label:        "web",
+			IP:           "10.0.0.1",
+			ContainerIPs: []string{"10.0.0.2"},
+			MultiHost:    true,
+		}}},
 	}
 	c := clientImpl{pbClient: apiClient}
+	res, err := c.QueryLabels()
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+		return
+	}
 
-	_, err := c.QueryMachines()
-	if err == nil {
-		t.Error("Bad json should throw a unmarshalling error, but got nothing")
+	exp := []db.Label{{
+		ID:           1,
+		Label:        "web",
+		IP:           "10.0.0.1",
+		ContainerIPs: []string{"10.0.0.2"},
+		MultiHost:    true,
+	}}
+
+	if !reflect.DeepEqual(exp, res) {
+		t.Errorf("Bad conversion of labels: expected %v, got %v.", exp, res)
+	}
+}
+
+func TestPlanStitch(t *testing.T) {
+	t.Parallel()
+
+	apiClient := mockAPIClient{
+		mockPlanReply: &pb.PlanReply{
+			BootMachines:    []*pb.Machine{{Role: "Master", Size: "size"}},
+			StartContainers: []*pb.Container{{Image: "image"}},
+			StopContainers:  []*pb.Container{{ID: 2, Image: "old"}},
+		},
+	}
+	c := clientImpl{pbClient: apiClient}
+	res, err := c.PlanStitch("", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+		return
 	}
 
-	exp := "unexpected end of JSON input"
-	if err.Error() != exp {
-		t.Errorf("Bad json should throw a unmarshalling error:"+
-			"expected %s, got %s", exp, err.Error())
+	exp := engine.Plan{
+		BootMachines:    []db.Machine{{Role: db.Master, Size: "size"}},
+		StartContainers: []db.Container{{Image: "image"}},
+		StopContainers:  []db.Container{{ID: 2, Image: "old"}},
+	}
+
+	if !reflect.DeepEqual(exp, res) {
+		t.Errorf("Bad conversion of plan: expected %v, got %v.",
+			exp, res)
 	}
 }
 
@@ -131,3 +310,68 @@ func TestGrpcError(t *testing.T) {
 			exp.Error(), err.Error())
 	}
 }
+
+func TestContainerLogs(t *testing.T) {
+	t.Parallel()
+
+	apiClient := mockAPIClient{mockLogs: []string{"hello\n", "world\n"}}
+	c := clientImpl{pbClient: apiClient}
+
+	var out bytes.Buffer
+	if err := c.ContainerLogs(1, false, time.Time{}, &out); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if out.String() != "hello\nworld\n" {
+		t.Errorf("Unexpected logs: %q", out.String())
+	}
+
+	c = clientImpl{pbClient: mockAPIClient{mockError: errors.New("err")}}
+	if err := c.ContainerLogs(1, true, time.Now(), &out); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+
+	stream := &mockExecClient{replies: []*pb.ExecReply{
+		{Stdout: []byte("out")},
+		{Stderr: []byte("err")},
+		{Exited: true, ExitCode: 3},
+	}}
+	c := clientImpl{pbClient: mockAPIClient{mockExec: stream}}
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := c.Exec(1, docker.ExecOptions{
+		Cmd:    []string{"ls", "-l"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if exitCode != 3 {
+		t.Errorf("Exit code %d, expected 3", exitCode)
+	}
+
+	if stdout.String() != "out" || stderr.String() != "err" {
+		t.Errorf("Unexpected output: %q, %q", stdout.String(), stderr.String())
+	}
+
+	exp := []*pb.ExecRequest{{
+		StitchID:   1,
+		Command:    []string{"ls", "-l"},
+		CloseStdin: true,
+	}}
+	if !reflect.DeepEqual(stream.requests, exp) {
+		t.Errorf("Sent %v, expected %v", stream.requests, exp)
+	}
+
+	// Streams that end before the command exits are errors.
+	c = clientImpl{pbClient: mockAPIClient{mockExec: &mockExecClient{}}}
+	if _, err := c.Exec(1, docker.ExecOptions{}); err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"github.com/NetSys/quilt/api/pb"
+	"github.com/NetSys/quilt/db"
+)
+
+func machinesFromPB(machines []*pb.Machine) []db.Machine {
+	var res []db.Machine
+	for _, m := range machines {
+		res = append(res, db.Machine{
+			ID:        int(m.ID),
+			Namespace: m.Namespace,
+			Role:      db.Role(m.Role),
+			Provider:  db.Provider(m.Provider),
+			Region:    m.Region,
+			Size:      m.Size,
+			DiskSize:  int(m.DiskSize),
+			SSHKeys:   m.SSHKeys,
+			CloudID:   m.CloudID,
+			PublicIP:  m.PublicIP,
+			PrivateIP: m.PrivateIP,
+			Connected: m.Connected,
+
+			Unschedulable: m.Unschedulable,
+			Draining:      m.Draining,
+		})
+	}
+	return res
+}
+
+func containersFromPB(containers []*pb.Container) []db.Container {
+	var res []db.Container
+	for _, c := range containers {
+		res = append(res, db.Container{
+			ID:       int(c.ID),
+			Pid:      int(c.Pid),
+			IP:       c.IP,
+			Mac:      c.Mac,
+			Minion:   c.Minion,
+			DockerID: c.DockerID,
+			StitchID: int(c.StitchID),
+			Image:    c.Image,
+			Command:  c.Command,
+			Labels:   c.Labels,
+			Env:      c.Env,
+			Volumes:  c.Volumes,
+			CPU:      c.CPU,
+			RAM:      c.RAM,
+
+			HealthCheck:   healthCheckFromPB(c.HealthCheck),
+			RestartPolicy: c.RestartPolicy,
+			Health:        c.Health,
+			Restarts:      int(c.Restarts),
+			Unplaced:      c.Unplaced,
+		})
+	}
+	return res
+}
+
+func healthCheckFromPB(hc *pb.HealthCheck) db.HealthCheck {
+	if hc == nil {
+		return db.HealthCheck{}
+	}
+
+	return db.HealthCheck{
+		Exec:     hc.Exec,
+		TCP:      int(hc.TCP),
+		HTTP:     int(hc.HTTP),
+		Path:     hc.Path,
+		Interval: int(hc.Interval),
+		Retries:  int(hc.Retries),
+	}
+}
+
+func etcdsFromPB(etcds []*pb.Etcd) []db.Etcd {
+	var res []db.Etcd
+	for _, e := range etcds {
+		res = append(res, db.Etcd{
+			ID:       int(e.ID),
+			EtcdIPs:  e.EtcdIPs,
+			Leader:   e.Leader,
+			LeaderIP: e.LeaderIP,
+		})
+	}
+	return res
+}
+
+func labelsFromPB(labels []*pb.Label) []db.Label {
+	var res []db.Label
+	for _, l := range labels {
+		res = append(res, db.Label{
+			ID:           int(l.ID),
+			Label:        l.Label,
+			IP:           l.IP,
+			ContainerIPs: l.ContainerIPs,
+			MultiHost:    l.MultiHost,
+		})
+	}
+	return res
+}
+
+func connectionsFromPB(connections []*pb.Connection) []db.Connection {
+	var res []db.Connection
+	for _, c := range connections {
+		res = append(res, db.Connection{
+			ID:       int(c.ID),
+			From:     c.From,
+			To:       c.To,
+			MinPort:  int(c.MinPort),
+			MaxPort:  int(c.MaxPort),
+			Protocol: c.Protocol,
+		})
+	}
+	return res
+}
+
+func placementsFromPB(placements []*pb.Placement) []db.Placement {
+	var res []db.Placement
+	for _, p := range placements {
+		res = append(res, db.Placement{
+			ID:          int(p.ID),
+			TargetLabel: p.TargetLabel,
+			Exclusive:   p.Exclusive,
+			OtherLabel:  p.OtherLabel,
+			Provider:    p.Provider,
+			Size:        p.Size,
+			Region:      p.Region,
+			Spread:      p.Spread,
+			MaxSkew:     int(p.MaxSkew),
+			Rebalance:   p.Rebalance,
+		})
+	}
+	return res
+}
+
+func minionsFromPB(minions []*pb.Minion) []db.Minion {
+	var res []db.Minion
+	for _, m := range minions {
+		res = append(res, db.Minion{
+			ID:        int(m.ID),
+			Self:      m.Self,
+			Spec:      m.Spec,
+			Role:      db.Role(m.Role),
+			PrivateIP: m.PrivateIP,
+			Provider:  m.Provider,
+			Size:      m.Size,
+			Region:    m.Region,
+			Volumes:   m.Volumes,
+			Failed:    m.Failed,
+
+			Unschedulable: m.Unschedulable,
+			Draining:      m.Draining,
+		})
+	}
+	return res
+}
+
+func rolloutsFromPB(rollouts []*pb.Rollout) []db.Rollout {
+	var res []db.Rollout
+	for _, r := range rollouts {
+		res = append(res, db.Rollout{
+			ID:       int(r.ID),
+			Label:    r.Label,
+			Replicas: int(r.Replicas),
+			Updated:  int(r.Updated),
+			Ready:    int(r.Ready),
+			Old:      int(r.Old),
+		})
+	}
+	return res
+}
+
+func clustersFromPB(clusters []*pb.Cluster) []db.Cluster {
+	var res []db.Cluster
+	for _, c := range clusters {
+		res = append(res, db.Cluster{
+			ID:        int(c.ID),
+			Namespace: c.Namespace,
+			Spec:      c.Spec,
+			AdminACLs: c.AdminACLs,
+		})
+	}
+	return res
+}
@@ -13,6 +13,25 @@ It has these top-level messages:
 	QueryReply
 	RunRequest
 	RunReply
+	Secret
+	SetSecretReply
+	LogsRequest
+	LogsReply
+	ExecRequest
+	ExecReply
+	CordonRequest
+	CordonReply
+	PlanReply
+	Machine
+	Container
+	HealthCheck
+	Etcd
+	Label
+	Connection
+	Placement
+	Minion
+	Cluster
+	Rollout
 */
 package pb
 
@@ -45,8 +64,17 @@ func (m *DBQuery) String() string            { return proto.CompactTextString(m)
 func (*DBQuery) ProtoMessage()               {}
 func (*DBQuery) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+// Only the field corresponding to the queried table is populated.
 type QueryReply struct {
-	TableContents string `protobuf:"bytes,1,opt,name=TableContents,json=tableContents" json:"TableContents,omitempty"`
+	Machines    []*Machine    `protobuf:"bytes,2,rep,name=Machines,json=machines" json:"Machines,omitempty"`
+	Containers  []*Container  `protobuf:"bytes,3,rep,name=Containers,json=containers" json:"Containers,omitempty"`
+	Etcds       []*Etcd       `protobuf:"bytes,4,rep,name=Etcds,json=etcds" json:"Etcds,omitempty"`
+	Labels      []*Label      `protobuf:"bytes,5,rep,name=Labels,json=labels" json:"Labels,omitempty"`
+	Connections []*Connection `protobuf:"bytes,6,rep,name=Connections,json=connections" json:"Connections,omitempty"`
+	Placements  []*Placement  `protobuf:"bytes,7,rep,name=Placements,json=placements" json:"Placements,omitempty"`
+	Minions     []*Minion     `protobuf:"bytes,8,rep,name=Minions,json=minions" json:"Minions,omitempty"`
+	Clusters    []*Cluster    `protobuf:"bytes,9,rep,name=Clusters,json=clusters" json:"Clusters,omitempty"`
+	Rollouts    []*Rollout    `protobuf:"bytes,10,rep,name=Rollouts,json=rollouts" json:"Rollouts,omitempty"`
 }
 
 func (m *QueryReply) Reset()                    { *m = QueryReply{} }
@@ -54,8 +82,72 @@ func (m *QueryReply) String() string            { return proto.CompactTextString
 func (*QueryReply) ProtoMessage()               {}
 func (*QueryReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
+func (m *QueryReply) GetMachines() []*Machine {
+	if m != nil {
+		return m.Machines
+	}
+	return nil
+}
+
+func (m *QueryReply) GetContainers() []*Container {
+	if m != nil {
+		return m.Containers
+	}
+	return nil
+}
+
+func (m *QueryReply) GetEtcds() []*Etcd {
+	if m != nil {
+		return m.Etcds
+	}
+	return nil
+}
+
+func (m *QueryReply) GetLabels() []*Label {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *QueryReply) GetConnections() []*Connection {
+	if m != nil {
+		return m.Connections
+	}
+	return nil
+}
+
+func (m *QueryReply) GetPlacements() []*Placement {
+	if m != nil {
+		return m.Placements
+	}
+	return nil
+}
+
+func (m *QueryReply) GetMinions() []*Minion {
+	if m != nil {
+		return m.Minions
+	}
+	return nil
+}
+
+func (m *QueryReply) GetClusters() []*Cluster {
+	if m != nil {
+		return m.Clusters
+	}
+	return nil
+}
+
+func (m *QueryReply) GetRollouts() []*Rollout {
+	if m != nil {
+		return m.Rollouts
+	}
+	return nil
+}
+
 type RunRequest struct {
-	Stitch string `protobuf:"bytes,1,opt,name=Stitch,json=stitch" json:"Stitch,omitempty"`
+	Stitch    string `protobuf:"bytes,1,opt,name=Stitch,json=stitch" json:"Stitch,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=Namespace,json=namespace" json:"Namespace,omitempty"`
 }
 
 func (m *RunRequest) Reset()                    { *m = RunRequest{} }
@@ -71,11 +163,340 @@ func (m *RunReply) String() string            { return proto.CompactTextString(m
 func (*RunReply) ProtoMessage()               {}
 func (*RunReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
+type Secret struct {
+	Name  string `protobuf:"bytes,1,opt,name=Name,json=name" json:"Name,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=Value,json=value" json:"Value,omitempty"`
+}
+
+func (m *Secret) Reset()                    { *m = Secret{} }
+func (m *Secret) String() string            { return proto.CompactTextString(m) }
+func (*Secret) ProtoMessage()               {}
+func (*Secret) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+type SetSecretReply struct {
+}
+
+func (m *SetSecretReply) Reset()                    { *m = SetSecretReply{} }
+func (m *SetSecretReply) String() string            { return proto.CompactTextString(m) }
+func (*SetSecretReply) ProtoMessage()               {}
+func (*SetSecretReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+// Since is a unix timestamp, or 0 to request all of the container's output.
+type LogsRequest struct {
+	StitchID int32 `protobuf:"varint,1,opt,name=StitchID,json=stitchID" json:"StitchID,omitempty"`
+	Follow   bool  `protobuf:"varint,2,opt,name=Follow,json=follow" json:"Follow,omitempty"`
+	Since    int64 `protobuf:"varint,3,opt,name=Since,json=since" json:"Since,omitempty"`
+}
+
+func (m *LogsRequest) Reset()                    { *m = LogsRequest{} }
+func (m *LogsRequest) String() string            { return proto.CompactTextString(m) }
+func (*LogsRequest) ProtoMessage()               {}
+func (*LogsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+type LogsReply struct {
+	Output []byte `protobuf:"bytes,1,opt,name=Output,json=output,proto3" json:"Output,omitempty"`
+}
+
+func (m *LogsReply) Reset()                    { *m = LogsReply{} }
+func (m *LogsReply) String() string            { return proto.CompactTextString(m) }
+func (*LogsReply) ProtoMessage()               {}
+func (*LogsReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+// The first ExecRequest of a stream starts the command.  Later requests carry its
+// input, and the size of its terminal whenever it changes.
+type ExecRequest struct {
+	StitchID   int32    `protobuf:"varint,1,opt,name=StitchID,json=stitchID" json:"StitchID,omitempty"`
+	Command    []string `protobuf:"bytes,2,rep,name=Command,json=command" json:"Command,omitempty"`
+	TTY        bool     `protobuf:"varint,3,opt,name=TTY,json=tTY" json:"TTY,omitempty"`
+	Stdin      []byte   `protobuf:"bytes,4,opt,name=Stdin,json=stdin,proto3" json:"Stdin,omitempty"`
+	CloseStdin bool     `protobuf:"varint,5,opt,name=CloseStdin,json=closeStdin" json:"CloseStdin,omitempty"`
+	Height     int32    `protobuf:"varint,6,opt,name=Height,json=height" json:"Height,omitempty"`
+	Width      int32    `protobuf:"varint,7,opt,name=Width,json=width" json:"Width,omitempty"`
+}
+
+func (m *ExecRequest) Reset()                    { *m = ExecRequest{} }
+func (m *ExecRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExecRequest) ProtoMessage()               {}
+func (*ExecRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+// The last ExecReply of a stream has Exited set.
+type ExecReply struct {
+	Stdout   []byte `protobuf:"bytes,1,opt,name=Stdout,json=stdout,proto3" json:"Stdout,omitempty"`
+	Stderr   []byte `protobuf:"bytes,2,opt,name=Stderr,json=stderr,proto3" json:"Stderr,omitempty"`
+	Exited   bool   `protobuf:"varint,3,opt,name=Exited,json=exited" json:"Exited,omitempty"`
+	ExitCode int32  `protobuf:"varint,4,opt,name=ExitCode,json=exitCode" json:"ExitCode,omitempty"`
+}
+
+func (m *ExecReply) Reset()                    { *m = ExecReply{} }
+func (m *ExecReply) String() string            { return proto.CompactTextString(m) }
+func (*ExecReply) ProtoMessage()               {}
+func (*ExecReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+// Unschedulable keeps new containers off the machine, and Drain moves its containers
+// elsewhere.
+type CordonRequest struct {
+	MachineID     int32 `protobuf:"varint,1,opt,name=MachineID,json=machineID" json:"MachineID,omitempty"`
+	Unschedulable bool  `protobuf:"varint,2,opt,name=Unschedulable,json=unschedulable" json:"Unschedulable,omitempty"`
+	Drain         bool  `protobuf:"varint,3,opt,name=Drain,json=drain" json:"Drain,omitempty"`
+}
+
+func (m *CordonRequest) Reset()                    { *m = CordonRequest{} }
+func (m *CordonRequest) String() string            { return proto.CompactTextString(m) }
+func (*CordonRequest) ProtoMessage()               {}
+func (*CordonRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+type CordonReply struct {
+}
+
+func (m *CordonReply) Reset()                    { *m = CordonReply{} }
+func (m *CordonReply) String() string            { return proto.CompactTextString(m) }
+func (*CordonReply) ProtoMessage()               {}
+func (*CordonReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+type PlanReply struct {
+	BootMachines      []*Machine   `protobuf:"bytes,2,rep,name=BootMachines,json=bootMachines" json:"BootMachines,omitempty"`
+	TerminateMachines []*Machine   `protobuf:"bytes,3,rep,name=TerminateMachines,json=terminateMachines" json:"TerminateMachines,omitempty"`
+	StartContainers   []*Container `protobuf:"bytes,4,rep,name=StartContainers,json=startContainers" json:"StartContainers,omitempty"`
+	StopContainers    []*Container `protobuf:"bytes,5,rep,name=StopContainers,json=stopContainers" json:"StopContainers,omitempty"`
+}
+
+func (m *PlanReply) Reset()                    { *m = PlanReply{} }
+func (m *PlanReply) String() string            { return proto.CompactTextString(m) }
+func (*PlanReply) ProtoMessage()               {}
+func (*PlanReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *PlanReply) GetBootMachines() []*Machine {
+	if m != nil {
+		return m.BootMachines
+	}
+	return nil
+}
+
+func (m *PlanReply) GetTerminateMachines() []*Machine {
+	if m != nil {
+		return m.TerminateMachines
+	}
+	return nil
+}
+
+func (m *PlanReply) GetStartContainers() []*Container {
+	if m != nil {
+		return m.StartContainers
+	}
+	return nil
+}
+
+func (m *PlanReply) GetStopContainers() []*Container {
+	if m != nil {
+		return m.StopContainers
+	}
+	return nil
+}
+
+type Machine struct {
+	ID            int32    `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	Namespace     string   `protobuf:"bytes,2,opt,name=Namespace,json=namespace" json:"Namespace,omitempty"`
+	Role          string   `protobuf:"bytes,3,opt,name=Role,json=role" json:"Role,omitempty"`
+	Provider      string   `protobuf:"bytes,4,opt,name=Provider,json=provider" json:"Provider,omitempty"`
+	Region        string   `protobuf:"bytes,5,opt,name=Region,json=region" json:"Region,omitempty"`
+	Size          string   `protobuf:"bytes,6,opt,name=Size,json=size" json:"Size,omitempty"`
+	DiskSize      int32    `protobuf:"varint,7,opt,name=DiskSize,json=diskSize" json:"DiskSize,omitempty"`
+	SSHKeys       []string `protobuf:"bytes,8,rep,name=SSHKeys,json=sSHKeys" json:"SSHKeys,omitempty"`
+	CloudID       string   `protobuf:"bytes,9,opt,name=CloudID,json=cloudID" json:"CloudID,omitempty"`
+	PublicIP      string   `protobuf:"bytes,10,opt,name=PublicIP,json=publicIP" json:"PublicIP,omitempty"`
+	PrivateIP     string   `protobuf:"bytes,11,opt,name=PrivateIP,json=privateIP" json:"PrivateIP,omitempty"`
+	Connected     bool     `protobuf:"varint,12,opt,name=Connected,json=connected" json:"Connected,omitempty"`
+	Unschedulable bool     `protobuf:"varint,13,opt,name=Unschedulable,json=unschedulable" json:"Unschedulable,omitempty"`
+	Draining      bool     `protobuf:"varint,14,opt,name=Draining,json=draining" json:"Draining,omitempty"`
+}
+
+func (m *Machine) Reset()                    { *m = Machine{} }
+func (m *Machine) String() string            { return proto.CompactTextString(m) }
+func (*Machine) ProtoMessage()               {}
+func (*Machine) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+type Container struct {
+	ID            int32             `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	Pid           int32             `protobuf:"varint,2,opt,name=Pid,json=pid" json:"Pid,omitempty"`
+	IP            string            `protobuf:"bytes,3,opt,name=IP,json=iP" json:"IP,omitempty"`
+	Mac           string            `protobuf:"bytes,4,opt,name=Mac,json=mac" json:"Mac,omitempty"`
+	Minion        string            `protobuf:"bytes,5,opt,name=Minion,json=minion" json:"Minion,omitempty"`
+	DockerID      string            `protobuf:"bytes,6,opt,name=DockerID,json=dockerID" json:"DockerID,omitempty"`
+	StitchID      int32             `protobuf:"varint,7,opt,name=StitchID,json=stitchID" json:"StitchID,omitempty"`
+	Image         string            `protobuf:"bytes,8,opt,name=Image,json=image" json:"Image,omitempty"`
+	Command       []string          `protobuf:"bytes,9,rep,name=Command,json=command" json:"Command,omitempty"`
+	Labels        []string          `protobuf:"bytes,10,rep,name=Labels,json=labels" json:"Labels,omitempty"`
+	Env           map[string]string `protobuf:"bytes,11,rep,name=Env,json=env" json:"Env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Volumes       []string          `protobuf:"bytes,12,rep,name=Volumes,json=volumes" json:"Volumes,omitempty"`
+	CPU           float64           `protobuf:"fixed64,13,opt,name=CPU,json=cPU" json:"CPU,omitempty"`
+	RAM           float64           `protobuf:"fixed64,14,opt,name=RAM,json=rAM" json:"RAM,omitempty"`
+	HealthCheck   *HealthCheck      `protobuf:"bytes,15,opt,name=HealthCheck,json=healthCheck" json:"HealthCheck,omitempty"`
+	RestartPolicy string            `protobuf:"bytes,16,opt,name=RestartPolicy,json=restartPolicy" json:"RestartPolicy,omitempty"`
+	Health        string            `protobuf:"bytes,17,opt,name=Health,json=health" json:"Health,omitempty"`
+	Restarts      int32             `protobuf:"varint,18,opt,name=Restarts,json=restarts" json:"Restarts,omitempty"`
+	Unplaced      string            `protobuf:"bytes,19,opt,name=Unplaced,json=unplaced" json:"Unplaced,omitempty"`
+}
+
+func (m *Container) Reset()                    { *m = Container{} }
+func (m *Container) String() string            { return proto.CompactTextString(m) }
+func (*Container) ProtoMessage()               {}
+func (*Container) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *Container) GetEnv() map[string]string {
+	if m != nil {
+		return m.Env
+	}
+	return nil
+}
+
+func (m *Container) GetHealthCheck() *HealthCheck {
+	if m != nil {
+		return m.HealthCheck
+	}
+	return nil
+}
+
+type HealthCheck struct {
+	Exec     []string `protobuf:"bytes,1,rep,name=Exec,json=exec" json:"Exec,omitempty"`
+	TCP      int32    `protobuf:"varint,2,opt,name=TCP,json=tCP" json:"TCP,omitempty"`
+	HTTP     int32    `protobuf:"varint,3,opt,name=HTTP,json=hTTP" json:"HTTP,omitempty"`
+	Path     string   `protobuf:"bytes,4,opt,name=Path,json=path" json:"Path,omitempty"`
+	Interval int32    `protobuf:"varint,5,opt,name=Interval,json=interval" json:"Interval,omitempty"`
+	Retries  int32    `protobuf:"varint,6,opt,name=Retries,json=retries" json:"Retries,omitempty"`
+}
+
+func (m *HealthCheck) Reset()                    { *m = HealthCheck{} }
+func (m *HealthCheck) String() string            { return proto.CompactTextString(m) }
+func (*HealthCheck) ProtoMessage()               {}
+func (*HealthCheck) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+type Etcd struct {
+	ID       int32    `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	EtcdIPs  []string `protobuf:"bytes,2,rep,name=EtcdIPs,json=etcdIPs" json:"EtcdIPs,omitempty"`
+	Leader   bool     `protobuf:"varint,3,opt,name=Leader,json=leader" json:"Leader,omitempty"`
+	LeaderIP string   `protobuf:"bytes,4,opt,name=LeaderIP,json=leaderIP" json:"LeaderIP,omitempty"`
+}
+
+func (m *Etcd) Reset()                    { *m = Etcd{} }
+func (m *Etcd) String() string            { return proto.CompactTextString(m) }
+func (*Etcd) ProtoMessage()               {}
+func (*Etcd) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+type Label struct {
+	ID           int32    `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	Label        string   `protobuf:"bytes,2,opt,name=Label,json=label" json:"Label,omitempty"`
+	IP           string   `protobuf:"bytes,3,opt,name=IP,json=iP" json:"IP,omitempty"`
+	ContainerIPs []string `protobuf:"bytes,4,rep,name=ContainerIPs,json=containerIPs" json:"ContainerIPs,omitempty"`
+	MultiHost    bool     `protobuf:"varint,5,opt,name=MultiHost,json=multiHost" json:"MultiHost,omitempty"`
+}
+
+func (m *Label) Reset()                    { *m = Label{} }
+func (m *Label) String() string            { return proto.CompactTextString(m) }
+func (*Label) ProtoMessage()               {}
+func (*Label) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+type Connection struct {
+	ID       int32  `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	From     string `protobuf:"bytes,2,opt,name=From,json=from" json:"From,omitempty"`
+	To       string `protobuf:"bytes,3,opt,name=To,json=to" json:"To,omitempty"`
+	MinPort  int32  `protobuf:"varint,4,opt,name=MinPort,json=minPort" json:"MinPort,omitempty"`
+	MaxPort  int32  `protobuf:"varint,5,opt,name=MaxPort,json=maxPort" json:"MaxPort,omitempty"`
+	Protocol string `protobuf:"bytes,6,opt,name=Protocol,json=protocol" json:"Protocol,omitempty"`
+}
+
+func (m *Connection) Reset()                    { *m = Connection{} }
+func (m *Connection) String() string            { return proto.CompactTextString(m) }
+func (*Connection) ProtoMessage()               {}
+func (*Connection) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+type Placement struct {
+	ID          int32  `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	TargetLabel string `protobuf:"bytes,2,opt,name=TargetLabel,json=targetLabel" json:"TargetLabel,omitempty"`
+	Exclusive   bool   `protobuf:"varint,3,opt,name=Exclusive,json=exclusive" json:"Exclusive,omitempty"`
+	OtherLabel  string `protobuf:"bytes,4,opt,name=OtherLabel,json=otherLabel" json:"OtherLabel,omitempty"`
+	Provider    string `protobuf:"bytes,5,opt,name=Provider,json=provider" json:"Provider,omitempty"`
+	Size        string `protobuf:"bytes,6,opt,name=Size,json=size" json:"Size,omitempty"`
+	Region      string `protobuf:"bytes,7,opt,name=Region,json=region" json:"Region,omitempty"`
+	Spread      string `protobuf:"bytes,8,opt,name=Spread,json=spread" json:"Spread,omitempty"`
+	MaxSkew     int32  `protobuf:"varint,9,opt,name=MaxSkew,json=maxSkew" json:"MaxSkew,omitempty"`
+	Rebalance   bool   `protobuf:"varint,10,opt,name=Rebalance,json=rebalance" json:"Rebalance,omitempty"`
+}
+
+func (m *Placement) Reset()                    { *m = Placement{} }
+func (m *Placement) String() string            { return proto.CompactTextString(m) }
+func (*Placement) ProtoMessage()               {}
+func (*Placement) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+type Minion struct {
+	ID            int32    `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	Self          bool     `protobuf:"varint,2,opt,name=Self,json=self" json:"Self,omitempty"`
+	Spec          string   `protobuf:"bytes,3,opt,name=Spec,json=spec" json:"Spec,omitempty"`
+	Role          string   `protobuf:"bytes,4,opt,name=Role,json=role" json:"Role,omitempty"`
+	PrivateIP     string   `protobuf:"bytes,5,opt,name=PrivateIP,json=privateIP" json:"PrivateIP,omitempty"`
+	Provider      string   `protobuf:"bytes,6,opt,name=Provider,json=provider" json:"Provider,omitempty"`
+	Size          string   `protobuf:"bytes,7,opt,name=Size,json=size" json:"Size,omitempty"`
+	Region        string   `protobuf:"bytes,8,opt,name=Region,json=region" json:"Region,omitempty"`
+	Volumes       []string `protobuf:"bytes,9,rep,name=Volumes,json=volumes" json:"Volumes,omitempty"`
+	Failed        bool     `protobuf:"varint,10,opt,name=Failed,json=failed" json:"Failed,omitempty"`
+	Unschedulable bool     `protobuf:"varint,11,opt,name=Unschedulable,json=unschedulable" json:"Unschedulable,omitempty"`
+	Draining      bool     `protobuf:"varint,12,opt,name=Draining,json=draining" json:"Draining,omitempty"`
+}
+
+func (m *Minion) Reset()                    { *m = Minion{} }
+func (m *Minion) String() string            { return proto.CompactTextString(m) }
+func (*Minion) ProtoMessage()               {}
+func (*Minion) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+type Cluster struct {
+	ID        int32    `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	Namespace string   `protobuf:"bytes,2,opt,name=Namespace,json=namespace" json:"Namespace,omitempty"`
+	Spec      string   `protobuf:"bytes,3,opt,name=Spec,json=spec" json:"Spec,omitempty"`
+	AdminACLs []string `protobuf:"bytes,4,rep,name=AdminACLs,json=adminACLs" json:"AdminACLs,omitempty"`
+}
+
+func (m *Cluster) Reset()                    { *m = Cluster{} }
+func (m *Cluster) String() string            { return proto.CompactTextString(m) }
+func (*Cluster) ProtoMessage()               {}
+func (*Cluster) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+type Rollout struct {
+	ID       int32  `protobuf:"varint,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	Label    string `protobuf:"bytes,2,opt,name=Label,json=label" json:"Label,omitempty"`
+	Replicas int32  `protobuf:"varint,3,opt,name=Replicas,json=replicas" json:"Replicas,omitempty"`
+	Updated  int32  `protobuf:"varint,4,opt,name=Updated,json=updated" json:"Updated,omitempty"`
+	Ready    int32  `protobuf:"varint,5,opt,name=Ready,json=ready" json:"Ready,omitempty"`
+	Old      int32  `protobuf:"varint,6,opt,name=Old,json=old" json:"Old,omitempty"`
+}
+
+func (m *Rollout) Reset()                    { *m = Rollout{} }
+func (m *Rollout) String() string            { return proto.CompactTextString(m) }
+func (*Rollout) ProtoMessage()               {}
+func (*Rollout) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
 func init() {
 	proto.RegisterType((*DBQuery)(nil), "DBQuery")
 	proto.RegisterType((*QueryReply)(nil), "QueryReply")
 	proto.RegisterType((*RunRequest)(nil), "RunRequest")
 	proto.RegisterType((*RunReply)(nil), "RunReply")
+	proto.RegisterType((*Secret)(nil), "Secret")
+	proto.RegisterType((*SetSecretReply)(nil), "SetSecretReply")
+	proto.RegisterType((*LogsRequest)(nil), "LogsRequest")
+	proto.RegisterType((*LogsReply)(nil), "LogsReply")
+	proto.RegisterType((*ExecRequest)(nil), "ExecRequest")
+	proto.RegisterType((*ExecReply)(nil), "ExecReply")
+	proto.RegisterType((*CordonRequest)(nil), "CordonRequest")
+	proto.RegisterType((*CordonReply)(nil), "CordonReply")
+	proto.RegisterType((*PlanReply)(nil), "PlanReply")
+	proto.RegisterType((*Machine)(nil), "Machine")
+	proto.RegisterType((*Container)(nil), "Container")
+	proto.RegisterType((*HealthCheck)(nil), "HealthCheck")
+	proto.RegisterType((*Etcd)(nil), "Etcd")
+	proto.RegisterType((*Label)(nil), "Label")
+	proto.RegisterType((*Connection)(nil), "Connection")
+	proto.RegisterType((*Placement)(nil), "Placement")
+	proto.RegisterType((*Minion)(nil), "Minion")
+	proto.RegisterType((*Cluster)(nil), "Cluster")
+	proto.RegisterType((*Rollout)(nil), "Rollout")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -91,6 +512,12 @@ const _ = grpc.SupportPackageIsVersion3
 type APIClient interface {
 	Query(ctx context.Context, in *DBQuery, opts ...grpc.CallOption) (*QueryReply, error)
 	Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunReply, error)
+	Plan(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*PlanReply, error)
+	Watch(ctx context.Context, in *DBQuery, opts ...grpc.CallOption) (API_WatchClient, error)
+	SetSecret(ctx context.Context, in *Secret, opts ...grpc.CallOption) (*SetSecretReply, error)
+	ContainerLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (API_ContainerLogsClient, error)
+	Exec(ctx context.Context, opts ...grpc.CallOption) (API_ExecClient, error)
+	Cordon(ctx context.Context, in *CordonRequest, opts ...grpc.CallOption) (*CordonReply, error)
 }
 
 type aPIClient struct {
@@ -119,11 +546,139 @@ func (c *aPIClient) Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOp
 	return out, nil
 }
 
+func (c *aPIClient) Plan(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*PlanReply, error) {
+	out := new(PlanReply)
+	err := grpc.Invoke(ctx, "/API/Plan", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) Watch(ctx context.Context, in *DBQuery, opts ...grpc.CallOption) (API_WatchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[0], c.cc, "/API/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_WatchClient interface {
+	Recv() (*QueryReply, error)
+	grpc.ClientStream
+}
+
+type aPIWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIWatchClient) Recv() (*QueryReply, error) {
+	m := new(QueryReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) SetSecret(ctx context.Context, in *Secret, opts ...grpc.CallOption) (*SetSecretReply, error) {
+	out := new(SetSecretReply)
+	err := grpc.Invoke(ctx, "/API/SetSecret", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ContainerLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (API_ContainerLogsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[1], c.cc, "/API/ContainerLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIContainerLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_ContainerLogsClient interface {
+	Recv() (*LogsReply, error)
+	grpc.ClientStream
+}
+
+type aPIContainerLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIContainerLogsClient) Recv() (*LogsReply, error) {
+	m := new(LogsReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) Exec(ctx context.Context, opts ...grpc.CallOption) (API_ExecClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[2], c.cc, "/API/Exec", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIExecClient{stream}
+	return x, nil
+}
+
+type API_ExecClient interface {
+	Send(*ExecRequest) error
+	Recv() (*ExecReply, error)
+	grpc.ClientStream
+}
+
+type aPIExecClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIExecClient) Send(m *ExecRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *aPIExecClient) Recv() (*ExecReply, error) {
+	m := new(ExecReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) Cordon(ctx context.Context, in *CordonRequest, opts ...grpc.CallOption) (*CordonReply, error) {
+	out := new(CordonReply)
+	err := grpc.Invoke(ctx, "/API/Cordon", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for API service
 
 type APIServer interface {
 	Query(context.Context, *DBQuery) (*QueryReply, error)
 	Run(context.Context, *RunRequest) (*RunReply, error)
+	Plan(context.Context, *RunRequest) (*PlanReply, error)
+	Watch(*DBQuery, API_WatchServer) error
+	SetSecret(context.Context, *Secret) (*SetSecretReply, error)
+	ContainerLogs(*LogsRequest, API_ContainerLogsServer) error
+	Exec(API_ExecServer) error
+	Cordon(context.Context, *CordonRequest) (*CordonReply, error)
 }
 
 func RegisterAPIServer(s *grpc.Server, srv APIServer) {
@@ -166,6 +721,128 @@ func _API_Run_Handler(srv interface{}, ctx context.Context, dec func(interface{}
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_Plan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Plan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/API/Plan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Plan(ctx, req.(*RunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DBQuery)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).Watch(m, &aPIWatchServer{stream})
+}
+
+type API_WatchServer interface {
+	Send(*QueryReply) error
+	grpc.ServerStream
+}
+
+type aPIWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIWatchServer) Send(m *QueryReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_SetSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Secret)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).SetSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/API/SetSecret",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).SetSecret(ctx, req.(*Secret))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ContainerLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).ContainerLogs(m, &aPIContainerLogsServer{stream})
+}
+
+type API_ContainerLogsServer interface {
+	Send(*LogsReply) error
+	grpc.ServerStream
+}
+
+type aPIContainerLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIContainerLogsServer) Send(m *LogsReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_Exec_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(APIServer).Exec(&aPIExecServer{stream})
+}
+
+type API_ExecServer interface {
+	Send(*ExecReply) error
+	Recv() (*ExecRequest, error)
+	grpc.ServerStream
+}
+
+type aPIExecServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIExecServer) Send(m *ExecReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *aPIExecServer) Recv() (*ExecRequest, error) {
+	m := new(ExecRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _API_Cordon_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CordonRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Cordon(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/API/Cordon",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Cordon(ctx, req.(*CordonRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _API_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "API",
 	HandlerType: (*APIServer)(nil),
@@ -178,25 +855,151 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Run",
 			Handler:    _API_Run_Handler,
 		},
+		{
+			MethodName: "Plan",
+			Handler:    _API_Plan_Handler,
+		},
+		{
+			MethodName: "SetSecret",
+			Handler:    _API_SetSecret_Handler,
+		},
+		{
+			MethodName: "Cordon",
+			Handler:    _API_Cordon_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _API_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ContainerLogs",
+			Handler:       _API_ContainerLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Exec",
+			Handler:       _API_Exec_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: fileDescriptor0,
 }
 
 func init() { proto.RegisterFile("pb/pb.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 185 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0xe2, 0x2e, 0x48, 0xd2, 0x2f,
-	0x48, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x57, 0x92, 0xe7, 0x62, 0x77, 0x71, 0x0a, 0x2c, 0x4d,
-	0x2d, 0xaa, 0x14, 0x12, 0xe1, 0x62, 0x0d, 0x49, 0x4c, 0xca, 0x49, 0x95, 0x60, 0x54, 0x60, 0xd4,
-	0xe0, 0x0c, 0x62, 0x2d, 0x01, 0x71, 0x94, 0x8c, 0xb8, 0xb8, 0xc0, 0xd2, 0x41, 0xa9, 0x05, 0x39,
-	0x95, 0x42, 0x2a, 0x5c, 0xbc, 0x60, 0x35, 0xce, 0xf9, 0x79, 0x25, 0xa9, 0x79, 0x25, 0xc5, 0x50,
-	0xb5, 0xbc, 0x25, 0xc8, 0x82, 0x4a, 0x2a, 0x5c, 0x5c, 0x41, 0xa5, 0x79, 0x41, 0xa9, 0x85, 0xa5,
-	0xa9, 0xc5, 0x25, 0x42, 0x62, 0x5c, 0x6c, 0xc1, 0x25, 0x99, 0x25, 0xc9, 0x19, 0x50, 0xc5, 0x6c,
-	0xc5, 0x60, 0x9e, 0x12, 0x17, 0x17, 0x07, 0x58, 0x15, 0xd0, 0x5c, 0x23, 0x0f, 0x2e, 0x66, 0xc7,
-	0x00, 0x4f, 0x21, 0x05, 0x2e, 0x56, 0x88, 0x5b, 0x38, 0xf4, 0xa0, 0xae, 0x92, 0xe2, 0xd6, 0x43,
-	0x58, 0xaf, 0xc4, 0x20, 0x24, 0xcf, 0xc5, 0x0c, 0xd4, 0x24, 0xc4, 0xad, 0x87, 0xb0, 0x40, 0x8a,
-	0x53, 0x0f, 0x66, 0x8e, 0x12, 0x43, 0x12, 0x1b, 0xd8, 0x5f, 0xc6, 0x80, 0x00, 0x00, 0x00, 0xff,
-	0xff, 0x7c, 0xaf, 0xc0, 0xc2, 0xe6, 0x00, 0x00, 0x00,
+	// 1733 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x57, 0x5f, 0x6f, 0xe4, 0x48,
+	0x11, 0xdf, 0xf9, 0xe3, 0x7f, 0xe5, 0x49, 0x36, 0xeb, 0x5b, 0xad, 0xac, 0xb0, 0xba, 0xcb, 0x99,
+	0x03, 0x22, 0xc4, 0x99, 0x53, 0x40, 0x27, 0xc4, 0x5b, 0x76, 0x92, 0x53, 0x06, 0x36, 0xac, 0xe9,
+	0x99, 0xdc, 0xea, 0x1e, 0x1d, 0xbb, 0x93, 0x69, 0xc5, 0xe3, 0x36, 0x76, 0xcf, 0x6c, 0x72, 0x2f,
+	0x7c, 0x02, 0x24, 0x10, 0xdf, 0x82, 0x07, 0xde, 0xef, 0xe3, 0xf0, 0xcc, 0x67, 0x40, 0x42, 0x55,
+	0xdd, 0xf6, 0xcc, 0x64, 0x76, 0xe1, 0xee, 0xcd, 0xbf, 0x5f, 0x95, 0xab, 0xab, 0xab, 0xab, 0xab,
+	0xaa, 0xc1, 0xaf, 0xae, 0x7f, 0x59, 0x5d, 0xc7, 0x55, 0x2d, 0x95, 0x8c, 0x3e, 0x01, 0xe7, 0xec,
+	0xd5, 0x1f, 0x97, 0xbc, 0x7e, 0x08, 0x9e, 0x83, 0x35, 0x4b, 0xaf, 0x0b, 0x1e, 0xf6, 0x8e, 0x7a,
+	0xc7, 0x1e, 0xb3, 0x14, 0x82, 0xe8, 0xdf, 0x7d, 0x00, 0x92, 0x33, 0x5e, 0x15, 0x0f, 0xc1, 0x67,
+	0xe0, 0x5e, 0xa6, 0xd9, 0x5c, 0x94, 0xbc, 0x09, 0xfb, 0x47, 0x83, 0x63, 0xff, 0xc4, 0x8d, 0x0d,
+	0xc1, 0xdc, 0x85, 0x91, 0x04, 0x3f, 0x07, 0x18, 0xcb, 0x52, 0xa5, 0xa2, 0xe4, 0x75, 0x13, 0x0e,
+	0x48, 0x0f, 0xe2, 0x8e, 0x62, 0x90, 0x75, 0xd2, 0xe0, 0x47, 0x60, 0x9d, 0xab, 0x2c, 0x6f, 0xc2,
+	0x21, 0xa9, 0x59, 0x31, 0x22, 0x66, 0x71, 0xe4, 0x82, 0x8f, 0xc1, 0x7e, 0x9d, 0x5e, 0xf3, 0xa2,
+	0x09, 0x2d, 0x92, 0xda, 0x31, 0x41, 0x66, 0x17, 0xc4, 0x06, 0x9f, 0x83, 0x3f, 0x96, 0x65, 0xc9,
+	0x33, 0x25, 0x64, 0xd9, 0x84, 0x36, 0x29, 0xf9, 0xf1, 0x9a, 0x63, 0x7e, 0xb6, 0x96, 0xa3, 0x5f,
+	0x49, 0x91, 0x66, 0x7c, 0xc1, 0x4b, 0xd5, 0x84, 0x8e, 0xf1, 0xab, 0xa3, 0x18, 0x54, 0x9d, 0x34,
+	0xf8, 0x14, 0x9c, 0x4b, 0x51, 0x92, 0x59, 0x97, 0x14, 0x9d, 0x58, 0x63, 0xe6, 0x2c, 0x34, 0x8f,
+	0xc1, 0x18, 0x17, 0xcb, 0x46, 0xe1, 0x26, 0x3d, 0x13, 0x0c, 0x43, 0x30, 0x37, 0x33, 0x12, 0xd4,
+	0x62, 0xb2, 0x28, 0xe4, 0x52, 0x35, 0x21, 0x18, 0x2d, 0x43, 0x30, 0xb7, 0x36, 0x92, 0xdf, 0x0d,
+	0xdd, 0xde, 0x41, 0x3f, 0x7a, 0x05, 0xc0, 0x96, 0x25, 0xe3, 0x7f, 0x5a, 0xf2, 0x46, 0x05, 0x2f,
+	0xc0, 0x9e, 0x2a, 0xa1, 0xb2, 0xb9, 0x39, 0x12, 0xbb, 0x21, 0x14, 0xbc, 0x04, 0xef, 0x0f, 0xe9,
+	0x82, 0x37, 0x55, 0x9a, 0xf1, 0xb0, 0x4f, 0x22, 0xaf, 0x6c, 0x89, 0x08, 0xc0, 0x25, 0x1b, 0x55,
+	0xf1, 0x10, 0x9d, 0x80, 0x3d, 0xe5, 0x59, 0xcd, 0x55, 0x10, 0xc0, 0x10, 0xff, 0x31, 0x96, 0x86,
+	0xa8, 0x8e, 0x27, 0xfe, 0x75, 0x5a, 0x2c, 0x5b, 0x1b, 0xd6, 0x0a, 0x41, 0x74, 0x00, 0xfb, 0x53,
+	0xae, 0xf4, 0x6f, 0xda, 0xca, 0x5b, 0xf0, 0x5f, 0xcb, 0xdb, 0xa6, 0x75, 0xeb, 0x10, 0x5c, 0xed,
+	0xd6, 0xe4, 0x8c, 0xcc, 0x59, 0xcc, 0x6d, 0x0c, 0x46, 0x97, 0xbf, 0xc2, 0x2d, 0xbd, 0x23, 0x9b,
+	0x2e, 0xb3, 0x6f, 0x08, 0xe1, 0x52, 0x53, 0x51, 0x66, 0x3c, 0x1c, 0x1c, 0xf5, 0x8e, 0x07, 0xcc,
+	0x6a, 0x10, 0x44, 0x3f, 0x06, 0x4f, 0x1b, 0xc6, 0xd4, 0x7a, 0x01, 0xf6, 0x9b, 0xa5, 0xaa, 0x96,
+	0x8a, 0x8c, 0x8e, 0x98, 0x2d, 0x09, 0x45, 0xdf, 0xf5, 0xc0, 0x3f, 0xbf, 0xe7, 0xd9, 0xf7, 0x59,
+	0x3e, 0x04, 0x67, 0x2c, 0x17, 0x8b, 0xb4, 0xcc, 0x29, 0x3b, 0x3d, 0xe6, 0x64, 0x1a, 0x06, 0x07,
+	0x30, 0x98, 0xcd, 0xbe, 0xa1, 0xe5, 0x5d, 0x36, 0x50, 0xb3, 0x6f, 0xc8, 0x25, 0x95, 0x8b, 0x32,
+	0x1c, 0xd2, 0x72, 0x56, 0x83, 0x20, 0xf8, 0x18, 0x60, 0x5c, 0xc8, 0x86, 0x6b, 0x91, 0x45, 0xea,
+	0x90, 0x75, 0x0c, 0x7a, 0x79, 0xc1, 0xc5, 0xed, 0x5c, 0x85, 0x36, 0xad, 0x6d, 0xcf, 0x09, 0xa1,
+	0xb5, 0xb7, 0x22, 0x57, 0xf3, 0xd0, 0x21, 0xda, 0x7a, 0x87, 0x20, 0x92, 0xe0, 0x69, 0xd7, 0xcd,
+	0x06, 0xa7, 0x2a, 0x97, 0xeb, 0x0d, 0x36, 0x84, 0x0c, 0xcf, 0xeb, 0x3a, 0xec, 0x77, 0x3c, 0xaf,
+	0x6b, 0xe4, 0xcf, 0xef, 0x85, 0xe2, 0xb9, 0xf1, 0xda, 0xe6, 0x84, 0x30, 0x00, 0xc8, 0x8f, 0x65,
+	0xce, 0xc9, 0x77, 0x8b, 0xb9, 0xdc, 0xe0, 0x48, 0xc0, 0xde, 0x58, 0xd6, 0xb9, 0xec, 0x72, 0xe8,
+	0x25, 0x78, 0xe6, 0x7e, 0x76, 0xe1, 0xf2, 0x16, 0x2d, 0x11, 0x7c, 0x06, 0x7b, 0x57, 0x65, 0x93,
+	0xcd, 0x79, 0xbe, 0x2c, 0xe8, 0xee, 0xeb, 0x53, 0xdb, 0x5b, 0x6e, 0x92, 0xb8, 0xb7, 0xb3, 0x3a,
+	0x15, 0xa5, 0xf1, 0xc3, 0xca, 0x11, 0x44, 0x7b, 0xe0, 0xb7, 0x4b, 0x61, 0x92, 0xfc, 0xab, 0x07,
+	0x5e, 0x52, 0xa4, 0x1a, 0x05, 0xbf, 0x80, 0xd1, 0x2b, 0x29, 0xd5, 0x07, 0x6b, 0xc5, 0xe8, 0x7a,
+	0x43, 0x1a, 0x7c, 0x09, 0xcf, 0x66, 0xbc, 0x5e, 0x88, 0x32, 0x55, 0xbc, 0xfb, 0x65, 0xf0, 0xe8,
+	0x97, 0x67, 0xea, 0xb1, 0x4a, 0xf0, 0x6b, 0x78, 0x3a, 0x55, 0x69, 0xad, 0x36, 0x8a, 0xcd, 0x70,
+	0xa7, 0xd8, 0x3c, 0x6d, 0xb6, 0x55, 0x82, 0x13, 0xd8, 0x9f, 0x2a, 0x59, 0x6d, 0xfc, 0x64, 0xed,
+	0xfc, 0xb4, 0xdf, 0x6c, 0x69, 0x98, 0xeb, 0xf9, 0x9f, 0x3e, 0x38, 0x66, 0xf1, 0x60, 0x1f, 0xfa,
+	0x5d, 0x44, 0xfb, 0xe2, 0xec, 0x7f, 0x5f, 0x4a, 0xbc, 0x7e, 0x4c, 0x16, 0x3a, 0xfd, 0x3d, 0x36,
+	0xac, 0x65, 0xc1, 0xf1, 0x1c, 0x93, 0x5a, 0xae, 0x44, 0xce, 0x6b, 0x3a, 0x47, 0x8f, 0xb9, 0x95,
+	0xc1, 0x78, 0xf6, 0x8c, 0xdf, 0x0a, 0xa9, 0x53, 0xd0, 0x63, 0x76, 0x4d, 0x08, 0xed, 0x4c, 0xc5,
+	0xb7, 0x9c, 0x92, 0xcf, 0x63, 0xc3, 0x46, 0x7c, 0x4b, 0x76, 0xce, 0x44, 0x73, 0x47, 0xbc, 0xce,
+	0x3e, 0x37, 0x37, 0x18, 0x2f, 0xc4, 0x74, 0x7a, 0xf1, 0x7b, 0xfe, 0xa0, 0xab, 0x98, 0xc7, 0x9c,
+	0x46, 0x43, 0xba, 0x2a, 0x85, 0x5c, 0xe6, 0x93, 0xb3, 0xd0, 0x23, 0x63, 0x4e, 0xa6, 0x21, 0xf9,
+	0xb5, 0xbc, 0x2e, 0x44, 0x36, 0x49, 0x42, 0x30, 0x7e, 0x19, 0x8c, 0xbb, 0x4c, 0x6a, 0xb1, 0x4a,
+	0x15, 0x9f, 0x24, 0xa1, 0xaf, 0x77, 0x59, 0xb5, 0x04, 0x4a, 0x4d, 0xe9, 0xe5, 0x79, 0x38, 0xa2,
+	0x64, 0xf1, 0xb2, 0x96, 0xd8, 0x4d, 0xb6, 0xbd, 0xf7, 0x25, 0x1b, 0xee, 0x06, 0xf3, 0x4b, 0x94,
+	0xb7, 0xe1, 0x3e, 0x29, 0xb8, 0xb9, 0xc1, 0xd1, 0x3f, 0x86, 0xe0, 0x75, 0x87, 0xb2, 0x73, 0x02,
+	0x07, 0x30, 0x48, 0x44, 0x4e, 0xb1, 0xb7, 0xd8, 0xa0, 0x12, 0x39, 0x69, 0x24, 0x26, 0xe6, 0x7d,
+	0x91, 0xa0, 0xc6, 0x65, 0x9a, 0x99, 0x60, 0x0f, 0x16, 0x69, 0x86, 0x71, 0xd6, 0x55, 0xbd, 0x8d,
+	0xb3, 0xae, 0xed, 0xe4, 0x85, 0xcc, 0xee, 0x78, 0x3d, 0x39, 0x33, 0xb1, 0x76, 0x73, 0x83, 0xb7,
+	0x0a, 0x90, 0xf3, 0xa8, 0x00, 0x3d, 0x07, 0x6b, 0xb2, 0x48, 0x6f, 0x79, 0xe8, 0xea, 0x92, 0x2a,
+	0x10, 0x6c, 0x96, 0x25, 0x6f, 0xbb, 0x2c, 0xbd, 0xe8, 0x1a, 0x1c, 0x90, 0xa0, 0x6d, 0x6c, 0x3f,
+	0x81, 0xc1, 0x79, 0xb9, 0x0a, 0x7d, 0x4a, 0xcc, 0x8f, 0xd6, 0x89, 0x19, 0x9f, 0x97, 0xab, 0xf3,
+	0x52, 0xd5, 0x0f, 0x6c, 0xc0, 0xcb, 0x15, 0x1a, 0xfe, 0x5a, 0x16, 0xcb, 0x05, 0x6f, 0xc2, 0x91,
+	0x36, 0xbc, 0xd2, 0x10, 0xb7, 0x3a, 0x4e, 0xae, 0x28, 0xc4, 0x3d, 0x36, 0xc8, 0x92, 0x2b, 0x64,
+	0xd8, 0xe9, 0x25, 0xc5, 0xb4, 0xc7, 0x06, 0xf5, 0xe9, 0x65, 0x10, 0x83, 0x7f, 0xc1, 0xd3, 0x42,
+	0xcd, 0xc7, 0x73, 0x9e, 0xdd, 0x85, 0x4f, 0x8f, 0x7a, 0xc7, 0xfe, 0xc9, 0x28, 0xde, 0xe0, 0x98,
+	0x3f, 0x5f, 0x03, 0x3c, 0x40, 0xc6, 0xe9, 0x36, 0x25, 0xb2, 0x10, 0xd9, 0x43, 0x78, 0x40, 0x9b,
+	0xdc, 0xab, 0x37, 0x49, 0x5d, 0x21, 0xf1, 0xa7, 0xf0, 0x99, 0x0e, 0xa9, 0x36, 0x81, 0x61, 0x33,
+	0x7f, 0x37, 0x61, 0xa0, 0xc3, 0x66, 0x7e, 0x6c, 0x50, 0x76, 0x55, 0x52, 0xf3, 0xcd, 0xc3, 0x8f,
+	0x74, 0xb8, 0x97, 0x06, 0x1f, 0x7e, 0x09, 0x6e, 0xbb, 0x69, 0xdc, 0xc3, 0x1d, 0x7f, 0x30, 0x4d,
+	0x0c, 0x3f, 0x31, 0xe0, 0xab, 0x9d, 0x1e, 0xf6, 0xdb, 0xfe, 0x6f, 0x7a, 0xd1, 0xdf, 0x7a, 0x5b,
+	0xdb, 0xc3, 0xab, 0x83, 0xb5, 0x38, 0xec, 0x51, 0xa0, 0x86, 0xfc, 0x9e, 0x67, 0xd4, 0x15, 0xc6,
+	0x49, 0x9b, 0x32, 0x6a, 0x9c, 0xa0, 0xd6, 0xc5, 0x6c, 0xa6, 0x93, 0xc6, 0x62, 0xc3, 0xf9, 0x6c,
+	0x46, 0x5c, 0x92, 0xaa, 0xb9, 0xc9, 0x9b, 0x61, 0x95, 0xea, 0xdd, 0x4c, 0x4a, 0xc5, 0xeb, 0x55,
+	0x5a, 0x50, 0xea, 0x58, 0xcc, 0x15, 0x06, 0xe3, 0xa9, 0x30, 0xae, 0x6a, 0xc1, 0x1b, 0xd3, 0x24,
+	0x9c, 0x5a, 0xc3, 0x28, 0x87, 0x21, 0x8e, 0x37, 0x3b, 0xa9, 0x1b, 0x82, 0x83, 0xfc, 0x24, 0x69,
+	0xda, 0xbe, 0xc5, 0x35, 0xa4, 0x04, 0xe1, 0x29, 0x96, 0x08, 0xd3, 0x04, 0x0a, 0x42, 0xb8, 0xbe,
+	0xe6, 0x27, 0x49, 0x5b, 0x3c, 0x0a, 0x83, 0xa3, 0x3f, 0x83, 0x45, 0x49, 0xb5, 0xb3, 0xcc, 0x73,
+	0x23, 0x68, 0x83, 0x55, 0x74, 0x5a, 0x9b, 0xb7, 0x24, 0x82, 0x51, 0x97, 0x6f, 0x93, 0x44, 0x97,
+	0x54, 0x8f, 0x8d, 0xb2, 0x0d, 0x8e, 0xda, 0xca, 0xb2, 0x50, 0xe2, 0x42, 0x36, 0xca, 0x74, 0x49,
+	0x6f, 0xd1, 0x12, 0xd1, 0x5f, 0x7b, 0x34, 0x00, 0x9a, 0xb9, 0x6b, 0xc7, 0x8d, 0x00, 0x86, 0x5f,
+	0xd5, 0x72, 0x61, 0xbc, 0x18, 0xde, 0xd4, 0x72, 0x81, 0x3a, 0x33, 0xd9, 0x3a, 0xa1, 0x24, 0x46,
+	0xe4, 0x52, 0x94, 0x89, 0xac, 0x95, 0xe9, 0x71, 0xce, 0x42, 0x43, 0x92, 0xa4, 0xf7, 0x24, 0xb1,
+	0x8c, 0x44, 0x43, 0x53, 0x50, 0x95, 0xcc, 0x64, 0xd1, 0x5e, 0xda, 0xca, 0xe0, 0xe8, 0xef, 0x7d,
+	0x6a, 0x4f, 0x7a, 0xba, 0xdb, 0xf1, 0xe8, 0x08, 0xfc, 0x59, 0x5a, 0xdf, 0x72, 0xb5, 0x19, 0x1e,
+	0x5f, 0xad, 0x29, 0xdc, 0xf0, 0xf9, 0x3d, 0xce, 0x74, 0x62, 0xc5, 0xcd, 0x51, 0x78, 0xbc, 0x25,
+	0x70, 0x6a, 0x78, 0xa3, 0xe6, 0xbc, 0xd6, 0xbf, 0xeb, 0xf3, 0x00, 0xd9, 0x31, 0x5b, 0xa5, 0xde,
+	0x7a, 0x54, 0xea, 0xdf, 0x57, 0xd2, 0xd7, 0xe5, 0xdf, 0xd9, 0x2a, 0xff, 0x38, 0x2a, 0x54, 0x35,
+	0x4f, 0x73, 0x53, 0x5f, 0xec, 0x86, 0x90, 0x89, 0xc9, 0xf4, 0x8e, 0xbf, 0x0b, 0xbd, 0x2e, 0x26,
+	0x08, 0xd1, 0x6f, 0xc6, 0xaf, 0xd3, 0x22, 0xc5, 0xe1, 0x0b, 0xb4, 0xdf, 0x75, 0x4b, 0x44, 0xff,
+	0xec, 0xb7, 0xf5, 0xef, 0x7d, 0x87, 0x34, 0xe5, 0xc5, 0x8d, 0x99, 0x08, 0x86, 0x0d, 0x2f, 0x6e,
+	0x88, 0xab, 0x78, 0xd6, 0x76, 0xb1, 0xa6, 0xe2, 0x59, 0xd7, 0xd9, 0x86, 0x1b, 0x9d, 0x6d, 0xab,
+	0x4b, 0x58, 0x8f, 0xbb, 0xc4, 0x66, 0x30, 0xec, 0x0f, 0x04, 0xc3, 0x79, 0x6f, 0x30, 0xdc, 0xad,
+	0x60, 0x6c, 0x14, 0x3f, 0x6f, 0xbb, 0xf8, 0xe1, 0x14, 0x9a, 0x8a, 0x82, 0xe7, 0x66, 0xc7, 0xf6,
+	0x0d, 0xa1, 0xdd, 0x0e, 0xe4, 0xff, 0xbf, 0x0e, 0x34, 0x7a, 0xd4, 0x81, 0x04, 0x76, 0x4d, 0x1a,
+	0xec, 0x7f, 0xf8, 0x00, 0xb0, 0x13, 0xba, 0x97, 0xe0, 0x9d, 0xe6, 0x0b, 0x51, 0x9e, 0x8e, 0x5f,
+	0xb7, 0xb7, 0xcc, 0x4b, 0x5b, 0x22, 0xfa, 0x4b, 0x0f, 0x1c, 0xf3, 0x4e, 0xf8, 0x9e, 0x17, 0x99,
+	0x2a, 0x6c, 0x55, 0x88, 0x2c, 0x6d, 0x4c, 0xfd, 0x72, 0x6b, 0x83, 0x31, 0x58, 0x57, 0x55, 0x9e,
+	0x62, 0x63, 0x36, 0xf7, 0x69, 0xa9, 0x21, 0xda, 0x62, 0x3c, 0xcd, 0x1f, 0xcc, 0x6d, 0xb2, 0x30,
+	0xa1, 0xa8, 0xd2, 0xbe, 0x29, 0x72, 0x53, 0xbf, 0x06, 0xb2, 0xc8, 0x4f, 0xbe, 0xeb, 0xc3, 0xe0,
+	0x34, 0x99, 0x04, 0x47, 0x60, 0xe9, 0x07, 0xa3, 0x1b, 0x9b, 0xa7, 0xe3, 0xa1, 0x1f, 0xaf, 0x9f,
+	0x88, 0xd1, 0x93, 0xe0, 0x13, 0x18, 0xb0, 0x65, 0x19, 0xf8, 0xf1, 0xfa, 0x2d, 0x73, 0xe8, 0xc5,
+	0xdd, 0xa3, 0xe4, 0x49, 0xf0, 0x29, 0x0c, 0x71, 0x54, 0xdc, 0xd6, 0xa0, 0x87, 0x58, 0xa7, 0x12,
+	0x81, 0xf5, 0x36, 0xc5, 0xc7, 0xce, 0x87, 0x56, 0xf9, 0xa2, 0x17, 0xfc, 0x0c, 0xbc, 0xee, 0xa5,
+	0x12, 0x38, 0xb1, 0xfe, 0x38, 0x7c, 0x1a, 0x3f, 0x7a, 0xbe, 0x3c, 0x09, 0x3e, 0xc7, 0xa9, 0xd8,
+	0x54, 0x2f, 0x7c, 0x70, 0x04, 0xa3, 0x78, 0xe3, 0x41, 0x73, 0x08, 0x71, 0xf7, 0x0a, 0x21, 0xbb,
+	0x3f, 0xd5, 0x9d, 0x22, 0x18, 0xc5, 0x1b, 0xef, 0x8e, 0x43, 0x88, 0xbb, 0x51, 0x3e, 0x7a, 0x72,
+	0xdc, 0xfb, 0xa2, 0x17, 0x1c, 0x83, 0xad, 0x27, 0xe0, 0x60, 0x3f, 0xde, 0x9a, 0xba, 0x0f, 0x47,
+	0xf1, 0xe6, 0x68, 0xfc, 0xe4, 0xda, 0xa6, 0x3a, 0xf4, 0xab, 0xff, 0x0e, 0x00, 0x03, 0x59, 0x38,
+	0x3a, 0x7c, 0x0f, 0x00, 0x00,
 }
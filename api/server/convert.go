@@ -0,0 +1,185 @@
+package server
+
+import (
+	"github.com/NetSys/quilt/api/pb"
+	"github.com/NetSys/quilt/db"
+)
+
+func machinesToPB(machines []db.Machine) []*pb.Machine {
+	var res []*pb.Machine
+	for _, m := range machines {
+		res = append(res, &pb.Machine{
+			ID:        int32(m.ID),
+			Namespace: m.Namespace,
+			Role:      string(m.Role),
+			Provider:  string(m.Provider),
+			Region:    m.Region,
+			Size:      m.Size,
+			DiskSize:  int32(m.DiskSize),
+			SSHKeys:   m.SSHKeys,
+			CloudID:   m.CloudID,
+			PublicIP:  m.PublicIP,
+			PrivateIP: m.PrivateIP,
+			Connected: m.Connected,
+
+			Unschedulable: m.Unschedulable,
+			Draining:      m.Draining,
+		})
+	}
+	return res
+}
+
+func containersToPB(containers []db.Container) []*pb.Container {
+	var res []*pb.Container
+	for _, c := range containers {
+		res = append(res, &pb.Container{
+			ID:       int32(c.ID),
+			Pid:      int32(c.Pid),
+			IP:       c.IP,
+			Mac:      c.Mac,
+			Minion:   c.Minion,
+			DockerID: c.DockerID,
+			StitchID: int32(c.StitchID),
+			Image:    c.Image,
+			Command:  c.Command,
+			Labels:   c.Labels,
+			Env:      c.Env,
+			Volumes:  c.Volumes,
+			CPU:      c.CPU,
+			RAM:      c.RAM,
+
+			HealthCheck:   healthCheckToPB(c.HealthCheck),
+			RestartPolicy: c.RestartPolicy,
+			Health:        c.Health,
+			Restarts:      int32(c.Restarts),
+			Unplaced:      c.Unplaced,
+		})
+	}
+	return res
+}
+
+func healthCheckToPB(hc db.HealthCheck) *pb.HealthCheck {
+	if hc.Empty() {
+		return nil
+	}
+
+	return &pb.HealthCheck{
+		Exec:     hc.Exec,
+		TCP:      int32(hc.TCP),
+		HTTP:     int32(hc.HTTP),
+		Path:     hc.Path,
+		Interval: int32(hc.Interval),
+		Retries:  int32(hc.Retries),
+	}
+}
+
+func etcdsToPB(etcds []db.Etcd) []*pb.Etcd {
+	var res []*pb.Etcd
+	for _, e := range etcds {
+		res = append(res, &pb.Etcd{
+			ID:       int32(e.ID),
+			EtcdIPs:  e.EtcdIPs,
+			Leader:   e.Leader,
+			LeaderIP: e.LeaderIP,
+		})
+	}
+	return res
+}
+
+func labelsToPB(labels []db.Label) []*pb.Label {
+	var res []*pb.Label
+	for _, l := range labels {
+		res = append(res, &pb.Label{
+			ID:           int32(l.ID),
+			Label:        l.Label,
+			IP:           l.IP,
+			ContainerIPs: l.ContainerIPs,
+			MultiHost:    l.MultiHost,
+		})
+	}
+	return res
+}
+
+func connectionsToPB(connections []db.Connection) []*pb.Connection {
+	var res []*pb.Connection
+	for _, c := range connections {
+		res = append(res, &pb.Connection{
+			ID:       int32(c.ID),
+			From:     c.From,
+			To:       c.To,
+			MinPort:  int32(c.MinPort),
+			MaxPort:  int32(c.MaxPort),
+			Protocol: c.Protocol,
+		})
+	}
+	return res
+}
+
+func placementsToPB(placements []db.Placement) []*pb.Placement {
+	var res []*pb.Placement
+	for _, p := range placements {
+		res = append(res, &pb.Placement{
+			ID:          int32(p.ID),
+			TargetLabel: p.TargetLabel,
+			Exclusive:   p.Exclusive,
+			OtherLabel:  p.OtherLabel,
+			Provider:    p.Provider,
+			Size:        p.Size,
+			Region:      p.Region,
+			Spread:      p.Spread,
+			MaxSkew:     int32(p.MaxSkew),
+			Rebalance:   p.Rebalance,
+		})
+	}
+	return res
+}
+
+func minionsToPB(minions []db.Minion) []*pb.Minion {
+	var res []*pb.Minion
+	for _, m := range minions {
+		res = append(res, &pb.Minion{
+			ID:        int32(m.ID),
+			Self:      m.Self,
+			Spec:      m.Spec,
+			Role:      string(m.Role),
+			PrivateIP: m.PrivateIP,
+			Provider:  m.Provider,
+			Size:      m.Size,
+			Region:    m.Region,
+			Volumes:   m.Volumes,
+			Failed:    m.Failed,
+
+			Unschedulable: m.Unschedulable,
+			Draining:      m.Draining,
+		})
+	}
+	return res
+}
+
+func rolloutsToPB(rollouts []db.Rollout) []*pb.Rollout {
+	var res []*pb.Rollout
+	for _, r := range rollouts {
+		res = append(res, &pb.Rollout{
+			ID:       int32(r.ID),
+			Label:    r.Label,
+			Replicas: int32(r.Replicas),
+			Updated:  int32(r.Updated),
+			Ready:    int32(r.Ready),
+			Old:      int32(r.Old),
+		})
+	}
+	return res
+}
+
+func clustersToPB(clusters []db.Cluster) []*pb.Cluster {
+	var res []*pb.Cluster
+	for _, c := range clusters {
+		res = append(res, &pb.Cluster{
+			ID:        int32(c.ID),
+			Namespace: c.Namespace,
+			Spec:      c.Spec,
+			AdminACLs: c.AdminACLs,
+		})
+	}
+	return res
+}
@@ -1,18 +1,22 @@
 package server
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/NetSys/quilt/api"
 	"github.com/NetSys/quilt/api/pb"
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/engine"
+	"github.com/NetSys/quilt/minion/docker"
 	"github.com/NetSys/quilt/stitch"
 
 	"golang.org/x/net/context"
@@ -23,17 +27,49 @@ import (
 
 type server struct {
 	dbConn db.Conn
+
+	// The docker daemon running this machine's containers, or nil if the server
+	// isn't running on a minion.
+	dk *docker.Client
+
+	// Whether clients connect over TCP, and so must be Admins to make privileged
+	// requests.  Clients of Unix sockets are authorized by their file permissions.
+	remote bool
 }
 
-// Run accepts incoming `quiltctl` connections and responds to them.
-func Run(conn db.Conn, listenAddr string) error {
+// Run accepts incoming `quiltctl` connections and responds to them.  Connections over
+// TCP must be mutually authenticated with `creds`, while Unix sockets rely on their
+// file permissions.
+func Run(conn db.Conn, listenAddr string, creds certs.Credentials) error {
+	return serve(server{dbConn: conn}, listenAddr, creds.ServerOption)
+}
+
+// RunMinion is like Run, but for the API server of a minion, which additionally
+// answers requests about the containers running in its docker daemon, `dk`.
+func RunMinion(conn db.Conn, listenAddr string, creds *certs.Store,
+	dk docker.Client) error {
+	return serve(server{dbConn: conn, dk: &dk}, listenAddr, creds.ServerOption)
+}
+
+func serve(apiServer server, listenAddr string,
+	security func() (grpc.ServerOption, error)) error {
+
 	proto, addr, err := api.ParseListenAddress(listenAddr)
 	if err != nil {
 		return err
 	}
 
+	var opts []grpc.ServerOption
+	if proto == "tcp" {
+		opt, err := security()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, opt)
+		apiServer.remote = true
+	}
+
 	var sock net.Listener
-	apiServer := server{conn}
 	for {
 		sock, err = net.Listen(proto, addr)
 
@@ -55,7 +91,7 @@ func Run(conn db.Conn, listenAddr string) error {
 		os.Exit(0)
 	}(sigc)
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(opts...)
 	pb.RegisterAPIServer(s, apiServer)
 	s.Serve(sock)
 
@@ -63,38 +99,101 @@ func Run(conn db.Conn, listenAddr string) error {
 }
 
 func (s server) Query(cts context.Context, query *pb.DBQuery) (*pb.QueryReply, error) {
-	var rows interface{}
-	err := s.dbConn.Transact(func(view db.Database) error {
-		switch db.TableType(query.Table) {
+	if err := checkTable(query.Table); err != nil {
+		return nil, err
+	}
+
+	return s.queryTable(db.TableType(query.Table)), nil
+}
+
+// Watch sends the contents of the requested table, followed by a fresh copy every time
+// the table changes, until the client goes away.
+func (s server) Watch(query *pb.DBQuery, stream pb.API_WatchServer) error {
+	if err := checkTable(query.Table); err != nil {
+		return err
+	}
+
+	table := db.TableType(query.Table)
+	trigg := s.dbConn.Trigger(table)
+	defer trigg.Stop()
+
+	for {
+		if err := stream.Send(s.queryTable(table)); err != nil {
+			return err
+		}
+
+		select {
+		case <-trigg.C:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func checkTable(table string) error {
+	switch db.TableType(table) {
+	case db.MachineTable, db.ContainerTable, db.EtcdTable, db.LabelTable,
+		db.ConnectionTable, db.PlacementTable, db.MinionTable, db.ClusterTable,
+		db.RolloutTable:
+		return nil
+	default:
+		return fmt.Errorf("unrecognized table: %s", table)
+	}
+}
+
+func (s server) queryTable(table db.TableType) *pb.QueryReply {
+	var reply pb.QueryReply
+	s.dbConn.Transact(func(view db.Database) error {
+		switch table {
 		case db.MachineTable:
-			rows = view.SelectFromMachine(nil)
+			reply.Machines = machinesToPB(view.SelectFromMachine(nil))
 		case db.ContainerTable:
-			rows = view.SelectFromContainer(nil)
+			reply.Containers = containersToPB(view.SelectFromContainer(nil))
 		case db.EtcdTable:
-			rows = view.SelectFromEtcd(nil)
-		default:
-			return fmt.Errorf("unrecognized table: %s", query.Table)
+			reply.Etcds = etcdsToPB(view.SelectFromEtcd(nil))
+		case db.LabelTable:
+			reply.Labels = labelsToPB(view.SelectFromLabel(nil))
+		case db.ConnectionTable:
+			connections := view.SelectFromConnection(nil)
+			reply.Connections = connectionsToPB(connections)
+		case db.PlacementTable:
+			reply.Placements = placementsToPB(view.SelectFromPlacement(nil))
+		case db.MinionTable:
+			reply.Minions = minionsToPB(view.SelectFromMinion(nil))
+		case db.ClusterTable:
+			reply.Clusters = clustersToPB(view.SelectFromCluster(nil))
+		case db.RolloutTable:
+			reply.Rollouts = rolloutsToPB(view.SelectFromRollout(nil))
 		}
 		return nil
 	})
-	if err != nil {
-		return nil, err
-	}
 
-	json, err := json.Marshal(rows)
-	if err != nil {
-		return nil, err
-	}
+	return &reply
+}
 
-	return &pb.QueryReply{TableContents: string(json)}, nil
+// authorize returns an error unless the client that made the request in `ctx` may make
+// privileged requests: those that change the deployment, or reach into its containers.
+func (s server) authorize(ctx context.Context) error {
+	if !s.remote {
+		return nil
+	}
+	return certs.Authorize(ctx, certs.Admin)
 }
 
 func (s server) Run(cts context.Context, runReq *pb.RunRequest) (*pb.RunReply, error) {
+	if err := s.authorize(cts); err != nil {
+		return &pb.RunReply{}, err
+	}
+
 	stitch, err := stitch.New(runReq.Stitch, stitch.DefaultImportGetter)
 	if err != nil {
 		return &pb.RunReply{}, err
 	}
 
+	if runReq.Namespace != "" {
+		stitch = stitch.WithNamespace(runReq.Namespace)
+	}
+
 	err = engine.UpdatePolicy(s.dbConn, stitch)
 	if err != nil {
 		return &pb.RunReply{}, err
@@ -102,3 +201,254 @@ func (s server) Run(cts context.Context, runReq *pb.RunRequest) (*pb.RunReply, e
 
 	return &pb.RunReply{}, nil
 }
+
+func (s server) SetSecret(cts context.Context, secret *pb.Secret) (
+	*pb.SetSecretReply, error) {
+
+	if err := s.authorize(cts); err != nil {
+		return &pb.SetSecretReply{}, err
+	}
+
+	if secret.Name == "" {
+		return &pb.SetSecretReply{}, errors.New("secret name must not be empty")
+	}
+
+	err := s.dbConn.Transact(func(view db.Database) error {
+		// Minions learn their secrets from the daemon, so a secret set
+		// directly on a minion would be overwritten.
+		if _, err := view.MinionSelf(); err == nil {
+			return errors.New("secrets must be set on the daemon")
+		}
+
+		var dbSecret db.Secret
+		secrets := view.SelectFromSecret(func(s db.Secret) bool {
+			return s.Name == secret.Name
+		})
+		if len(secrets) > 0 {
+			dbSecret = secrets[0]
+		} else {
+			dbSecret = view.InsertSecret()
+			dbSecret.Name = secret.Name
+		}
+
+		dbSecret.Value = secret.Value
+		view.Commit(dbSecret)
+		return nil
+	})
+	return &pb.SetSecretReply{}, err
+}
+
+// Cordon marks a machine as unschedulable, draining, or neither.  The foreman passes
+// this on to the machine's minion, from which the leader's scheduler learns it.
+func (s server) Cordon(cts context.Context, req *pb.CordonRequest) (
+	*pb.CordonReply, error) {
+
+	if err := s.authorize(cts); err != nil {
+		return &pb.CordonReply{}, err
+	}
+
+	err := s.dbConn.Transact(func(view db.Database) error {
+		if _, err := view.MinionSelf(); err == nil {
+			return errors.New("machines must be cordoned on the daemon")
+		}
+
+		machines := view.SelectFromMachine(func(m db.Machine) bool {
+			return m.ID == int(req.MachineID)
+		})
+		if len(machines) == 0 {
+			return fmt.Errorf("no machine with ID %d", req.MachineID)
+		}
+
+		dbm := machines[0]
+		dbm.Unschedulable = req.Unschedulable || req.Drain
+		dbm.Draining = req.Drain
+		view.Commit(dbm)
+		return nil
+	})
+	return &pb.CordonReply{}, err
+}
+
+// ContainerLogs streams the output of a container running on this minion.
+func (s server) ContainerLogs(req *pb.LogsRequest,
+	stream pb.API_ContainerLogsServer) error {
+
+	if err := s.authorize(stream.Context()); err != nil {
+		return err
+	}
+
+	dockerID, err := s.localContainer(int(req.StitchID))
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if req.Since != 0 {
+		since = time.Unix(req.Since, 0)
+	}
+
+	return s.dk.StreamLogs(stream.Context(), dockerID, req.Follow, since,
+		logsWriter{stream})
+}
+
+// localContainer returns the docker ID of the running container with the given stitch
+// ID, if it's running on this minion.
+func (s server) localContainer(stitchID int) (string, error) {
+	if s.dk == nil {
+		return "", errors.New("containers must be accessed through the minion " +
+			"running them")
+	}
+
+	self, err := s.dbConn.MinionSelf()
+	if err != nil {
+		return "", err
+	}
+
+	dbcs := s.dbConn.SelectFromContainerBy("Minion", self.PrivateIP,
+		func(dbc db.Container) bool {
+			return dbc.StitchID == stitchID && dbc.DockerID != ""
+		})
+	if len(dbcs) == 0 {
+		return "", fmt.Errorf("no running container with stitchID %d on "+
+			"this minion", stitchID)
+	}
+	return dbcs[0].DockerID, nil
+}
+
+// logsWriter sends everything written to it over a ContainerLogs stream.
+type logsWriter struct {
+	stream pb.API_ContainerLogsServer
+}
+
+func (w logsWriter) Write(p []byte) (int, error) {
+	// The stream may hold on to the buffer after Send returns, but the writer's
+	// caller may reuse it, so it's copied.
+	output := append([]byte{}, p...)
+	if err := w.stream.Send(&pb.LogsReply{Output: output}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Exec runs a command in a container on this minion.  The command's input and terminal
+// size are read from the stream, and its output and exit code are written back.
+func (s server) Exec(stream pb.API_ExecServer) error {
+	if err := s.authorize(stream.Context()); err != nil {
+		return err
+	}
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	dockerID, err := s.localContainer(int(req.StitchID))
+	if err != nil {
+		return err
+	}
+
+	stdin, stdinWriter := io.Pipe()
+	defer stdin.Close()
+
+	// Only the latest size matters, so a pending resize is replaced by newer ones.
+	resize := make(chan docker.TermSize, 1)
+	handle := func(req *pb.ExecRequest) {
+		if req.Height != 0 && req.Width != 0 {
+			select {
+			case <-resize:
+			default:
+			}
+			resize <- docker.TermSize{
+				Height: int(req.Height),
+				Width:  int(req.Width),
+			}
+		}
+
+		if len(req.Stdin) != 0 {
+			if _, err := stdinWriter.Write(req.Stdin); err != nil {
+				log.WithError(err).Debug("Failed to write exec input.")
+			}
+		}
+
+		if req.CloseStdin {
+			stdinWriter.Close()
+		}
+	}
+
+	var sendLock sync.Mutex
+	opts := docker.ExecOptions{
+		Cmd:    req.Command,
+		TTY:    req.TTY,
+		Stdin:  stdin,
+		Stdout: execWriter{stream, &sendLock, false},
+		Stderr: execWriter{stream, &sendLock, true},
+		Resize: resize,
+	}
+
+	go func() {
+		handle(req)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				stdinWriter.Close()
+				return
+			}
+			handle(req)
+		}
+	}()
+
+	exitCode, err := s.dk.ExecInteractive(stream.Context(), dockerID, opts)
+	if err != nil {
+		return err
+	}
+
+	sendLock.Lock()
+	defer sendLock.Unlock()
+	return stream.Send(&pb.ExecReply{Exited: true, ExitCode: int32(exitCode)})
+}
+
+// execWriter sends everything written to it over an Exec stream, as either standard
+// output or standard error.  Writers sharing `lock` never send concurrently.
+type execWriter struct {
+	stream pb.API_ExecServer
+	lock   *sync.Mutex
+	stderr bool
+}
+
+func (w execWriter) Write(p []byte) (int, error) {
+	output := append([]byte{}, p...)
+	reply := &pb.ExecReply{Stdout: output}
+	if w.stderr {
+		reply = &pb.ExecReply{Stderr: output}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if err := w.stream.Send(reply); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s server) Plan(cts context.Context, runReq *pb.RunRequest) (*pb.PlanReply, error) {
+	stitch, err := stitch.New(runReq.Stitch, stitch.DefaultImportGetter)
+	if err != nil {
+		return &pb.PlanReply{}, err
+	}
+
+	if runReq.Namespace != "" {
+		stitch = stitch.WithNamespace(runReq.Namespace)
+	}
+
+	var plan engine.Plan
+	s.dbConn.Transact(func(view db.Database) error {
+		plan = engine.PlanPolicy(view, stitch)
+		return nil
+	})
+
+	return &pb.PlanReply{
+		BootMachines:      machinesToPB(plan.BootMachines),
+		TerminateMachines: machinesToPB(plan.TerminateMachines),
+		StartContainers:   containersToPB(plan.StartContainers),
+		StopContainers:    containersToPB(plan.StopContainers),
+	}, nil
+}
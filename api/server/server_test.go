@@ -1,15 +1,20 @@
 package server
 
 import (
+	"bytes"
+	"io"
+	"reflect"
 	"testing"
 
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 
 	"github.com/NetSys/quilt/api/pb"
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/minion/docker"
 )
 
-func checkQuery(t *testing.T, s server, table db.TableType, exp string) {
+func checkQuery(t *testing.T, s server, table db.TableType, exp *pb.QueryReply) {
 	reply, err := s.Query(context.Background(),
 		&pb.DBQuery{Table: string(table)})
 	if err != nil {
@@ -17,9 +22,8 @@ func checkQuery(t *testing.T, s server, table db.TableType, exp string) {
 		return
 	}
 
-	if exp != reply.TableContents {
-		t.Errorf(`Bad query response: expected "%s", got "%s".`,
-			exp, reply.TableContents)
+	if !reflect.DeepEqual(exp, reply) {
+		t.Errorf(`Bad query response: expected "%v", got "%v".`, exp, reply)
 	}
 }
 
@@ -39,11 +43,16 @@ func TestMachineResponse(t *testing.T) {
 		return nil
 	})
 
-	exp := `[{"ID":1,"Role":"Master","Provider":"Amazon","Region":"",` +
-		`"Size":"size","DiskSize":0,"SSHKeys":null,"CloudID":"",` +
-		`"PublicIP":"8.8.8.8","PrivateIP":"9.9.9.9","Connected":false}]`
+	exp := &pb.QueryReply{Machines: []*pb.Machine{{
+		ID:        1,
+		Role:      "Master",
+		Provider:  "Amazon",
+		Size:      "size",
+		PublicIP:  "8.8.8.8",
+		PrivateIP: "9.9.9.9",
+	}}}
 
-	checkQuery(t, server{conn}, db.MachineTable, exp)
+	checkQuery(t, server{dbConn: conn}, db.MachineTable, exp)
 }
 
 func TestContainerResponse(t *testing.T) {
@@ -61,11 +70,47 @@ func TestContainerResponse(t *testing.T) {
 		return nil
 	})
 
-	exp := `[{"ID":1,"Pid":0,"IP":"","Mac":"","Minion":"",` +
-		`"DockerID":"docker-id","StitchID":0,"Image":"image",` +
-		`"Command":["cmd","arg"],"Labels":["labelA","labelB"],"Env":null}]`
+	exp := &pb.QueryReply{Containers: []*pb.Container{{
+		ID:       1,
+		DockerID: "docker-id",
+		Image:    "image",
+		Command:  []string{"cmd", "arg"},
+		Labels:   []string{"labelA", "labelB"},
+	}}}
 
-	checkQuery(t, server{conn}, db.ContainerTable, exp)
+	checkQuery(t, server{dbConn: conn}, db.ContainerTable, exp)
+}
+
+func TestClusterResponse(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	conn.Transact(func(view db.Database) error {
+		c := view.InsertCluster()
+		c.Namespace = "namespace"
+		c.AdminACLs = []string{"local"}
+		view.Commit(c)
+
+		return nil
+	})
+
+	exp := &pb.QueryReply{Clusters: []*pb.Cluster{{
+		ID:        1,
+		Namespace: "namespace",
+		AdminACLs: []string{"local"},
+	}}}
+
+	checkQuery(t, server{dbConn: conn}, db.ClusterTable, exp)
+}
+
+func TestBadTable(t *testing.T) {
+	t.Parallel()
+
+	_, err := server{dbConn: db.New()}.Query(context.Background(),
+		&pb.DBQuery{Table: "BadTable"})
+	if err == nil || err.Error() != "unrecognized table: BadTable" {
+		t.Errorf("Expected error for bad table, got %v", err)
+	}
 }
 
 func TestBadStitch(t *testing.T) {
@@ -116,4 +161,379 @@ func TestRun(t *testing.T) {
 		t.Errorf("Two machines should have been created by running the stitch, "+
 			"but we found: %v\n", machines)
 	}
+
+	// The requested namespace overrides the one the stitch declares.
+	_, err = s.Run(context.Background(), &pb.RunRequest{
+		Stitch:    createMachineStitch,
+		Namespace: "staging",
+	})
+	if err != nil {
+		t.Errorf("Unexpected error when running stich: %s\n", err.Error())
+		return
+	}
+
+	var staging []db.Machine
+	conn.Transact(func(view db.Database) error {
+		staging = view.SelectFromMachine(func(m db.Machine) bool {
+			return m.Namespace == "staging"
+		})
+		return nil
+	})
+	if len(staging) != 2 {
+		t.Errorf("Two machines should have been created in the staging "+
+			"namespace, but we found: %v\n", staging)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	conn := db.New()
+	s := server{dbConn: conn}
+
+	conn.Transact(func(view db.Database) error {
+		m := view.InsertMachine()
+		m.Namespace = "default-namespace"
+		m.Provider = db.Amazon
+		m.Size = "m3.medium"
+		m.Role = db.Worker
+		view.Commit(m)
+		return nil
+	})
+
+	createMachineStitch :=
+		`deployment.deploy([
+		new Machine({provider: "Amazon", size: "m4.large", role: "Master"}),
+		new Machine({provider: "Amazon", size: "m4.large", role: "Worker"}),
+		]);`
+
+	reply, err := s.Plan(context.Background(),
+		&pb.RunRequest{Stitch: createMachineStitch})
+	if err != nil {
+		t.Errorf("Unexpected error when planning stitch: %s\n", err.Error())
+		return
+	}
+
+	plan := reply
+	if len(plan.BootMachines) != 2 {
+		t.Errorf("Expected to boot two machines, but got: %v\n",
+			plan.BootMachines)
+	}
+
+	if len(plan.TerminateMachines) != 1 || plan.TerminateMachines[0].ID != 1 {
+		t.Errorf("Expected to terminate machine 1, but got: %v\n",
+			plan.TerminateMachines)
+	}
+
+	var machines []db.Machine
+	conn.Transact(func(view db.Database) error {
+		machines = view.SelectFromMachine(nil)
+		return nil
+	})
+
+	if len(machines) != 1 || machines[0].Size != "m3.medium" {
+		t.Errorf("Planning should not modify the database, but found: %v\n",
+			machines)
+	}
+}
+
+func TestSetSecret(t *testing.T) {
+	conn := db.New()
+	s := server{dbConn: conn}
+
+	for _, value := range []string{"hunter2", "letmein"} {
+		_, err := s.SetSecret(context.Background(),
+			&pb.Secret{Name: "password", Value: value})
+		if err != nil {
+			t.Errorf("Unexpected error setting secret: %s", err)
+		}
+
+		exp := map[string]string{"password": value}
+		if secrets := conn.SecretMap(); !reflect.DeepEqual(secrets, exp) {
+			t.Errorf("Expected secrets %v, but got %v", exp, secrets)
+		}
+	}
+
+	// Secrets can't be set on minions.
+	conn.Transact(func(view db.Database) error {
+		minion := view.InsertMinion()
+		minion.Self = true
+		view.Commit(minion)
+		return nil
+	})
+
+	_, err := s.SetSecret(context.Background(),
+		&pb.Secret{Name: "password", Value: "other"})
+	if err == nil {
+		t.Error("Expected an error setting a secret on a minion")
+	}
+}
+
+func TestRemoteAuthorization(t *testing.T) {
+	conn := db.New()
+	s := server{dbConn: conn, remote: true}
+
+	// Over TCP, only clients with the admin certificate may change the deployment.
+	_, err := s.SetSecret(context.Background(),
+		&pb.Secret{Name: "password", Value: "hunter2"})
+	if err == nil {
+		t.Error("Expected an error setting a secret without a certificate")
+	}
+
+	if secrets := conn.SecretMap(); len(secrets) != 0 {
+		t.Errorf("Unexpected secrets: %v", secrets)
+	}
+
+	_, err = s.Run(context.Background(), &pb.RunRequest{Stitch: "{}"})
+	if err == nil {
+		t.Error("Expected an error running a stitch without a certificate")
+	}
+
+	// Reads are allowed, as the minions depend on them.
+	if _, err := s.Query(context.Background(),
+		&pb.DBQuery{Table: string(db.MachineTable)}); err != nil {
+		t.Errorf("Unexpected error querying machines: %s", err)
+	}
+}
+
+func TestCordon(t *testing.T) {
+	conn := db.New()
+	s := server{dbConn: conn}
+
+	var id int
+	conn.Transact(func(view db.Database) error {
+		id = view.InsertMachine().ID
+		return nil
+	})
+
+	checkMachine := func(unschedulable, draining bool) {
+		var dbm db.Machine
+		conn.Transact(func(view db.Database) error {
+			dbm = view.SelectFromMachine(nil)[0]
+			return nil
+		})
+
+		if dbm.Unschedulable != unschedulable || dbm.Draining != draining {
+			t.Errorf("Expected unschedulable=%t draining=%t, got %v",
+				unschedulable, draining, dbm)
+		}
+	}
+
+	tests := []struct {
+		req                     pb.CordonRequest
+		unschedulable, draining bool
+	}{
+		{pb.CordonRequest{Unschedulable: true}, true, false},
+		{pb.CordonRequest{Drain: true}, true, true},
+		{pb.CordonRequest{}, false, false},
+	}
+	for _, test := range tests {
+		test.req.MachineID = int32(id)
+		if _, err := s.Cordon(context.Background(), &test.req); err != nil {
+			t.Errorf("Unexpected error cordoning machine: %s", err)
+		}
+		checkMachine(test.unschedulable, test.draining)
+	}
+
+	_, err := s.Cordon(context.Background(),
+		&pb.CordonRequest{MachineID: int32(id + 1), Unschedulable: true})
+	if err == nil {
+		t.Error("Expected an error cordoning a nonexistent machine")
+	}
+}
+
+type mockWatchServer struct {
+	grpc.ServerStream
+	ctx     context.Context
+	replies chan *pb.QueryReply
+}
+
+func (s mockWatchServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s mockWatchServer) Send(reply *pb.QueryReply) error {
+	s.replies <- reply
+	return nil
+}
+
+func TestWatch(t *testing.T) {
+	conn := db.New()
+	s := server{dbConn: conn}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := mockWatchServer{ctx: ctx, replies: make(chan *pb.QueryReply)}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- s.Watch(&pb.DBQuery{Table: string(db.EtcdTable)}, stream)
+	}()
+
+	if reply := <-stream.replies; len(reply.Etcds) != 0 {
+		t.Errorf("Expected empty initial table, got %v", reply)
+	}
+
+	conn.Transact(func(view db.Database) error {
+		etcd := view.InsertEtcd()
+		etcd.LeaderIP = "1.2.3.4"
+		view.Commit(etcd)
+		return nil
+	})
+
+	exp := &pb.QueryReply{Etcds: []*pb.Etcd{{ID: 1, LeaderIP: "1.2.3.4"}}}
+	if reply := <-stream.replies; !reflect.DeepEqual(exp, reply) {
+		t.Errorf("Expected %v, got %v", exp, reply)
+	}
+
+	cancel()
+	if err := <-errChan; err != context.Canceled {
+		t.Errorf("Expected watch to end when cancelled, got %v", err)
+	}
+
+	err := s.Watch(&pb.DBQuery{Table: "BadTable"}, stream)
+	if err == nil || err.Error() != "unrecognized table: BadTable" {
+		t.Errorf("Expected error for bad table, got %v", err)
+	}
+}
+
+type mockLogsServer struct {
+	grpc.ServerStream
+	output *bytes.Buffer
+}
+
+func (s mockLogsServer) Context() context.Context {
+	return context.Background()
+}
+
+func (s mockLogsServer) Send(reply *pb.LogsReply) error {
+	s.output.Write(reply.Output)
+	return nil
+}
+
+func TestContainerLogs(t *testing.T) {
+	conn := db.New()
+	md, dk := docker.NewMock()
+
+	id, err := dk.Run(docker.RunOptions{Image: "image"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	md.Output[id] = "hello\nworld\n"
+
+	conn.Transact(func(view db.Database) error {
+		self := view.InsertMinion()
+		self.Self = true
+		self.PrivateIP = "1.2.3.4"
+		view.Commit(self)
+
+		dbc := view.InsertContainer()
+		dbc.StitchID = 5
+		dbc.Minion = "1.2.3.4"
+		dbc.DockerID = id
+		view.Commit(dbc)
+		return nil
+	})
+
+	stream := mockLogsServer{output: &bytes.Buffer{}}
+	err = server{dbConn: conn}.ContainerLogs(&pb.LogsRequest{StitchID: 5}, stream)
+	if err == nil {
+		t.Error("Expected an error requesting logs from the daemon")
+	}
+
+	s := server{dbConn: conn, dk: &dk}
+	if err := s.ContainerLogs(&pb.LogsRequest{StitchID: 5}, stream); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if out := stream.output.String(); out != "hello\nworld\n" {
+		t.Errorf("Unexpected logs: %q", out)
+	}
+
+	err = s.ContainerLogs(&pb.LogsRequest{StitchID: 6}, stream)
+	if err == nil {
+		t.Error("Expected an error requesting logs of an unknown container")
+	}
+}
+
+type mockExecServer struct {
+	grpc.ServerStream
+	requests []*pb.ExecRequest
+	replies  []*pb.ExecReply
+}
+
+func (s *mockExecServer) Context() context.Context {
+	return context.Background()
+}
+
+func (s *mockExecServer) Recv() (*pb.ExecRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *mockExecServer) Send(reply *pb.ExecReply) error {
+	s.replies = append(s.replies, reply)
+	return nil
+}
+
+func TestExec(t *testing.T) {
+	conn := db.New()
+	md, dk := docker.NewMock()
+
+	id, err := dk.Run(docker.RunOptions{Image: "image"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	conn.Transact(func(view db.Database) error {
+		self := view.InsertMinion()
+		self.Self = true
+		self.PrivateIP = "1.2.3.4"
+		view.Commit(self)
+
+		dbc := view.InsertContainer()
+		dbc.StitchID = 5
+		dbc.Minion = "1.2.3.4"
+		dbc.DockerID = id
+		view.Commit(dbc)
+		return nil
+	})
+
+	// The mock echoes each command's input.
+	md.ExecExitCode = 2
+	stream := &mockExecServer{requests: []*pb.ExecRequest{
+		{StitchID: 5, Command: []string{"cat"}},
+		{Stdin: []byte("hello")},
+		{CloseStdin: true},
+	}}
+
+	s := server{dbConn: conn, dk: &dk}
+	if err := s.Exec(stream); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	var out string
+	for _, reply := range stream.replies[:len(stream.replies)-1] {
+		out += string(reply.Stdout)
+	}
+
+	if out != "hello" {
+		t.Errorf("Unexpected output: %q", out)
+	}
+
+	last := stream.replies[len(stream.replies)-1]
+	if !last.Exited || last.ExitCode != 2 {
+		t.Errorf("Unexpected final reply: %v", last)
+	}
+
+	if !reflect.DeepEqual(md.Executions[id], []string{"cat"}) {
+		t.Errorf("Unexpected executions: %v", md.Executions[id])
+	}
+
+	stream = &mockExecServer{requests: []*pb.ExecRequest{{StitchID: 6}}}
+	if err := s.Exec(stream); err == nil {
+		t.Error("Expected an error executing in an unknown container")
+	}
 }
@@ -0,0 +1,582 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/NetSys/quilt/util"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ServerName is the name every Quilt certificate is issued for.  Quilt machines are
+// addressed by IPs that aren't known when their certificates are issued, so peers are
+// authenticated by the certificate authority that signed them rather than by address.
+const ServerName = "quilt"
+
+// The identities that certificates are issued for, recorded as their common name.  The
+// daemon and its foremen are Admins, and only they may configure minions, change the
+// deployment, or run commands in its containers.
+const (
+	Admin  = "quilt-admin"
+	Minion = "quilt-minion"
+)
+
+const (
+	caCertFile = "ca.crt"
+	caKeyFile  = "ca.key"
+	certFile   = "quilt.crt"
+	keyFile    = "quilt.key"
+
+	validFor    = 10 * 365 * 24 * time.Hour
+	dialTimeout = 10 * time.Second
+)
+
+// An Authority is the PEM encoded certificate and private key of a cluster's
+// certificate authority.  The daemon uses it to issue Credentials to itself, and to
+// sign the certificates of the minions it boots.
+type Authority struct {
+	Cert []byte
+	Key  []byte
+}
+
+// Credentials are the PEM encoded certificate authority, certificate, and private key
+// a Quilt process uses to authenticate itself to its peers, and its peers to itself.
+type Credentials struct {
+	CA   []byte
+	Cert []byte
+	Key  []byte
+}
+
+// NewAuthority generates a new, self signed, certificate authority.
+func NewAuthority() (Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Authority{}, err
+	}
+
+	template, err := newTemplate("Quilt CA")
+	if err != nil {
+		return Authority{}, err
+	}
+	template.IsCA = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template,
+		&key.PublicKey, key)
+	if err != nil {
+		return Authority{}, err
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return Authority{}, err
+	}
+
+	return Authority{Cert: encodeCert(der), Key: keyPEM}, nil
+}
+
+// Issue generates a new key pair signed by the authority, and issued for `identity`.
+// The resulting certificate may be used both to serve and to dial connections.
+func (a Authority) Issue(identity string) (Credentials, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	der, err := a.sign(&key.PublicKey, identity)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return Credentials{CA: a.Cert, Cert: encodeCert(der), Key: keyPEM}, nil
+}
+
+// Sign returns a certificate signed by the authority, and issued for `identity`, for
+// the key of the PEM encoded certificate `cert`.
+func (a Authority) Sign(cert []byte, identity string) ([]byte, error) {
+	parsed, err := parseCert(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := a.sign(parsed.PublicKey, identity)
+	if err != nil {
+		return nil, err
+	}
+	return encodeCert(der), nil
+}
+
+func (a Authority) sign(pub interface{}, identity string) ([]byte, error) {
+	caPair, err := tls.X509KeyPair(a.Cert, a.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	caCert, err := x509.ParseCertificate(caPair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newLeafTemplate(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.CreateCertificate(rand.Reader, template, caCert, pub,
+		caPair.PrivateKey)
+}
+
+// NewMinionCredentials generates a key pair with a self-signed certificate, for a
+// minion to serve with until the foreman has the authority sign it.  Minions generate
+// their own keys so that they're never sent over the network, or stored where their
+// containers may read them.  `ca` is the PEM encoded certificate of the authority.
+func NewMinionCredentials(ca []byte) (Credentials, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	template, err := newLeafTemplate(Minion)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template,
+		&key.PublicKey, key)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return Credentials{CA: ca, Cert: encodeCert(der), Key: keyPEM}, nil
+}
+
+// Identity returns the identity the certificate of `c` was issued for.
+func (c Credentials) Identity() (string, error) {
+	cert, err := parseCert(c.Cert)
+	if err != nil {
+		return "", err
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// Signed returns true if the certificate of `c` is signed by its authority.
+func (c Credentials) Signed() bool {
+	cert, err := parseCert(c.Cert)
+	if err != nil {
+		return false
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(c.CA) {
+		return false
+	}
+
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+// ServerTLS returns a TLS configuration that serves with `c`, and requires that
+// clients present a certificate signed by the same authority.
+func (c Credentials) ServerTLS() (*tls.Config, error) {
+	cert, pool, err := c.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}, nil
+}
+
+// ClientTLS returns a TLS configuration that dials with `c`, and requires that servers
+// present a certificate signed by the same authority.
+func (c Credentials) ClientTLS() (*tls.Config, error) {
+	cert, pool, err := c.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   ServerName,
+	}, nil
+}
+
+// DialOption returns a grpc option that dials with `c`.
+func (c Credentials) DialOption() (grpc.DialOption, error) {
+	tlsConfig, err := c.ClientTLS()
+	if err != nil {
+		return nil, err
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), nil
+}
+
+// PinnedDialOption returns a grpc option that dials with `c`, but only trusts servers
+// that present the PEM encoded certificate `server`, rather than those signed by the
+// authority.
+func (c Credentials) PinnedDialOption(server []byte) (grpc.DialOption, error) {
+	tlsConfig, err := c.ClientTLS()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.RootCAs = x509.NewCertPool()
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(server) {
+		return nil, errors.New("malformed server certificate")
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), nil
+}
+
+// ServerCert returns the PEM encoded certificate that the server at `addr` presents
+// when dialed with `c`.  The certificate isn't verified.
+func (c Credentials) ServerCert(addr string) ([]byte, error) {
+	tlsConfig, err := c.ClientTLS()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.InsecureSkipVerify = true
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	peerCerts := conn.ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return nil, errors.New("server presented no certificate")
+	}
+	return encodeCert(peerCerts[0].Raw), nil
+}
+
+// ServerOption returns a grpc option that serves with `c`.
+func (c Credentials) ServerOption() (grpc.ServerOption, error) {
+	tlsConfig, err := c.ServerTLS()
+	if err != nil {
+		return nil, err
+	}
+	return grpc.Creds(credentials.NewTLS(tlsConfig)), nil
+}
+
+// Authorize returns an error unless the client that made the gRPC request in `ctx`
+// authenticated with a certificate issued for `identity`.
+func Authorize(ctx context.Context, identity string) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return grpc.Errorf(codes.Unauthenticated, "unknown peer")
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return grpc.Errorf(codes.Unauthenticated, "no verified certificate")
+	}
+
+	peerIdentity := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+	if peerIdentity != identity {
+		return grpc.Errorf(codes.PermissionDenied,
+			"%s may not make this request", peerIdentity)
+	}
+	return nil
+}
+
+// AdminOnly returns a grpc option that rejects unary requests from clients that
+// aren't Admins.
+func AdminOnly() grpc.ServerOption {
+	return grpc.UnaryInterceptor(func(ctx context.Context, req interface{},
+		_ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		if err := Authorize(ctx, Admin); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	})
+}
+
+// A Store holds the credentials of a minion, whose self-signed certificate is replaced
+// once the foreman has the authority sign it.
+type Store struct {
+	dir string
+
+	mutex sync.Mutex
+	creds Credentials
+}
+
+// LoadStore loads the minion credentials in `dir`, first generating a key pair with a
+// self-signed certificate if there isn't one.  Only the authority's certificate must
+// be in `dir` beforehand.
+func LoadStore(dir string) (*Store, error) {
+	creds, err := Load(dir)
+	if os.IsNotExist(err) {
+		var ca string
+		ca, err = util.ReadFile(filepath.Join(dir, caCertFile))
+		if err != nil {
+			return nil, err
+		}
+
+		creds, err = NewMinionCredentials([]byte(ca))
+		if err == nil {
+			err = Save(dir, creds)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: dir, creds: creds}, nil
+}
+
+// Credentials returns the credentials currently held by the store.
+func (s *Store) Credentials() Credentials {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.creds
+}
+
+// SetCert replaces the certificate held by the store with `cert`, which must be
+// signed by the authority, and issued for the store's key.
+func (s *Store) SetCert(cert []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	creds := s.creds
+	creds.Cert = cert
+	if _, err := tls.X509KeyPair(creds.Cert, creds.Key); err != nil {
+		return err
+	}
+
+	if !creds.Signed() {
+		return errors.New("certificate isn't signed by the authority")
+	}
+
+	if id, err := creds.Identity(); err != nil || id != Minion {
+		return fmt.Errorf("certificate isn't issued for %s", Minion)
+	}
+
+	if err := Save(s.dir, creds); err != nil {
+		return err
+	}
+
+	s.creds = creds
+	return nil
+}
+
+// ServerOption returns a grpc option that serves with the certificate currently held
+// by the store, and requires that clients present a certificate signed by the
+// authority.
+func (s *Store) ServerOption() (grpc.ServerOption, error) {
+	tlsConfig, err := s.Credentials().ServerTLS()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.Certificates = nil
+	tlsConfig.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		creds := s.Credentials()
+		cert, err := tls.X509KeyPair(creds.Cert, creds.Key)
+		return &cert, err
+	}
+	return grpc.Creds(credentials.NewTLS(tlsConfig)), nil
+}
+
+func (c Credentials) parse() (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.X509KeyPair(c.Cert, c.Key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(c.CA) {
+		return tls.Certificate{}, nil, errors.New("malformed CA certificate")
+	}
+
+	return cert, pool, nil
+}
+
+// DefaultDir is the directory Quilt stores its certificates in by default.
+func DefaultDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".quilt", "tls")
+}
+
+// Setup loads the authority and credentials stored in `dir`, generating and saving
+// them first if they don't exist yet.
+func Setup(dir string) (Authority, Credentials, error) {
+	ca, err := loadAuthority(dir)
+	if os.IsNotExist(err) {
+		ca, err = NewAuthority()
+		if err == nil {
+			err = saveAuthority(dir, ca)
+		}
+	}
+	if err != nil {
+		return Authority{}, Credentials{}, err
+	}
+
+	// Credentials from before identities were introduced are reissued, so that
+	// the daemon remains an Admin.
+	var identity string
+	creds, err := Load(dir)
+	if err == nil {
+		identity, err = creds.Identity()
+	}
+
+	if os.IsNotExist(err) || (err == nil && identity != Admin) {
+		creds, err = ca.Issue(Admin)
+		if err == nil {
+			err = Save(dir, creds)
+		}
+	}
+	if err != nil {
+		return Authority{}, Credentials{}, err
+	}
+
+	return ca, creds, nil
+}
+
+// Load reads the credentials stored in `dir`.
+func Load(dir string) (Credentials, error) {
+	var files [3]string
+	for i, name := range []string{caCertFile, certFile, keyFile} {
+		contents, err := util.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return Credentials{}, err
+		}
+		files[i] = contents
+	}
+
+	return Credentials{
+		CA:   []byte(files[0]),
+		Cert: []byte(files[1]),
+		Key:  []byte(files[2]),
+	}, nil
+}
+
+// Save writes `creds` to `dir` so that they may later be read by Load().
+func Save(dir string, creds Credentials) error {
+	return writeFiles(dir, map[string][]byte{
+		caCertFile: creds.CA,
+		certFile:   creds.Cert,
+		keyFile:    creds.Key,
+	})
+}
+
+func loadAuthority(dir string) (Authority, error) {
+	cert, err := util.ReadFile(filepath.Join(dir, caCertFile))
+	if err != nil {
+		return Authority{}, err
+	}
+
+	key, err := util.ReadFile(filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return Authority{}, err
+	}
+
+	return Authority{Cert: []byte(cert), Key: []byte(key)}, nil
+}
+
+func saveAuthority(dir string, ca Authority) error {
+	return writeFiles(dir, map[string][]byte{
+		caCertFile: ca.Cert,
+		caKeyFile:  ca.Key,
+	})
+}
+
+func writeFiles(dir string, files map[string][]byte) error {
+	if err := util.AppFs.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	for name, contents := range files {
+		err := util.WriteFile(filepath.Join(dir, name), contents, 0600)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLeafTemplate returns the template of a certificate issued for `identity`, which
+// may be used both to serve and to dial connections.
+func newLeafTemplate(identity string) (*x509.Certificate, error) {
+	template, err := newTemplate(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	template.DNSNames = []string{ServerName}
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth,
+		x509.ExtKeyUsageClientAuth}
+	return template, nil
+}
+
+func newTemplate(commonName string) (*x509.Certificate, error) {
+	serialLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serial, err := rand.Int(rand.Reader, serialLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validFor),
+		BasicConstraintsValid: true,
+	}, nil
+}
+
+func parseCert(cert []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(cert)
+	if block == nil {
+		return nil, errors.New("malformed certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,227 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/NetSys/quilt/util"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestSetup(t *testing.T) {
+	util.AppFs = afero.NewMemMapFs()
+	defer func() { util.AppFs = afero.NewOsFs() }()
+
+	ca, creds, err := Setup("/tls")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	loaded, err := Load("/tls")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(creds, loaded) {
+		t.Error("Loaded credentials differ from those that were set up")
+	}
+
+	ca2, creds2, err := Setup("/tls")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(ca, ca2) || !reflect.DeepEqual(creds, creds2) {
+		t.Error("Setup should reuse existing credentials")
+	}
+
+	if id, err := creds.Identity(); err != nil || id != Admin {
+		t.Errorf("Daemon credentials issued for %q (%v), expected %s", id, err,
+			Admin)
+	}
+
+	// Credentials that aren't an Admin's are reissued.
+	if err := Save("/tls", issue(t, ca, "quilt")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	_, creds3, err := Setup("/tls")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if id, err := creds3.Identity(); err != nil || id != Admin {
+		t.Errorf("Daemon credentials issued for %q (%v), expected %s", id, err,
+			Admin)
+	}
+}
+
+func TestStore(t *testing.T) {
+	util.AppFs = afero.NewMemMapFs()
+	defer func() { util.AppFs = afero.NewOsFs() }()
+
+	ca := newTestAuthority(t)
+	if _, err := LoadStore("/tls"); err == nil {
+		t.Error("Expected an error without the authority's certificate")
+	}
+
+	util.WriteFile("/tls/ca.crt", ca.Cert, 0600)
+	store, err := LoadStore("/tls")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The minion's key pair is generated locally, and self-signed until the
+	// authority signs it.
+	selfSigned := store.Credentials()
+	if selfSigned.Signed() {
+		t.Error("Expected a self-signed certificate")
+	}
+
+	// Only a certificate signed by the authority, for the store's key, and for a
+	// minion is accepted.
+	stranger := newTestAuthority(t)
+	for _, cert := range [][]byte{
+		sign(t, stranger, selfSigned.Cert, Minion),
+		sign(t, ca, selfSigned.Cert, Admin),
+		issue(t, ca, Minion).Cert,
+	} {
+		if err := store.SetCert(cert); err == nil {
+			t.Error("Expected an invalid certificate to be rejected")
+		}
+	}
+
+	if err := store.SetCert(sign(t, ca, selfSigned.Cert, Minion)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	signed := store.Credentials()
+	if !signed.Signed() || !reflect.DeepEqual(signed.Key, selfSigned.Key) {
+		t.Error("Expected the store's key to be signed by the authority")
+	}
+
+	admin := issue(t, ca, Admin)
+	if err := handshake(t, signed, admin); err != nil {
+		t.Errorf("Unexpected handshake error: %s", err)
+	}
+
+	// The signed certificate is kept across restarts.
+	reloaded, err := LoadStore("/tls")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(reloaded.Credentials(), signed) {
+		t.Error("Reloaded credentials differ from those that were signed")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	ca := newTestAuthority(t)
+
+	ctxWith := func(creds Credentials) context.Context {
+		cert, err := parseCert(creds.Cert)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		state := tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}
+		return peer.NewContext(context.Background(),
+			&peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+
+	if err := Authorize(ctxWith(issue(t, ca, Admin)), Admin); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if err := Authorize(ctxWith(issue(t, ca, Minion)), Admin); err == nil {
+		t.Error("Expected a minion to be refused")
+	}
+
+	if err := Authorize(context.Background(), Admin); err == nil {
+		t.Error("Expected an unauthenticated peer to be refused")
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	ca := newTestAuthority(t)
+	server := issue(t, ca, Minion)
+	client := issue(t, ca, Admin)
+
+	if err := handshake(t, server, client); err != nil {
+		t.Errorf("Unexpected handshake error: %s", err)
+	}
+
+	// Credentials issued by a different authority must be rejected.
+	stranger := issue(t, newTestAuthority(t), Admin)
+	if err := handshake(t, server, stranger); err == nil {
+		t.Error("Expected handshake with an untrusted client to fail")
+	}
+
+	if err := handshake(t, stranger, client); err == nil {
+		t.Error("Expected handshake with an untrusted server to fail")
+	}
+}
+
+func handshake(t *testing.T, server, client Credentials) error {
+	serverTLS, err := server.ServerTLS()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	clientTLS, err := client.ClientTLS()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- tls.Server(serverConn, serverTLS).Handshake()
+		serverConn.Close()
+	}()
+
+	clientErr := tls.Client(clientConn, clientTLS).Handshake()
+	clientConn.Close()
+	if serverErr := <-errChan; serverErr != nil {
+		return serverErr
+	}
+	return clientErr
+}
+
+func newTestAuthority(t *testing.T) Authority {
+	ca, err := NewAuthority()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return ca
+}
+
+func issue(t *testing.T, ca Authority, identity string) Credentials {
+	creds, err := ca.Issue(identity)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return creds
+}
+
+func sign(t *testing.T, ca Authority, cert []byte, identity string) []byte {
+	signed, err := ca.Sign(cert, identity)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return signed
+}
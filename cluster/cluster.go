@@ -3,6 +3,7 @@ package cluster
 import (
 	"time"
 
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/cluster/provider"
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/join"
@@ -12,9 +13,11 @@ import (
 
 var myIP = util.MyIP
 var sleep = time.Sleep
+var now = time.Now
 
 // Store the providers in a variable so we can change it in the tests
-var allProviders = []db.Provider{db.Amazon, db.Azure, db.Google, db.Vagrant}
+var allProviders = []db.Provider{db.Amazon, db.Azure, db.Google, db.Vagrant,
+	db.Docker}
 
 type cluster struct {
 	conn    db.Conn
@@ -23,42 +26,49 @@ type cluster struct {
 
 	namespace string
 	providers map[db.Provider]provider.Provider
+	authority certs.Authority
 }
 
-// Run continually checks 'conn' for cluster changes and recreates the cluster as
-// needed.
-func Run(conn db.Conn) {
-	var clst *cluster
+// Run continually checks 'conn' for cluster changes and creates or destroys a cluster
+// for each namespace as needed.  Each namespace is managed independently, with its own
+// foreman and set of providers.  The certificate of each minion is signed by `ca`.
+func Run(conn db.Conn, ca certs.Authority) {
+	clusters := map[string]*cluster{}
 	for range conn.TriggerTick(60, db.ClusterTable).C {
-		var dbCluster db.Cluster
-		err := conn.Transact(func(db db.Database) error {
-			var err error
-			dbCluster, err = db.GetCluster()
-			return err
-		})
-		if err != nil {
-			continue
+		namespaces := map[string]struct{}{}
+		for _, dbCluster := range conn.SelectFromCluster(nil) {
+			namespaces[dbCluster.Namespace] = struct{}{}
 		}
 
-		if clst == nil {
-			clst = newCluster(conn, dbCluster.Namespace)
+		for namespace := range namespaces {
+			if _, ok := clusters[namespace]; !ok {
+				clusters[namespace] = newCluster(conn, namespace, ca)
+			}
 		}
 
-		if clst != nil && clst.namespace != dbCluster.Namespace {
-			clst.fm.stop()
-			clst.trigger.Stop()
-			clst = newCluster(conn, dbCluster.Namespace)
+		for namespace, clst := range clusters {
+			if _, ok := namespaces[namespace]; !ok {
+				clst.fm.stop()
+				clst.trigger.Stop()
+				delete(clusters, namespace)
+			}
 		}
 	}
 }
 
-func newCluster(conn db.Conn, namespace string) *cluster {
+func newCluster(conn db.Conn, namespace string, ca certs.Authority) *cluster {
+	creds, err := ca.Issue(certs.Admin)
+	if err != nil {
+		log.WithError(err).Error("Failed to issue foreman credentials.")
+	}
+
 	clst := &cluster{
 		conn:      conn,
 		trigger:   conn.TriggerTick(30, db.ClusterTable, db.MachineTable),
-		fm:        createForeman(conn),
+		fm:        createForeman(conn, namespace, ca, creds),
 		namespace: namespace,
 		providers: make(map[db.Provider]provider.Provider),
+		authority: ca,
 	}
 
 	for _, p := range allProviders {
@@ -151,12 +161,13 @@ func (clst cluster) sync() {
 	 * instances) that should be reflected in the database.  Therefore, if updates
 	 * are necessary the code loops so that database can be updated before
 	 * the next sync() call. */
+	clst.replaceDisconnected()
 	for i := 0; i < 2; i++ {
 		bootSet, terminateSet := clst.syncMachines()
 		if len(bootSet) == 0 && len(terminateSet) == 0 {
 			break
 		}
-		clst.updateCloud(bootSet, true)
+		clst.updateCloud(clst.withAuthority(bootSet), true)
 		clst.updateCloud(terminateSet, false)
 	}
 
@@ -167,14 +178,71 @@ func (clst cluster) sync() {
 	var adminACLs []string
 	var machines []db.Machine
 	clst.conn.Transact(func(view db.Database) error {
-		machines = view.SelectFromMachine(nil)
-		dbCluster, _ := view.GetCluster()
+		machines = view.SelectFromMachine(clst.inNamespace)
+		dbCluster, _ := view.GetCluster(clst.namespace)
 		adminACLs = dbCluster.AdminACLs
 		return nil
 	})
 	clst.syncACLs(adminACLs, machines)
 }
 
+// replaceDisconnected stops the machines whose minions have been disconnected for too
+// long.  Their database rows are kept, but forget the stopped machines, so that
+// syncMachines boots replacements for them.
+func (clst cluster) replaceDisconnected() {
+	disconnected := map[int]struct{}{}
+	for _, dbm := range clst.fm.disconnected() {
+		disconnected[dbm.ID] = struct{}{}
+	}
+
+	if len(disconnected) == 0 {
+		return
+	}
+
+	var stopSet []provider.Machine
+	clst.conn.Transact(func(view db.Database) error {
+		machines := view.SelectFromMachine(func(m db.Machine) bool {
+			_, ok := disconnected[m.ID]
+			return ok && clst.inNamespace(m) && m.CloudID != ""
+		})
+
+		for _, dbm := range machines {
+			log.WithField("machine", dbm).Warning("Minion has been " +
+				"disconnected for too long. Replacing its machine.")
+			stopSet = append(stopSet, provider.Machine{
+				ID:        dbm.CloudID,
+				PublicIP:  dbm.PublicIP,
+				PrivateIP: dbm.PrivateIP,
+				Provider:  dbm.Provider,
+				Region:    dbm.Region,
+				Size:      dbm.Size,
+			})
+			clst.fm.forget(dbm.PublicIP)
+
+			dbm.CloudID = ""
+			dbm.PublicIP = ""
+			dbm.PrivateIP = ""
+			dbm.Connected = false
+			view.Commit(dbm)
+		}
+		return nil
+	})
+
+	clst.updateCloud(stopSet, false)
+}
+
+// withAuthority has each machine in `bootSet` trust the cluster's certificate
+// authority.  Their minions generate their own keys, whose certificates the foreman
+// has the authority sign once they've booted.
+func (clst cluster) withAuthority(bootSet []provider.Machine) []provider.Machine {
+	var res []provider.Machine
+	for _, m := range bootSet {
+		m.CA = clst.authority.Cert
+		res = append(res, m)
+	}
+	return res
+}
+
 func (clst cluster) syncMachines() (bootSet, terminateSet []provider.Machine) {
 	cloudMachines, err := clst.get()
 	if err != nil {
@@ -183,7 +251,7 @@ func (clst cluster) syncMachines() (bootSet, terminateSet []provider.Machine) {
 	}
 
 	clst.conn.Transact(func(view db.Database) error {
-		dbMachines := view.SelectFromMachine(nil)
+		dbMachines := view.SelectFromMachine(clst.inNamespace)
 
 		var pairs []join.Pair
 		pairs, bootSet, terminateSet = syncDB(cloudMachines, dbMachines)
@@ -214,6 +282,10 @@ func (clst cluster) syncMachines() (bootSet, terminateSet []provider.Machine) {
 	return bootSet, terminateSet
 }
 
+func (clst cluster) inNamespace(m db.Machine) bool {
+	return m.Namespace == clst.namespace
+}
+
 func (clst cluster) syncACLs(adminACLs []string, machines []db.Machine) {
 	acls := adminACLs
 
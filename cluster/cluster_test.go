@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/cluster/provider"
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/stitch"
@@ -91,10 +92,16 @@ func (p *fakeProvider) ChooseSize(ram stitch.Range, cpu stitch.Range,
 }
 
 func newTestCluster() cluster {
+	ca, err := certs.NewAuthority()
+	if err != nil {
+		panic(err)
+	}
+
 	conn := db.New()
 	clst := cluster{
 		conn:      conn,
 		providers: make(map[db.Provider]provider.Provider),
+		authority: ca,
 	}
 
 	clst.providers[FakeAmazon] = newFakeProvider(amazonCloudConfig)
@@ -114,7 +121,7 @@ func TestPanicBadProvider(t *testing.T) {
 	}()
 	allProviders = []db.Provider{FakeAmazon}
 	conn := db.New()
-	newCluster(conn, "test")
+	newCluster(conn, "test", certs.Authority{})
 }
 
 func TestSyncDB(t *testing.T) {
@@ -295,3 +302,69 @@ func TestACLs(t *testing.T) {
 func emptySlices(slice1 interface{}, slice2 interface{}) bool {
 	return reflect.ValueOf(slice1).Len() == 0 && reflect.ValueOf(slice2).Len() == 0
 }
+
+func TestReplaceDisconnected(t *testing.T) {
+	clst := newTestCluster()
+	clst.fm = createForeman(clst.conn, "", certs.Authority{}, certs.Credentials{})
+	amazon := clst.providers[FakeAmazon].(*fakeProvider)
+
+	var machines []db.Machine
+	clst.conn.Transact(func(view db.Database) error {
+		for _, id := range []string{"1", "2"} {
+			m := view.InsertMachine()
+			m.Role = db.Worker
+			m.Provider = FakeAmazon
+			m.CloudID = id
+			m.PublicIP = id + ".1.1.1"
+			m.PrivateIP = id + ".1.1.1"
+			view.Commit(m)
+			machines = append(machines, m)
+
+			amazon.machines[id] = provider.Machine{ID: id,
+				Provider: FakeAmazon, PublicIP: m.PublicIP,
+				PrivateIP: m.PrivateIP}
+		}
+		return nil
+	})
+
+	start := time.Now()
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	clients := &clients{make(map[string]*fakeClient), 0}
+	for _, m := range machines {
+		fc := &fakeClient{clients: clients, ip: m.PublicIP}
+		clients.clients[m.PublicIP] = fc
+		clst.fm.minions[m.PublicIP] = &minion{machine: m, lastConnected: start,
+			client: fc}
+	}
+	clst.fm.minions["1.1.1.1"].connected = true
+
+	// Machines are given until the deadline to connect.
+	clst.sync()
+	if len(amazon.stopRequests) != 0 || len(amazon.bootRequests) != 0 {
+		t.Errorf("Unexpected stops %v and boots %v", amazon.stopRequests,
+			amazon.bootRequests)
+	}
+
+	now = func() time.Time { return start.Add(disconnectDeadline + time.Second) }
+	clst.sync()
+	if !reflect.DeepEqual(amazon.stopRequests, []string{"2"}) ||
+		len(amazon.bootRequests) != 1 {
+		t.Errorf("Expected machine 2 to be replaced, got stops %v and boots %v",
+			amazon.stopRequests, amazon.bootRequests)
+	}
+	amazon.clearLogs()
+
+	if _, ok := clst.fm.minions["2.1.1.1"]; ok {
+		t.Error("The replaced machine's minion should no longer be tracked")
+	}
+
+	// Nothing is replaced if no minion is connected.
+	clst.fm.minions["1.1.1.1"].connected = false
+	clst.fm.minions["1.1.1.1"].lastConnected = start
+	clst.sync()
+	if len(amazon.stopRequests) != 0 {
+		t.Errorf("Unexpected stops %v", amazon.stopRequests)
+	}
+}
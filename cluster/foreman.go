@@ -3,6 +3,7 @@ package cluster
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -11,8 +12,10 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/minion/pb"
+	"github.com/NetSys/quilt/stitch"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -29,31 +32,53 @@ type clientImpl struct {
 	cc *grpc.ClientConn
 }
 
+// How long the minion on a booted machine may stay disconnected before the machine is
+// replaced.  It leaves new machines plenty of time to install and start their minions.
+var disconnectDeadline = 10 * time.Minute
+
 type foreman struct {
-	conn db.Conn
+	conn      db.Conn
+	namespace string
 
 	minions map[string]*minion
 	spec    string
 
-	// Making this a struct member allows us to mock it out.
+	// The names of the secrets referenced by the containers in `spec`.
+	secretNames map[string]struct{}
+
+	// Making these struct members allows us to mock them out.
 	newClient func(string) (client, error)
+	bootstrap func(string) (bool, error)
 }
 
 type minion struct {
 	client    client
 	connected bool
 
+	// When the minion was last connected, or first tracked if it never has been.
+	lastConnected time.Time
+
 	machine db.Machine
 	config  pb.MinionConfig
 
 	mark bool /* Mark and sweep garbage collection. */
 }
 
-func createForeman(conn db.Conn) foreman {
+// createForeman returns a foreman that connects to minions with the Admin credentials
+// `creds`, and has `ca` sign the certificates of new minions.
+func createForeman(conn db.Conn, namespace string, ca certs.Authority,
+	creds certs.Credentials) foreman {
+
 	return foreman{
 		conn:      conn,
+		namespace: namespace,
 		minions:   make(map[string]*minion),
-		newClient: newClient,
+		newClient: func(ip string) (client, error) {
+			return newClient(ip, creds)
+		},
+		bootstrap: func(ip string) (bool, error) {
+			return bootstrap(ip, ca, creds)
+		},
 	}
 }
 
@@ -65,9 +90,7 @@ func (fm *foreman) stop() {
 
 func (fm *foreman) init() {
 	fm.conn.Transact(func(view db.Database) error {
-		machines := view.SelectFromMachine(func(m db.Machine) bool {
-			return m.PublicIP != "" && m.PrivateIP != "" && m.CloudID != ""
-		})
+		machines := view.SelectFromMachine(fm.isBooted)
 
 		fm.updateMinionMap(machines)
 
@@ -91,21 +114,35 @@ func (fm *foreman) init() {
 
 func (fm *foreman) runOnce() {
 	var machines []db.Machine
+	var secrets map[string]string
 	fm.conn.Transact(func(view db.Database) error {
-		machines = view.SelectFromMachine(func(m db.Machine) bool {
-			return m.PublicIP != "" && m.PrivateIP != "" && m.CloudID != ""
-		})
+		machines = view.SelectFromMachine(fm.isBooted)
+		secrets = view.SecretMap()
 
-		fm.spec = ""
-		clst, _ := view.GetCluster()
+		clst, _ := view.GetCluster(fm.namespace)
+		if clst.Spec != fm.spec {
+			fm.secretNames = secretNames(clst.Spec)
+		}
 		fm.spec = clst.Spec
 		return nil
 	})
 
+	// Workers only get the secrets their deployment uses, rather than those of
+	// every namespace.
+	for name := range secrets {
+		if _, ok := fm.secretNames[name]; !ok {
+			delete(secrets, name)
+		}
+	}
+
 	fm.updateMinionMap(machines)
 
 	/* Request the current configuration from each minion. */
 	fm.forEachMinion(func(m *minion) {
+		if !m.connected {
+			fm.bootstrapMinion(m)
+		}
+
 		var err error
 		m.config, err = m.client.getMinion()
 
@@ -114,6 +151,10 @@ func (fm *foreman) runOnce() {
 			log.WithField("machine", m.machine).Debug("New connection.")
 		}
 
+		if connected {
+			m.lastConnected = now()
+		}
+
 		if connected != m.machine.Connected {
 			fm.conn.Transact(func(view db.Database) error {
 				m.machine.Connected = connected
@@ -145,9 +186,23 @@ func (fm *foreman) runOnce() {
 			Provider:  string(m.machine.Provider),
 			Size:      m.machine.Size,
 			Region:    m.machine.Region,
+
+			Unschedulable: m.machine.Unschedulable,
+			Draining:      m.machine.Draining,
+		}
+
+		// Only workers start containers, so only they need to resolve secrets.
+		// The daemon forgets its secrets when it restarts, so workers keep
+		// those they have until the secrets are set again.
+		switch {
+		case m.machine.Role != db.Worker:
+		case len(secrets) > 0:
+			newConfig.Secrets = secrets
+		default:
+			newConfig.Secrets = m.config.Secrets
 		}
 
-		if newConfig == m.config {
+		if reflect.DeepEqual(newConfig, m.config) {
 			return
 		}
 
@@ -161,6 +216,84 @@ func (fm *foreman) runOnce() {
 	})
 }
 
+// bootstrapMinion has the authority sign the certificate of `m`, if it's still
+// self-signed.  The foreman's connection to the minion failed to verify the old
+// certificate, and won't be retried, so it's replaced.
+func (fm *foreman) bootstrapMinion(m *minion) {
+	signed, err := fm.bootstrap(m.machine.PublicIP)
+	if err != nil {
+		log.WithError(err).Debug("Failed to bootstrap minion.")
+		return
+	}
+
+	if !signed {
+		return
+	}
+
+	client, err := fm.newClient(m.machine.PublicIP)
+	if err != nil {
+		return
+	}
+
+	m.client.Close()
+	m.client = client
+}
+
+// secretNames returns the names of the secrets referenced by the containers in `spec`.
+func secretNames(spec string) map[string]struct{} {
+	names := map[string]struct{}{}
+	compiled, err := stitch.New(spec, stitch.DefaultImportGetter)
+	if err != nil {
+		log.WithError(err).Warn("Invalid spec.")
+		return names
+	}
+
+	for _, c := range compiled.QueryContainers() {
+		for _, value := range c.Env {
+			if name, ok := stitch.SecretName(value); ok {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	return names
+}
+
+// disconnected returns the machines whose minions have been disconnected for longer
+// than `disconnectDeadline`.  If no minion is connected at all, it's more likely that
+// the foreman has lost connectivity itself, so none are returned.
+func (fm *foreman) disconnected() []db.Machine {
+	var res []db.Machine
+	anyConnected := false
+	for _, m := range fm.minions {
+		if m.connected {
+			anyConnected = true
+		} else if now().Sub(m.lastConnected) > disconnectDeadline {
+			res = append(res, m.machine)
+		}
+	}
+
+	if !anyConnected {
+		return nil
+	}
+	return res
+}
+
+// forget stops tracking the minion at `publicIP`, so that a machine that replaces it
+// is given a fresh deadline.
+func (fm *foreman) forget(publicIP string) {
+	if m, ok := fm.minions[publicIP]; ok {
+		m.client.Close()
+		delete(fm.minions, publicIP)
+	}
+}
+
+// isBooted returns true if `m` belongs to the foreman's namespace and has been booted
+// by its cloud provider.
+func (fm *foreman) isBooted(m db.Machine) bool {
+	return m.Namespace == fm.namespace && m.PublicIP != "" && m.PrivateIP != "" &&
+		m.CloudID != ""
+}
+
 func (fm *foreman) updateMinionMap(machines []db.Machine) {
 	for _, m := range machines {
 		min, ok := fm.minions[m.PublicIP]
@@ -169,7 +302,7 @@ func (fm *foreman) updateMinionMap(machines []db.Machine) {
 			if err != nil {
 				continue
 			}
-			min = &minion{client: client}
+			min = &minion{client: client, lastConnected: now()}
 			fm.minions[m.PublicIP] = min
 		}
 
@@ -199,8 +332,13 @@ func (fm *foreman) forEachMinion(do func(minion *minion)) {
 	wg.Wait()
 }
 
-func newClient(ip string) (client, error) {
-	cc, err := grpc.Dial(ip+":9999", grpc.WithInsecure())
+func newClient(ip string, creds certs.Credentials) (client, error) {
+	security, err := creds.DialOption()
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := grpc.Dial(ip+":9999", security)
 	if err != nil {
 		return nil, err
 	}
@@ -208,6 +346,51 @@ func newClient(ip string) (client, error) {
 	return clientImpl{pb.NewMinionClient(cc), cc}, nil
 }
 
+// bootstrap has `ca` sign the certificate of the minion at `ip`, and returns true, if
+// the certificate is self-signed.  Minions generate their own keys at boot, so that
+// they're never sent over the network or stored where containers may read them.  Like
+// a new SSH host key, the first certificate a minion presents is trusted.
+func bootstrap(ip string, ca certs.Authority, creds certs.Credentials) (bool, error) {
+	addr := ip + ":9999"
+	cert, err := creds.ServerCert(addr)
+	if err != nil {
+		return false, err
+	}
+
+	if (certs.Credentials{CA: creds.CA, Cert: cert}).Signed() {
+		return false, nil
+	}
+
+	signed, err := ca.Sign(cert, certs.Minion)
+	if err != nil {
+		return false, err
+	}
+
+	security, err := creds.PinnedDialOption(cert)
+	if err != nil {
+		return false, err
+	}
+
+	cc, err := grpc.Dial(addr, security, grpc.WithBlock(),
+		grpc.WithTimeout(10*time.Second))
+	if err != nil {
+		return false, err
+	}
+	defer cc.Close()
+
+	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	reply, err := pb.NewMinionClient(cc).SetCertificate(ctx,
+		&pb.Certificate{Cert: string(signed)})
+	if err != nil {
+		return false, err
+	} else if !reply.Success {
+		return false, errors.New(reply.Error)
+	}
+
+	log.WithField("ip", ip).Info("Signed minion certificate.")
+	return true, nil
+}
+
 func (c clientImpl) getMinion() (pb.MinionConfig, error) {
 	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
 	cfg, err := c.GetMinionConfig(ctx, &pb.Request{})
@@ -1,8 +1,10 @@
 package cluster
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/minion/pb"
 	"github.com/davecgh/go-spew/spew"
@@ -226,8 +228,153 @@ func TestConfigConsistency(t *testing.T) {
 	})
 }
 
+func TestSecrets(t *testing.T) {
+	fm, clients := startTest()
+	var master, worker db.Machine
+	fm.conn.Transact(func(view db.Database) error {
+		master = view.InsertMachine()
+		master.PublicIP = "1.1.1.1"
+		master.PrivateIP = master.PublicIP
+		master.CloudID = "ID1"
+		view.Commit(master)
+
+		worker = view.InsertMachine()
+		worker.PublicIP = "2.2.2.2"
+		worker.PrivateIP = worker.PublicIP
+		worker.CloudID = "ID2"
+		view.Commit(worker)
+		return nil
+	})
+
+	fm.init()
+	fm.conn.Transact(func(view db.Database) error {
+		master.Role = db.Master
+		worker.Role = db.Worker
+		view.Commit(master)
+		view.Commit(worker)
+
+		for name, value := range map[string]string{
+			"password": "hunter2",
+			"other":    "unused",
+		} {
+			secret := view.InsertSecret()
+			secret.Name = name
+			secret.Value = value
+			view.Commit(secret)
+		}
+
+		clst := view.InsertCluster()
+		clst.Spec = `deployment.deploy(new Label("foo", [new Container("image")
+			.withEnv({"pass": new Secret("password")})]));`
+		view.Commit(clst)
+		return nil
+	})
+	fm.runOnce()
+
+	// Only workers start containers, so only they need the secrets, and only those
+	// that the spec references.
+	if secrets := clients.clients["1.1.1.1"].mc.Secrets; secrets != nil {
+		t.Errorf("Master received secrets: %v", secrets)
+	}
+
+	exp := map[string]string{"password": "hunter2"}
+	if secrets := clients.clients["2.2.2.2"].mc.Secrets; !reflect.DeepEqual(
+		secrets, exp) {
+		t.Errorf("Worker has secrets %v, expected %v", secrets, exp)
+	}
+
+	// After a restart, the daemon has no secrets until they're set again, but the
+	// worker keeps its own so that its containers aren't killed.
+	fm.conn.Transact(func(view db.Database) error {
+		for _, secret := range view.SelectFromSecret(nil) {
+			view.Remove(secret)
+		}
+		return nil
+	})
+	fm.runOnce()
+
+	if secrets := clients.clients["2.2.2.2"].mc.Secrets; !reflect.DeepEqual(
+		secrets, exp) {
+		t.Errorf("Worker has secrets %v, expected %v", secrets, exp)
+	}
+}
+
+func TestCordon(t *testing.T) {
+	fm, clients := startTest()
+	fm.conn.Transact(func(view db.Database) error {
+		worker := view.InsertMachine()
+		worker.PublicIP = "2.2.2.2"
+		worker.PrivateIP = worker.PublicIP
+		worker.CloudID = "ID2"
+		worker.Role = db.Worker
+		view.Commit(worker)
+		return nil
+	})
+
+	fm.init()
+	fm.runOnce()
+	if mc := clients.clients["2.2.2.2"].mc; mc.Unschedulable || mc.Draining {
+		t.Errorf("Worker was cordoned before asked: %v", mc)
+	}
+
+	fm.conn.Transact(func(view db.Database) error {
+		worker := view.SelectFromMachine(nil)[0]
+		worker.Unschedulable = true
+		worker.Draining = true
+		view.Commit(worker)
+		return nil
+	})
+	fm.runOnce()
+
+	if mc := clients.clients["2.2.2.2"].mc; !mc.Unschedulable || !mc.Draining {
+		t.Errorf("Worker wasn't cordoned and drained: %v", mc)
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	fm, clients := startTest()
+	fm.conn.Transact(func(view db.Database) error {
+		m := view.InsertMachine()
+		m.PublicIP = "1.1.1.1"
+		m.PrivateIP = "1.1.1.1"
+		m.CloudID = "ID"
+		view.Commit(m)
+		return nil
+	})
+
+	// The minion's connection is replaced once its certificate is signed.
+	var bootstrapped []string
+	fm.bootstrap = func(ip string) (bool, error) {
+		bootstrapped = append(bootstrapped, ip)
+		return true, nil
+	}
+	newClient := fm.newClient
+	fm.newClient = func(ip string) (client, error) {
+		delete(clients.clients, ip)
+		return newClient(ip)
+	}
+
+	fm.runOnce()
+	if !reflect.DeepEqual(bootstrapped, []string{"1.1.1.1"}) {
+		t.Errorf("Bootstrapped %v, expected [1.1.1.1]", bootstrapped)
+	}
+	if clients.newCalls != 2 {
+		t.Errorf("clients.newCalls = %d, want 2", clients.newCalls)
+	}
+	if fc := clients.clients["1.1.1.1"]; fm.minions["1.1.1.1"].client != fc {
+		t.Errorf("Minion kept its old client: %s", spew.Sdump(clients))
+	}
+
+	// Connected minions are left alone.
+	fm.runOnce()
+	if len(bootstrapped) != 1 || clients.newCalls != 2 {
+		t.Errorf("Unexpected bootstrap of a connected minion: %v", bootstrapped)
+	}
+}
+
 func startTest() (foreman, *clients) {
-	fm := createForeman(db.New())
+	fm := createForeman(db.New(), "", certs.Authority{}, certs.Credentials{})
+	fm.bootstrap = func(ip string) (bool, error) { return false, nil }
 	clients := &clients{make(map[string]*fakeClient), 0}
 	fm.newClient = func(ip string) (client, error) {
 		if fc, ok := clients.clients[ip]; ok {
@@ -242,7 +389,8 @@ func startTest() (foreman, *clients) {
 }
 
 func startTestWithRole(role pb.MinionConfig_Role) foreman {
-	fm := createForeman(db.New())
+	fm := createForeman(db.New(), "", certs.Authority{}, certs.Credentials{})
+	fm.bootstrap = func(ip string) (bool, error) { return false, nil }
 	clientInst := &clients{make(map[string]*fakeClient), 0}
 	fm.newClient = func(ip string) (client, error) {
 		fc := &fakeClient{clientInst, ip, pb.MinionConfig{Role: role},
@@ -276,5 +424,7 @@ func (fc *fakeClient) getMinion() (pb.MinionConfig, error) {
 }
 
 func (fc *fakeClient) Close() {
-	delete(fc.clients.clients, fc.ip)
+	if fc.clients.clients[fc.ip] == fc {
+		delete(fc.clients.clients, fc.ip)
+	}
 }
@@ -210,7 +210,7 @@ func (clst *azureCluster) Boot(bootSet []Machine) error {
 			return err
 		}
 
-		cloudConfig := cloudConfigUbuntu(m.SSHKeys, "xenial")
+		cloudConfig := cloudConfigUbuntu(m.SSHKeys, "xenial", m.CA)
 		if err := clst.configureVirtualMachine(vmName, osDiskName, nicName,
 			cloudConfig, m.Size, m.Region, iface); err != nil {
 			return err
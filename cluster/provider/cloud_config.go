@@ -73,14 +73,23 @@ initialize_minion() {
 	ExecStart=/usr/bin/docker run --net=host --name=minion --privileged \
 	-v /var/run/docker.sock:/var/run/docker.sock \
 	-v /etc/ssl/certs/ca-certificates.crt:/etc/ssl/certs/ca-certificates.crt \
+	-v /etc/quilt/tls:/etc/quilt/tls \
 	-v /proc:/hostproc:ro -v /var/run/netns:/var/run/netns:rw %[1]s \
-	/quilt minion
+	/quilt -tls-dir=/etc/quilt/tls minion
 
 	[Install]
 	WantedBy=multi-user.target
 	EOF
 }
 
+initialize_tls() {
+	install -d -m 700 /etc/quilt/tls
+
+	cat <<- EOF > /etc/quilt/tls/ca.crt
+	%[4]s
+	EOF
+}
+
 install_docker() {
 	echo "deb https://apt.dockerproject.org/repo ubuntu-%[3]s main" > /etc/apt/sources.list.d/docker.list
 	apt-get update
@@ -115,6 +124,7 @@ export DEBIAN_FRONTEND=noninteractive
 install_docker
 initialize_ovs
 initialize_docker
+initialize_tls
 initialize_minion
 
 ssh_keys="%[2]s"
@@ -133,7 +143,17 @@ echo -n "Completed Boot Script: " >> /var/log/bootscript.log
 date >> /var/log/bootscript.log
     `
 
-func cloudConfigUbuntu(keys []string, ubuntuVersion string) string {
+// cloudConfigUbuntu returns the boot script of a machine.  Only the authority's
+// certificate is included, as the script is readable through the cloud provider's
+// metadata service, which containers may reach.  The minion generates its own key.
+func cloudConfigUbuntu(keys []string, ubuntuVersion string, ca []byte) string {
 	keyStr := strings.Join(keys, "\n")
-	return fmt.Sprintf(cloudConfigFormat, quiltImage, keyStr, ubuntuVersion)
+	return fmt.Sprintf(cloudConfigFormat, quiltImage, keyStr, ubuntuVersion,
+		indentPEM(ca))
+}
+
+// indentPEM prefixes each line of `pem` after the first with a tab, so that it lines
+// up with the rest of the tab stripped heredoc it's interpolated into.
+func indentPEM(pem []byte) string {
+	return strings.Replace(strings.TrimSpace(string(pem)), "\n", "\n\t", -1)
 }
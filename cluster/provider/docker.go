@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/minion/docker"
+	"github.com/NetSys/quilt/stitch"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	// The docker-in-docker image that hosts each machine's minion.  Its version
+	// matches the docker-engine installed on cloud machines.
+	dindImage = "docker:1.12-dind"
+
+	// Labels identifying the containers that are machines, and their attributes.
+	dockerNamespaceLabel = "quilt.machine.namespace"
+	dockerSizeLabel      = "quilt.machine.size"
+)
+
+var dockerSocket = "unix:///var/run/docker.sock"
+
+// dockerBootScript starts a docker daemon inside the machine container, and then runs
+// the minion on it just as the cloud config does.  The authority's certificate is
+// passed in through the environment.
+var dockerBootScript = `set -e
+
+install -d -m 700 /etc/quilt/tls
+printf '%%s\n' "$QUILT_TLS_CA" > /etc/quilt/tls/ca.crt
+mkdir -p /var/run/netns
+
+modprobe openvswitch || true
+modprobe vport_geneve || true
+
+dind dockerd --bridge=none -H unix:///var/run/docker.sock &
+until docker info > /dev/null 2>&1; do sleep 1; done
+
+docker pull %[1]s
+exec docker run --net=host --name=minion --privileged \
+	-v /var/run/docker.sock:/var/run/docker.sock \
+	-v /etc/ssl/certs/ca-certificates.crt:/etc/ssl/certs/ca-certificates.crt \
+	-v /etc/quilt/tls:/etc/quilt/tls \
+	-v /proc:/hostproc:ro -v /var/run/netns:/var/run/netns:rw %[1]s \
+	/quilt -tls-dir=/etc/quilt/tls minion
+`
+
+// dockerCluster boots machines as privileged containers on the local docker daemon.
+// Each runs its own docker daemon, on which the minion starts containers just as it
+// would on a cloud machine.
+type dockerCluster struct {
+	namespace string
+	client    docker.Client
+}
+
+func (clst *dockerCluster) Connect(namespace string) error {
+	client := docker.New(dockerSocket)
+	if _, err := client.List(nil); err != nil {
+		return fmt.Errorf("failed to connect to docker: %s", err)
+	}
+
+	clst.namespace = namespace
+	clst.client = client
+	return nil
+}
+
+func (clst dockerCluster) Boot(bootSet []Machine) error {
+	// If any of the bootContainer() calls fail, errChan will contain exactly one
+	// error for this function to return.
+	errChan := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	for _, m := range bootSet {
+		wg.Add(1)
+		go func(m Machine) {
+			defer wg.Done()
+			if err := clst.bootContainer(m); err != nil {
+				select {
+				case errChan <- err:
+				default:
+				}
+			}
+		}(m)
+	}
+	wg.Wait()
+
+	var err error
+	select {
+	case err = <-errChan:
+	default:
+	}
+
+	return err
+}
+
+func (clst dockerCluster) bootContainer(m Machine) error {
+	ram, cpu, err := parseDockerSize(m.Size)
+	if err != nil {
+		return err
+	}
+
+	opts := docker.RunOptions{
+		Image: dindImage,
+		Args:  []string{"sh", "-c", fmt.Sprintf(dockerBootScript, quiltImage)},
+		Labels: map[string]string{
+			dockerNamespaceLabel: clst.namespace,
+			dockerSizeLabel:      m.Size,
+		},
+		Env:        map[string]string{"QUILT_TLS_CA": string(m.CA)},
+		Privileged: true,
+		Binds:      []string{"/lib/modules:/lib/modules:ro"},
+	}
+
+	if ram > 0 {
+		opts.Memory = docker.MemoryLimit(ram)
+	}
+
+	if cpu > 0 {
+		opts.CPUQuota = docker.CPUQuota(cpu)
+	}
+
+	_, err = clst.client.Run(opts)
+	return err
+}
+
+func (clst dockerCluster) List() ([]Machine, error) {
+	containers, err := clst.client.List(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	machines := []Machine{}
+	for _, c := range containers {
+		if ns, ok := c.Labels[dockerNamespaceLabel]; !ok || ns != clst.namespace {
+			continue
+		}
+
+		if c.IP == "" {
+			log.Debugf("Docker machine %s doesn't have an IP yet.", c.ID)
+		}
+
+		machines = append(machines, Machine{
+			ID:        c.ID,
+			PublicIP:  c.IP,
+			PrivateIP: c.IP,
+			Provider:  db.Docker,
+			Size:      c.Labels[dockerSizeLabel],
+		})
+	}
+	return machines, nil
+}
+
+func (clst dockerCluster) Stop(machines []Machine) error {
+	for _, m := range machines {
+		if err := clst.client.RemoveID(m.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetACLs is a noop, as the machines are only reachable from this host.
+func (clst dockerCluster) SetACLs(acls []string) error {
+	return nil
+}
+
+// ChooseSize encodes the smallest RAM and CPU acceptable as the size, which limits the
+// resources of the machine's container.  A limit of 0 leaves it unrestricted.
+func (clst dockerCluster) ChooseSize(ram stitch.Range, cpu stitch.Range,
+	maxPrice float64) string {
+	return fmt.Sprintf("%g,%g", ram.Min, cpu.Min)
+}
+
+// parseDockerSize returns the gigabytes of RAM and number of CPUs encoded in `size`.
+// An empty size has no limits.
+func parseDockerSize(size string) (ram, cpu float64, err error) {
+	if size == "" {
+		return 0, 0, nil
+	}
+
+	fields := strings.Split(size, ",")
+	if len(fields) != 2 {
+		return 0, 0, errors.New("malformed docker machine size: " + size)
+	}
+
+	if ram, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return 0, 0, err
+	}
+
+	if cpu, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return 0, 0, err
+	}
+	return ram, cpu, nil
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/minion/docker"
+	"github.com/NetSys/quilt/stitch"
+)
+
+func TestDockerCluster(t *testing.T) {
+	md, dk := docker.NewMock()
+	clst := dockerCluster{namespace: "ns", client: dk}
+	other := dockerCluster{namespace: "other", client: dk}
+
+	size := clst.ChooseSize(stitch.Range{Min: 2}, stitch.Range{Min: 1}, 0)
+	if size != "2,1" {
+		t.Errorf("Chose size %s, expected 2,1", size)
+	}
+
+	err := clst.Boot([]Machine{{Size: size, CA: []byte("ca")}, {}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if err := other.Boot([]Machine{{}}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for id, c := range md.Containers {
+		c.NetworkSettings.IPAddress = "172.17.0." + id[:1]
+		if c.Config.Labels[dockerSizeLabel] == "2,1" {
+			if c.HostConfig.Memory != docker.MemoryLimit(2) ||
+				c.HostConfig.CPUQuota != docker.CPUQuota(1) {
+				t.Errorf("Wrong resource limits: %+v", c.HostConfig)
+			}
+
+			if !c.HostConfig.Privileged {
+				t.Error("Machine containers should be privileged")
+			}
+		}
+	}
+
+	machines, err := clst.List()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(machines) != 2 {
+		t.Fatalf("Listed %v, expected the 2 machines in the namespace", machines)
+	}
+
+	sizes := map[string]bool{}
+	for _, m := range machines {
+		if m.Provider != db.Docker || m.PublicIP == "" ||
+			m.PublicIP != m.PrivateIP {
+			t.Errorf("Bad machine: %+v", m)
+		}
+		sizes[m.Size] = true
+	}
+
+	if exp := map[string]bool{"2,1": true, "": true}; !reflect.DeepEqual(sizes,
+		exp) {
+		t.Errorf("Listed sizes %v, expected %v", sizes, exp)
+	}
+
+	if err := clst.Stop(machines[:1]); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if machines, _ = clst.List(); len(machines) != 1 {
+		t.Errorf("Listed %v after stopping, expected 1 machine", machines)
+	}
+
+	if machines, _ = other.List(); len(machines) != 1 {
+		t.Errorf("Listed %v in other namespace, expected 1 machine", machines)
+	}
+}
+
+func TestParseDockerSize(t *testing.T) {
+	ram, cpu, err := parseDockerSize("1.5,2")
+	if ram != 1.5 || cpu != 2 || err != nil {
+		t.Errorf("Parsed %g, %g, %v; expected 1.5, 2, nil", ram, cpu, err)
+	}
+
+	ram, cpu, err = parseDockerSize("")
+	if ram != 0 || cpu != 0 || err != nil {
+		t.Errorf("Parsed %g, %g, %v; expected 0, 0, nil", ram, cpu, err)
+	}
+
+	if _, _, err := parseDockerSize("large"); err == nil {
+		t.Error("Expected an error parsing a malformed size")
+	}
+}
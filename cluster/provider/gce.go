@@ -136,7 +136,7 @@ func (clst *gceCluster) Boot(bootSet []Machine) error {
 	for _, m := range bootSet {
 		name := "quilt-" + uuid.NewV4().String()
 		_, err := clst.instanceNew(name, m.Size, m.Region,
-			cloudConfigUbuntu(m.SSHKeys, "xenial"))
+			cloudConfigUbuntu(m.SSHKeys, "xenial", m.CA))
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err,
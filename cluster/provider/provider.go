@@ -17,6 +17,10 @@ type Machine struct {
 	SSHKeys   []string
 	Provider  db.Provider
 	Region    string
+
+	// The PEM encoded certificate of the authority the machine's minion trusts.
+	// Only set when booting.
+	CA []byte
 }
 
 // Provider defines an interface for interacting with cloud providers.
@@ -45,6 +49,8 @@ func New(dbp db.Provider) Provider {
 		return &azureCluster{}
 	case db.Vagrant:
 		return &vagrantCluster{}
+	case db.Docker:
+		return &dockerCluster{}
 	default:
 		panic("Unimplemented")
 	}
@@ -80,7 +86,7 @@ func DefaultRegion(m db.Machine) db.Machine {
 		region = "us-east1-b"
 	case "Azure":
 		region = "centralus"
-	case "Vagrant":
+	case "Vagrant", "Docker":
 	default:
 		panic(fmt.Sprintf("Unknown Cloud Provider: %s", m.Provider))
 	}
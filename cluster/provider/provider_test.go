@@ -47,6 +47,14 @@ func TestDefaultRegion(t *testing.T) {
 		t.Errorf("expected %s, found %s", exp, m.Region)
 	}
 
+	m.Region = ""
+	m.Provider = "Docker"
+	exp = ""
+	m = DefaultRegion(m)
+	if m.Region != exp {
+		t.Errorf("expected %s, found %s", exp, m.Region)
+	}
+
 	m.Region = ""
 	m.Provider = "Panic"
 	defer func() {
@@ -124,6 +132,7 @@ func TestNewProviderSuccess(t *testing.T) {
 	New(db.Amazon)
 	New(db.Google)
 	New(db.Vagrant)
+	New(db.Docker)
 }
 
 func TestNewProviderFailure(t *testing.T) {
@@ -168,10 +177,10 @@ func TestGroupBy(t *testing.T) {
 func TestCloudConfig(t *testing.T) {
 	t.Parallel()
 
-	cloudConfigFormat = "(%v) (%v) (%v)"
+	cloudConfigFormat = "(%v) (%v) (%v) (%v)"
 
-	res := cloudConfigUbuntu([]string{"a", "b"}, "1")
-	exp := "(quay.io/kklin/quilt:latest) (a\nb) (1)"
+	res := cloudConfigUbuntu([]string{"a", "b"}, "1", []byte("c\na\n"))
+	exp := "(quay.io/kklin/quilt:latest) (a\nb) (1) (c\n\ta)"
 	if res != exp {
 		t.Errorf("res: %s\nexp: %s", res, exp)
 	}
@@ -58,7 +58,8 @@ func (clst vagrantCluster) Boot(bootSet []Machine) error {
 func bootMachine(vagrant vagrantAPI, m Machine) error {
 	id := uuid.NewV4().String()
 
-	err := vagrant.Init(cloudConfigUbuntu(m.SSHKeys, "xenial"), m.Size, id)
+	cloudConfig := cloudConfigUbuntu(m.SSHKeys, "xenial", m.CA)
+	err := vagrant.Init(cloudConfig, m.Size, id)
 	if err == nil {
 		err = vagrant.Up(id)
 	}
@@ -9,3 +9,17 @@ type Description struct {
 	Disk   string
 	Region string
 }
+
+// LookupSize returns the description of the VM type named `size`, if any provider
+// offers it.
+func LookupSize(size string) (Description, bool) {
+	for _, descriptions := range [][]Description{AwsDescriptions,
+		GoogleDescriptions, AzureDescriptions} {
+		for _, d := range descriptions {
+			if d.Size == size {
+				return d, true
+			}
+		}
+	}
+	return Description{}, false
+}
@@ -1,7 +1,6 @@
 package db
 
 import (
-	"errors"
 	"fmt"
 	"log"
 )
@@ -35,17 +34,30 @@ func (db Database) SelectFromCluster(check func(Cluster) bool) []Cluster {
 	return result
 }
 
-// GetCluster gets the cluster from the database. There should only ever be a single
-// cluster.
-func (db Database) GetCluster() (Cluster, error) {
-	clusters := db.SelectFromCluster(nil)
+// SelectFromCluster gets all clusters in the database connection that satisfy 'check'.
+func (conn Conn) SelectFromCluster(check func(Cluster) bool) []Cluster {
+	var clusters []Cluster
+	conn.Transact(func(view Database) error {
+		clusters = view.SelectFromCluster(check)
+		return nil
+	})
+	return clusters
+}
+
+// GetCluster gets the cluster with the given namespace from the database. There should
+// only ever be a single cluster per namespace.
+func (db Database) GetCluster(namespace string) (Cluster, error) {
+	clusters := db.SelectFromCluster(func(c Cluster) bool {
+		return c.Namespace == namespace
+	})
 	numClusters := len(clusters)
 	if numClusters == 1 {
 		return clusters[0], nil
 	} else if numClusters > 1 {
-		log.Panicf("Found %d clusters, there should be 1", numClusters)
+		log.Panicf("Found %d clusters in namespace %s, there should be 1",
+			numClusters, namespace)
 	}
-	return Cluster{}, errors.New("no clusters found")
+	return Cluster{}, fmt.Errorf("no cluster in namespace %s", namespace)
 }
 
 func (c Cluster) getID() int {
@@ -5,14 +5,16 @@ import (
 )
 
 // A Connection allows the members of two labels to speak to each other on the port
-// range [MinPort, MaxPort] inclusive.
+// range [MinPort, MaxPort] inclusive.  If Protocol is set, only that protocol is
+// allowed, otherwise TCP, UDP, and ICMP all are.
 type Connection struct {
 	ID int
 
-	From    string
-	To      string
-	MinPort int
-	MaxPort int
+	From     string
+	To       string
+	MinPort  int
+	MaxPort  int
+	Protocol string
 }
 
 // InsertConnection creates a new connection row and inserts it into the database.
@@ -55,6 +57,10 @@ func (c Connection) String() string {
 		port += fmt.Sprintf("-%d", c.MaxPort)
 	}
 
+	if c.Protocol != "" {
+		port += "/" + c.Protocol
+	}
+
 	return fmt.Sprintf("Connection-%d{%s->%s:%s}", c.ID, c.From, c.To, port)
 }
 
@@ -70,6 +76,8 @@ func (c Connection) less(r row) bool {
 		return c.MaxPort < o.MaxPort
 	case c.MinPort != o.MaxPort:
 		return c.MinPort < o.MinPort
+	case c.Protocol != o.Protocol:
+		return c.Protocol < o.Protocol
 	default:
 		return c.ID < o.ID
 	}
@@ -64,12 +64,15 @@ const (
 
 	// Azure implements the Azure cloud provider.
 	Azure = "Azure"
+
+	// Docker implements machines as containers on the local docker daemon.
+	Docker = "Docker"
 )
 
 // ParseProvider returns the Provider represented by 'name' or an error.
 func ParseProvider(name string) (Provider, error) {
 	switch name {
-	case "Amazon", "Google", "Vagrant", "Azure":
+	case "Amazon", "Google", "Vagrant", "Azure", "Docker":
 		return Provider(name), nil
 	default:
 		return "", errors.New("unknown provider")
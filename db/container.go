@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/NetSys/quilt/util"
@@ -23,6 +24,65 @@ type Container struct {
 	Command  []string
 	Labels   []string
 	Env      map[string]string
+
+	// Volumes are docker bind specifications of the form "source:mountPoint",
+	// where the source is either a directory on the minion or a named volume.
+	Volumes []string
+
+	// The number of cores and gigabytes of memory the container requires.
+	CPU float64
+	RAM float64
+
+	HealthCheck   HealthCheck
+	RestartPolicy string
+
+	// The state of the container as observed by the worker running it.
+	Health   string
+	Restarts int
+
+	// Why the scheduler couldn't place the container, if it couldn't.
+	Unplaced string
+}
+
+// The restart policies a container may have.  The empty policy is RestartAlways.
+const (
+	RestartAlways    = "always"
+	RestartOnFailure = "on-failure"
+	RestartNever     = "never"
+)
+
+// The health states of a container.  Containers without a health check have no
+// health state until they exit.
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+	HealthExited    = "exited"
+)
+
+// A HealthCheck probes a container every Interval seconds.  Exactly one of Exec, a
+// command run in the container, TCP, a port to connect to, or HTTP, a port to request
+// Path from, is set.
+type HealthCheck struct {
+	Exec []string
+	TCP  int
+	HTTP int
+	Path string
+
+	Interval int
+	Retries  int
+}
+
+// Empty returns true if `hc` doesn't probe anything.
+func (hc HealthCheck) Empty() bool {
+	return len(hc.Exec) == 0 && hc.TCP == 0 && hc.HTTP == 0
+}
+
+// Equal returns true if `hc` and `other` perform the same probe.
+func (hc HealthCheck) Equal(other HealthCheck) bool {
+	return util.StrSliceEqual(hc.Exec, other.Exec) && hc.TCP == other.TCP &&
+		hc.HTTP == other.HTTP && hc.Path == other.Path &&
+		hc.Interval == other.Interval && hc.Retries == other.Retries
 }
 
 // ContainerSlice is an alias for []Container to allow for joins
@@ -47,6 +107,20 @@ func (db Database) SelectFromContainer(check func(Container) bool) []Container {
 	return result
 }
 
+// SelectFromContainerBy gets all containers in the database whose indexed 'field' is
+// equal to 'value', and that satisfy 'check'.  It panics if 'field' isn't indexed.
+func (db Database) SelectFromContainerBy(field string, value interface{},
+	check func(Container) bool) []Container {
+	var result []Container
+	for _, row := range db.tables[ContainerTable].lookup(field, value) {
+		if check == nil || check(row.(Container)) {
+			result = append(result, row.(Container))
+		}
+	}
+
+	return result
+}
+
 // SelectFromContainer gets all containers in the database that satisfy the 'check'.
 func (conn Conn) SelectFromContainer(check func(Container) bool) []Container {
 	var containers []Container
@@ -57,6 +131,31 @@ func (conn Conn) SelectFromContainer(check func(Container) bool) []Container {
 	return containers
 }
 
+// SelectFromContainerBy gets all containers in the database whose indexed 'field' is
+// equal to 'value', and that satisfy 'check'.  It panics if 'field' isn't indexed.
+func (conn Conn) SelectFromContainerBy(field string, value interface{},
+	check func(Container) bool) []Container {
+	var containers []Container
+	conn.Transact(func(view Database) error {
+		containers = view.SelectFromContainerBy(field, value, check)
+		return nil
+	})
+	return containers
+}
+
+// RolloutLabel returns the label whose update strategy applies to the container, and
+// that limits how many of its replicas may be down while it's moved.  Containers with
+// several labels follow the first in alphabetical order.
+func (c Container) RolloutLabel() string {
+	if len(c.Labels) == 0 {
+		return ""
+	}
+
+	sorted := append([]string{}, c.Labels...)
+	sort.Strings(sorted)
+	return sorted[0]
+}
+
 func (c Container) getID() int {
 	return c.ID
 }
@@ -98,6 +197,30 @@ func (c Container) String() string {
 		tags = append(tags, fmt.Sprintf("Env: %s", c.Env))
 	}
 
+	if len(c.Volumes) > 0 {
+		tags = append(tags, fmt.Sprintf("Volumes: %s", c.Volumes))
+	}
+
+	if c.CPU != 0 {
+		tags = append(tags, fmt.Sprintf("CPU: %g", c.CPU))
+	}
+
+	if c.RAM != 0 {
+		tags = append(tags, fmt.Sprintf("RAM: %gGB", c.RAM))
+	}
+
+	if c.Health != "" {
+		tags = append(tags, fmt.Sprintf("Health: %s", c.Health))
+	}
+
+	if c.Restarts != 0 {
+		tags = append(tags, fmt.Sprintf("Restarts: %d", c.Restarts))
+	}
+
+	if c.Unplaced != "" {
+		tags = append(tags, fmt.Sprintf("Unplaced: %s", c.Unplaced))
+	}
+
 	return fmt.Sprintf("Container-%d{%s}", c.ID, strings.Join(tags, ", "))
 }
 
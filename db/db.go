@@ -6,6 +6,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	log "github.com/Sirupsen/logrus"
 )
 
 // The Database is the central storage location for all state in the system.  The policy
@@ -22,6 +24,24 @@ type Trigger struct {
 	stop chan struct{}
 }
 
+// A ChangeTrigger delivers the rows modified by each committed transaction that touches
+// its tables.  Unlike a Trigger, notifications are never coalesced -- every transaction
+// results in exactly one slice of Changes on 'ChangeTrigger.C'.
+type ChangeTrigger struct {
+	C    chan []Change // The channel on which changes are delivered.
+	in   chan []Change
+	stop chan struct{}
+}
+
+// A Change describes the effect of a transaction on a single row.  Inserted rows have a
+// nil 'Old', and removed rows have a nil 'New'.  Otherwise both hold the row's value
+// before and after the transaction respectively.
+type Change struct {
+	Table TableType
+	Old   interface{}
+	New   interface{}
+}
+
 type row interface {
 	less(row) bool
 	String() string
@@ -30,20 +50,25 @@ type row interface {
 
 // A Conn is a database handle on which transactions may be executed.
 type Conn struct {
-	db   Database
-	lock *sync.Mutex
+	db    Database
+	lock  *sync.Mutex
+	store *store // Persists committed transactions, or nil if in-memory only.
 }
 
 // New creates a connection to a brand new database.
 func New() Conn {
+	cn := newConn()
+	cn.runLogger()
+	return cn
+}
+
+func newConn() Conn {
 	db := Database{make(map[TableType]*table), new(int)}
 	for _, t := range allTables {
-		db.tables[t] = newTable()
+		db.tables[t] = newTable(t)
 	}
 
-	cn := Conn{db: db, lock: &sync.Mutex{}}
-	cn.runLogger()
-	return cn
+	return Conn{db: db, lock: &sync.Mutex{}}
 }
 
 // Transact executes database transactions.  It takes a closure, 'do', which is operates
@@ -53,12 +78,38 @@ func (cn Conn) Transact(do func(db Database) error) error {
 	cn.lock.Lock()
 	err := do(cn.db)
 	var alertTables []*table
-	for _, table := range cn.db.tables {
+	persist := false
+	changes := map[ChangeTrigger][]Change{}
+	for tt, table := range cn.db.tables {
 		if table.shouldAlert {
 			alertTables = append(alertTables, table)
 			table.shouldAlert = false
+			persist = persist || persists(tt)
+		}
+
+		tableChanges := table.collectChanges(tt)
+		if len(tableChanges) == 0 {
+			continue
+		}
+
+		for trigger := range table.changeTriggers {
+			changes[trigger] = append(changes[trigger], tableChanges...)
 		}
 	}
+
+	// The snapshot is written while holding the lock so that the file on disk always
+	// reflects a prefix of the committed transactions.
+	if cn.store != nil && persist {
+		if err := cn.store.save(cn.db); err != nil {
+			log.WithError(err).Error("Failed to persist the database.")
+		}
+	}
+
+	// Changes are handed off while holding the lock so that they're delivered in the
+	// order their transactions committed.
+	for trigger, c := range changes {
+		trigger.send(c)
+	}
 	cn.lock.Unlock()
 
 	for _, table := range alertTables {
@@ -114,10 +165,74 @@ func (t Trigger) Stop() {
 	close(t.stop)
 }
 
+// ChangeTrigger registers a new trigger that delivers the row level changes made to the
+// tables 'tt' by each committed transaction.  So that clients may properly initialize,
+// the first notification contains every row already in those tables as an insertion.
+func (cn Conn) ChangeTrigger(tt ...TableType) ChangeTrigger {
+	trigger := ChangeTrigger{
+		C:    make(chan []Change),
+		in:   make(chan []Change),
+		stop: make(chan struct{}),
+	}
+	go trigger.run()
+
+	cn.Transact(func(db Database) error {
+		var initial []Change
+		for _, t := range tt {
+			table := db.tables[t]
+			table.changeTriggers[trigger] = struct{}{}
+			for _, id := range table.sortedIDs() {
+				change := Change{Table: t, New: table.rows[id]}
+				initial = append(initial, change)
+			}
+		}
+		trigger.send(initial)
+		return nil
+	})
+
+	return trigger
+}
+
+// Stop a running change trigger thus allowing resources to be deallocated.  Changes that
+// have not yet been received are discarded.
+func (t ChangeTrigger) Stop() {
+	close(t.stop)
+}
+
+func (t ChangeTrigger) send(changes []Change) {
+	select {
+	case t.in <- changes:
+	case <-t.stop:
+	}
+}
+
+// Queue changes so that transactions never block on slow consumers.
+func (t ChangeTrigger) run() {
+	var queue [][]Change
+	for {
+		var out chan []Change
+		var next []Change
+		if len(queue) > 0 {
+			out = t.C
+			next = queue[0]
+		}
+
+		select {
+		case changes := <-t.in:
+			queue = append(queue, changes)
+		case out <- next:
+			queue = queue[1:]
+		case <-t.stop:
+			return
+		}
+	}
+}
+
 func (db Database) insert(r row) {
 	table := db.tables[getTableType(r)]
+	table.touch(r.getID())
 	table.shouldAlert = true
-	table.rows[r.getID()] = r
+	table.set(r)
 }
 
 // Commit updates the database with the data contained in row.
@@ -131,7 +246,8 @@ func (db Database) Commit(r row) {
 	}
 
 	if table.shouldAlert || !reflect.DeepEqual(r, old) {
-		table.rows[rid] = r
+		table.touch(rid)
+		table.set(r)
 		table.shouldAlert = true
 	}
 }
@@ -139,7 +255,8 @@ func (db Database) Commit(r row) {
 // Remove deletes row from the database.
 func (db Database) Remove(r row) {
 	table := db.tables[getTableType(r)]
-	delete(table.rows, r.getID())
+	table.touch(r.getID())
+	table.remove(r.getID())
 	table.shouldAlert = true
 }
 
@@ -7,7 +7,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/NetSys/quilt/util"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/spf13/afero"
 )
 
 func TestMachine(t *testing.T) {
@@ -217,3 +219,217 @@ func (machines mSort) Swap(i, j int) {
 func (machines mSort) Less(i, j int) bool {
 	return machines[i].ID < machines[j].ID
 }
+
+func TestPersist(t *testing.T) {
+	util.AppFs = afero.NewMemMapFs()
+	defer func() { util.AppFs = afero.NewOsFs() }()
+
+	conn, err := Open("/quilt.db")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var m Machine
+	var clst Cluster
+	conn.Transact(func(view Database) error {
+		clst = view.InsertCluster()
+		clst.Namespace = "ns"
+		clst.AdminACLs = []string{"1.2.3.4/32"}
+		view.Commit(clst)
+
+		m = view.InsertMachine()
+		m.Namespace = "ns"
+		m.Role = Master
+		m.CloudID = "id"
+		m.SSHKeys = []string{"key"}
+		view.Commit(m)
+
+		secret := view.InsertSecret()
+		secret.Name = "key"
+		secret.Value = "value"
+		view.Commit(secret)
+
+		view.Commit(view.InsertContainer())
+		return nil
+	})
+
+	conn, err = Open("/quilt.db")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	conn.Transact(func(view Database) error {
+		clusters := view.SelectFromCluster(nil)
+		if !reflect.DeepEqual(clusters, []Cluster{clst}) {
+			t.Errorf("Got %v, expected %v", clusters, []Cluster{clst})
+		}
+
+		if err := SelectMachineCheck(view, nil, []Machine{m}); err != nil {
+			t.Error(err)
+		}
+
+		if secrets := view.SelectFromSecret(nil); len(secrets) != 0 {
+			t.Errorf("Expected secrets not to be persisted, got %v", secrets)
+		}
+
+		if dbcs := view.SelectFromContainer(nil); len(dbcs) != 0 {
+			t.Errorf("Expected containers not to be persisted, got %v", dbcs)
+		}
+
+		if id := view.InsertMachine().ID; id != m.ID+3 {
+			t.Errorf("Got ID %d, expected %d", id, m.ID+3)
+		}
+		return nil
+	})
+
+	// Machines from before each belonged to a namespace are adopted by the cluster.
+	conn.Transact(func(view Database) error {
+		for _, dbm := range view.SelectFromMachine(nil) {
+			if dbm.ID != m.ID {
+				view.Remove(dbm)
+			}
+		}
+
+		old := m
+		old.Namespace = ""
+		view.Commit(old)
+		return nil
+	})
+
+	conn, err = Open("/quilt.db")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	conn.Transact(func(view Database) error {
+		if err := SelectMachineCheck(view, nil, []Machine{m}); err != nil {
+			t.Error(err)
+		}
+		return nil
+	})
+
+	util.WriteFile("/corrupt.db", []byte("garbage"), 0600)
+	if _, err := Open("/corrupt.db"); err == nil {
+		t.Error("Expected an error opening a corrupt database")
+	}
+}
+
+func TestChangeTrigger(t *testing.T) {
+	conn := New()
+
+	var m Machine
+	conn.Transact(func(view Database) error {
+		m = view.InsertMachine()
+		return nil
+	})
+
+	trig := conn.ChangeTrigger(MachineTable)
+	defer trig.Stop()
+	changeRecv(t, trig, []Change{{Table: MachineTable, New: m}})
+
+	var c Cluster
+	old := m
+	conn.Transact(func(view Database) error {
+		c = view.InsertCluster()
+
+		m.Role = Master
+		view.Commit(m)
+
+		// Rows inserted and removed in the same transaction aren't reported.
+		view.Remove(view.InsertMachine())
+		return nil
+	})
+	changeRecv(t, trig, []Change{{Table: MachineTable, Old: old, New: m}})
+
+	var m2 Machine
+	conn.Transact(func(view Database) error {
+		view.Remove(m)
+		m2 = view.InsertMachine()
+		return nil
+	})
+	conn.Transact(func(view Database) error {
+		view.Commit(m2)
+		view.Commit(c)
+		return nil
+	})
+	changeRecv(t, trig, []Change{
+		{Table: MachineTable, Old: m},
+		{Table: MachineTable, New: m2},
+	})
+
+	select {
+	case changes := <-trig.C:
+		t.Errorf("Unexpected changes: %v", changes)
+	case <-time.After(25 * time.Millisecond):
+	}
+}
+
+func changeRecv(t *testing.T, trig ChangeTrigger, exp []Change) {
+	select {
+	case changes := <-trig.C:
+		if !reflect.DeepEqual(changes, exp) {
+			t.Errorf("Got changes %v, expected %v", changes, exp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected Receive")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	conn := New()
+
+	var a, b, c Container
+	conn.Transact(func(view Database) error {
+		a = view.InsertContainer()
+		a.Minion = "1"
+		view.Commit(a)
+
+		b = view.InsertContainer()
+		b.Minion = "1"
+		b.DockerID = "b"
+		view.Commit(b)
+
+		c = view.InsertContainer()
+		c.Minion = "2"
+		view.Commit(c)
+		return nil
+	})
+
+	checkBy := func(field string, value interface{}, exp []Container) {
+		dbcs := conn.SelectFromContainerBy(field, value, nil)
+		sort.Slice(dbcs, func(i, j int) bool { return dbcs[i].ID < dbcs[j].ID })
+		if !reflect.DeepEqual(dbcs, exp) {
+			t.Errorf("%s=%v: got %v, expected %v", field, value, dbcs, exp)
+		}
+	}
+
+	checkBy("Minion", "1", []Container{a, b})
+	checkBy("Minion", "2", []Container{c})
+	checkBy("Minion", "3", nil)
+	checkBy("DockerID", "b", []Container{b})
+
+	conn.Transact(func(view Database) error {
+		a.Minion = "2"
+		view.Commit(a)
+		view.Remove(b)
+		return nil
+	})
+
+	checkBy("Minion", "1", nil)
+	checkBy("Minion", "2", []Container{a, c})
+	checkBy("DockerID", "b", nil)
+
+	dbcs := conn.SelectFromContainerBy("Minion", "2", func(dbc Container) bool {
+		return dbc.ID == c.ID
+	})
+	if !reflect.DeepEqual(dbcs, []Container{c}) {
+		t.Errorf("Got %v, expected %v", dbcs, []Container{c})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic selecting on an unindexed field")
+		}
+	}()
+	conn.SelectFromContainerBy("Image", "", nil)
+}
@@ -32,6 +32,20 @@ func (db Database) SelectFromLabel(check func(Label) bool) []Label {
 	return result
 }
 
+// SelectFromLabelBy gets all labels in the database whose indexed 'field' is equal to
+// 'value', and that satisfy 'check'.  It panics if 'field' isn't indexed.
+func (db Database) SelectFromLabelBy(field string, value interface{},
+	check func(Label) bool) []Label {
+	var result []Label
+	for _, row := range db.tables[LabelTable].lookup(field, value) {
+		if check == nil || check(row.(Label)) {
+			result = append(result, row.(Label))
+		}
+	}
+
+	return result
+}
+
 // SelectFromLabel gets all labels in the database connection that satisfy 'check'.
 func (conn Conn) SelectFromLabel(check func(Label) bool) []Label {
 	var result []Label
@@ -42,6 +56,18 @@ func (conn Conn) SelectFromLabel(check func(Label) bool) []Label {
 	return result
 }
 
+// SelectFromLabelBy gets all labels in the database connection whose indexed 'field'
+// is equal to 'value', and that satisfy 'check'.  It panics if 'field' isn't indexed.
+func (conn Conn) SelectFromLabelBy(field string, value interface{},
+	check func(Label) bool) []Label {
+	var result []Label
+	conn.Transact(func(view Database) error {
+		result = view.SelectFromLabelBy(field, value, check)
+		return nil
+	})
+	return result
+}
+
 func (r Label) getID() int {
 	return r.ID
 }
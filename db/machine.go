@@ -12,12 +12,13 @@ type Machine struct {
 	ID int //Database ID
 
 	/* Populated by the policy engine. */
-	Role     Role
-	Provider Provider
-	Region   string
-	Size     string
-	DiskSize int
-	SSHKeys  []string `rowStringer:"omit"`
+	Namespace string // Namespace of the Cluster this machine belongs to.
+	Role      Role
+	Provider  Provider
+	Region    string
+	Size      string
+	DiskSize  int
+	SSHKeys   []string `rowStringer:"omit"`
 
 	/* Populated by the cloud provider. */
 	CloudID   string //Cloud Provider ID
@@ -26,6 +27,10 @@ type Machine struct {
 
 	/* Populated by the foreman. */
 	Connected bool // Whether the minion on this machine has connected back.
+
+	/* Populated by `quilt cordon` and `quilt drain`. */
+	Unschedulable bool // Whether new containers may be placed on this machine.
+	Draining      bool // Whether this machine's containers should be moved off.
 }
 
 // InsertMachine creates a new Machine and inserts it into 'db'.
@@ -46,6 +51,19 @@ func (db Database) SelectFromMachine(check func(Machine) bool) []Machine {
 	return result
 }
 
+// SelectFromMachineBy gets all machines in the database whose indexed 'field' is equal
+// to 'value', and that satisfy 'check'.  It panics if 'field' isn't indexed.
+func (db Database) SelectFromMachineBy(field string, value interface{},
+	check func(Machine) bool) []Machine {
+	var result []Machine
+	for _, row := range db.tables[MachineTable].lookup(field, value) {
+		if check == nil || check(row.(Machine)) {
+			result = append(result, row.(Machine))
+		}
+	}
+	return result
+}
+
 func (m Machine) getID() int {
 	return m.ID
 }
@@ -75,10 +93,22 @@ func (m Machine) String() string {
 		tags = append(tags, fmt.Sprintf("Disk=%dGB", m.DiskSize))
 	}
 
+	if m.Namespace != "" {
+		tags = append(tags, "Namespace="+m.Namespace)
+	}
+
 	if m.Connected {
 		tags = append(tags, "Connected")
 	}
 
+	if m.Unschedulable {
+		tags = append(tags, "Unschedulable")
+	}
+
+	if m.Draining {
+		tags = append(tags, "Draining")
+	}
+
 	return fmt.Sprintf("Machine-%d{%s}", m.ID, strings.Join(tags, ", "))
 }
 
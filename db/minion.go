@@ -10,12 +10,25 @@ type Minion struct {
 	Self bool   `json:"-"`
 	Spec string `json:"-"`
 
+	// Whether the minion has stopped sending heartbeats for long enough that its
+	// containers should be rescheduled elsewhere.
+	Failed bool `json:"-"`
+
 	// Below fields are included in the JSON encoding.
 	Role      Role
 	PrivateIP string
 	Provider  string
 	Size      string
 	Region    string
+
+	// Whether the scheduler may place new containers on this minion, and whether
+	// it should move the containers already here elsewhere.
+	Unschedulable bool `json:",omitempty"`
+	Draining      bool `json:",omitempty"`
+
+	// The container volumes whose data is stored on this minion, each as the
+	// StitchID of its container and the volume's source, separated by a colon.
+	Volumes []string `json:",omitempty"`
 }
 
 // InsertMinion creates a new Minion and inserts it into 'db'.
@@ -17,6 +17,13 @@ type Placement struct {
 	Provider string
 	Size     string
 	Region   string
+
+	// Spread Constraint
+	Spread  string
+	MaxSkew int
+
+	// Whether the containers may be moved to balance load across minions.
+	Rebalance bool
 }
 
 // PlacementSlice is an alias for []Placement to allow for joins
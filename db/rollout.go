@@ -0,0 +1,82 @@
+package db
+
+// A Rollout row tracks the progress of replacing the containers of a label when their
+// image, command, or environment changes.  The lead minion maintains one for each
+// label in the deployment.
+type Rollout struct {
+	ID int
+
+	Label    string
+	Replicas int // The number of containers the label should have.
+	Updated  int // The number of containers already running the new version.
+	Ready    int // The number of updated containers that are ready for traffic.
+	Old      int // The number of outdated containers yet to be replaced.
+
+	// How many of the label's containers may be unavailable at once.
+	MaxUnavailable int
+}
+
+// RolloutSlice is an alias for []Rollout to allow for joins
+type RolloutSlice []Rollout
+
+// InsertRollout creates a new rollout row and inserts it into the database.
+func (db Database) InsertRollout() Rollout {
+	result := Rollout{ID: db.nextID()}
+	db.insert(result)
+	return result
+}
+
+// SelectFromRollout gets all rollouts in the database that satisfy 'check'.
+func (db Database) SelectFromRollout(check func(Rollout) bool) []Rollout {
+	var result []Rollout
+	for _, row := range db.tables[RolloutTable].rows {
+		if check == nil || check(row.(Rollout)) {
+			result = append(result, row.(Rollout))
+		}
+	}
+	return result
+}
+
+// SelectFromRollout gets all rollouts in the database connection that satisfy 'check'.
+func (conn Conn) SelectFromRollout(check func(Rollout) bool) []Rollout {
+	var rollouts []Rollout
+	conn.Transact(func(view Database) error {
+		rollouts = view.SelectFromRollout(check)
+		return nil
+	})
+	return rollouts
+}
+
+// Done returns true if every container of the label runs the new version and is ready.
+func (r Rollout) Done() bool {
+	return r.Old == 0 && r.Updated == r.Replicas && r.Ready == r.Replicas
+}
+
+func (r Rollout) String() string {
+	return defaultString(r)
+}
+
+func (r Rollout) less(row row) bool {
+	r2 := row.(Rollout)
+
+	switch {
+	case r.Label != r2.Label:
+		return r.Label < r2.Label
+	default:
+		return r.ID < r2.ID
+	}
+}
+
+func (r Rollout) getID() int {
+	return r.ID
+}
+
+// Get returns the value contained at the given index
+func (rs RolloutSlice) Get(ii int) interface{} {
+	return rs[ii]
+}
+
+// Len returns the number of items in the slice
+func (rs RolloutSlice) Len() int {
+	return len(rs)
+}
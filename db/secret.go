@@ -0,0 +1,71 @@
+package db
+
+// The Secret table contains the values of the secrets referenced by containers.  The
+// daemon's table is the authoritative store, which the foreman copies to the workers
+// so that they may resolve references when starting containers.
+type Secret struct {
+	ID int
+
+	Name  string
+	Value string `rowStringer:"omit"`
+}
+
+// InsertSecret creates a new secret row and inserts it into the database.
+func (db Database) InsertSecret() Secret {
+	result := Secret{ID: db.nextID()}
+	db.insert(result)
+	return result
+}
+
+// SelectFromSecret gets all secrets in the database that satisfy 'check'.
+func (db Database) SelectFromSecret(check func(Secret) bool) []Secret {
+	var result []Secret
+	for _, row := range db.tables[SecretTable].rows {
+		if check == nil || check(row.(Secret)) {
+			result = append(result, row.(Secret))
+		}
+	}
+	return result
+}
+
+// SelectFromSecret gets all secrets in the database connection that satisfy 'check'.
+func (conn Conn) SelectFromSecret(check func(Secret) bool) []Secret {
+	var secrets []Secret
+	conn.Transact(func(view Database) error {
+		secrets = view.SelectFromSecret(check)
+		return nil
+	})
+	return secrets
+}
+
+// SecretMap returns the values of all secrets in the database, keyed by name.
+func (db Database) SecretMap() map[string]string {
+	secrets := map[string]string{}
+	for _, s := range db.SelectFromSecret(nil) {
+		secrets[s.Name] = s.Value
+	}
+	return secrets
+}
+
+// SecretMap returns the values of all secrets in the database connection, keyed by
+// name.
+func (conn Conn) SecretMap() map[string]string {
+	var secrets map[string]string
+	conn.Transact(func(view Database) error {
+		secrets = view.SecretMap()
+		return nil
+	})
+	return secrets
+}
+
+func (s Secret) String() string {
+	return defaultString(s)
+}
+
+func (s Secret) less(r row) bool {
+	return s.ID < r.(Secret).ID
+}
+
+func (s Secret) getID() int {
+	return s.ID
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+
+	"github.com/NetSys/quilt/util"
+)
+
+// The tables the daemon needs to pick up a running deployment after a restart.  The
+// rest are either rebuilt from the machines, or change too often to be worth writing
+// out.  Secrets are deliberately left out so that they're never stored in plaintext.
+var persistedTables = []TableType{ClusterTable, MachineTable}
+
+// A store persists the committed contents of a Database to a file so that they
+// survive restarts of the process that owns the database.
+type store struct {
+	path string
+}
+
+// The snapshot is the on-disk representation of a Database.
+type snapshot struct {
+	IDAlloc int
+	Rows    []row
+}
+
+func init() {
+	gob.Register(Cluster{})
+	gob.Register(Machine{})
+	gob.Register(Container{})
+	gob.Register(Minion{})
+	gob.Register(Connection{})
+	gob.Register(Label{})
+	gob.Register(Etcd{})
+	gob.Register(Placement{})
+	gob.Register(Secret{})
+	gob.Register(Rollout{})
+}
+
+// Open creates a connection to a database whose committed transactions are persisted
+// to the file at 'path'.  If the file already exists, the rows it contains are loaded
+// into the database before Open returns.
+func Open(path string) (Conn, error) {
+	cn := newConn()
+	cn.store = &store{path}
+	if err := cn.store.load(cn.db); err != nil {
+		return Conn{}, err
+	}
+
+	cn.runLogger()
+	return cn, nil
+}
+
+func (s store) load(db Database) error {
+	data, err := util.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err := gob.NewDecoder(bytes.NewBufferString(data)).Decode(&snap); err != nil {
+		return err
+	}
+
+	for _, r := range snap.Rows {
+		db.tables[getTableType(r)].set(r)
+	}
+	*db.idAlloc = snap.IDAlloc
+
+	migrate(db)
+	return nil
+}
+
+// migrate updates rows loaded from snapshots written by older daemons.
+func migrate(db Database) {
+	// Daemons that managed a single cluster didn't record the namespace of each
+	// machine.  Those machines belong to the only cluster.
+	clusters := db.SelectFromCluster(nil)
+	if len(clusters) != 1 {
+		return
+	}
+
+	for _, m := range db.SelectFromMachine(nil) {
+		if m.Namespace == "" {
+			m.Namespace = clusters[0].Namespace
+			db.tables[MachineTable].set(m)
+		}
+	}
+}
+
+func (s store) save(db Database) error {
+	snap := snapshot{IDAlloc: *db.idAlloc}
+	for _, t := range persistedTables {
+		for _, r := range db.tables[t].rows {
+			snap.Rows = append(snap.Rows, r)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash mid-write can't corrupt the
+	// previous snapshot.  The file is synced before it replaces the snapshot, and the
+	// directory after, so that the rename can't reach the disk before the data, and
+	// is itself durable.
+	tmpPath := s.path + ".tmp"
+	if err := writeSync(tmpPath, buf.Bytes()); err != nil {
+		return err
+	}
+
+	if err := util.AppFs.Rename(tmpPath, s.path); err != nil {
+		return err
+	}
+	return syncPath(filepath.Dir(s.path))
+}
+
+// persists returns true if `tt` is written to the snapshot.
+func persists(tt TableType) bool {
+	for _, t := range persistedTables {
+		if t == tt {
+			return true
+		}
+	}
+	return false
+}
+
+func writeSync(path string, data []byte) error {
+	f, err := util.AppFs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncPath(path string) error {
+	f, err := util.AppFs.Open(path)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
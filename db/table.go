@@ -1,7 +1,9 @@
 package db
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 )
 
 // TableType represents a table in the database.
@@ -31,22 +33,169 @@ var EtcdTable = TableType(reflect.TypeOf(Etcd{}).String())
 // PlacementTable is the type of the placement table.
 var PlacementTable = TableType(reflect.TypeOf(Placement{}).String())
 
+// SecretTable is the type of the secret table.
+var SecretTable = TableType(reflect.TypeOf(Secret{}).String())
+
+// RolloutTable is the type of the rollout table.
+var RolloutTable = TableType(reflect.TypeOf(Rollout{}).String())
+
 var allTables = []TableType{ClusterTable, MachineTable, ContainerTable, MinionTable,
-	ConnectionTable, LabelTable, EtcdTable, PlacementTable}
+	ConnectionTable, LabelTable, EtcdTable, PlacementTable, SecretTable, RolloutTable}
+
+// indexedFields declares the row fields on which each table maintains an index.  Hot
+// paths that repeatedly filter on one of these fields should use the corresponding
+// SelectFromXBy() function rather than scanning the entire table.
+var indexedFields = map[TableType][]string{
+	ContainerTable: {"Minion", "DockerID"},
+	LabelTable:     {"Label"},
+	MachineTable:   {"PublicIP"},
+}
+
+// An index maps each value of a row field to the IDs of the rows holding that value.
+type index map[interface{}]map[int]struct{}
 
 type table struct {
-	rows map[int]row
+	rows    map[int]row
+	indexes map[string]index
 
 	triggers    map[Trigger]struct{}
 	shouldAlert bool
+
+	changeTriggers map[ChangeTrigger]struct{}
+
+	// The value of each row touched by the current transaction as it was before the
+	// transaction began, or nil if the row didn't exist.  Only maintained when there
+	// are change triggers that need it.
+	txnOld map[int]row
+}
+
+func newTable(tt TableType) *table {
+	t := &table{
+		rows:           make(map[int]row),
+		indexes:        make(map[string]index),
+		triggers:       make(map[Trigger]struct{}),
+		shouldAlert:    false,
+		changeTriggers: make(map[ChangeTrigger]struct{}),
+		txnOld:         make(map[int]row),
+	}
+
+	for _, field := range indexedFields[tt] {
+		t.indexes[field] = make(index)
+	}
+	return t
+}
+
+// set writes 'r' into the table, keeping the indexes consistent.
+func (t *table) set(r row) {
+	id := r.getID()
+	if old, ok := t.rows[id]; ok {
+		t.unindex(old)
+	}
+
+	t.rows[id] = r
+	for field, idx := range t.indexes {
+		key := fieldValue(r, field)
+		if idx[key] == nil {
+			idx[key] = make(map[int]struct{})
+		}
+		idx[key][id] = struct{}{}
+	}
+}
+
+// remove deletes the row with the given 'id' from the table and its indexes.
+func (t *table) remove(id int) {
+	if old, ok := t.rows[id]; ok {
+		t.unindex(old)
+		delete(t.rows, id)
+	}
+}
+
+func (t *table) unindex(r row) {
+	for field, idx := range t.indexes {
+		key := fieldValue(r, field)
+		delete(idx[key], r.getID())
+		if len(idx[key]) == 0 {
+			delete(idx, key)
+		}
+	}
+}
+
+// lookup returns the rows whose indexed 'field' is equal to 'value'.
+func (t *table) lookup(field string, value interface{}) []row {
+	idx, ok := t.indexes[field]
+	if !ok {
+		panic(fmt.Sprintf("no index on field %s", field))
+	}
+
+	var rows []row
+	for id := range idx[value] {
+		rows = append(rows, t.rows[id])
+	}
+	return rows
+}
+
+func fieldValue(r row, field string) interface{} {
+	return reflect.ValueOf(r).FieldByName(field).Interface()
+}
+
+// touch records the value of row 'id' before it is modified by the current transaction.
+func (t *table) touch(id int) {
+	if len(t.changeTriggers) == 0 {
+		return
+	}
+
+	if _, ok := t.txnOld[id]; !ok {
+		t.txnOld[id] = t.rows[id]
+	}
+}
+
+// collectChanges computes the changes made to the table by the current transaction, and
+// resets the table for the next one.  Stopped change triggers are deregistered.
+func (t *table) collectChanges(tt TableType) []Change {
+	for trigger := range t.changeTriggers {
+		select {
+		case <-trigger.stop:
+			delete(t.changeTriggers, trigger)
+		default:
+		}
+	}
+
+	if len(t.txnOld) == 0 {
+		return nil
+	}
+
+	var ids []int
+	for id := range t.txnOld {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var changes []Change
+	for _, id := range ids {
+		old, new := t.txnOld[id], t.rows[id]
+		switch {
+		case old == nil && new == nil:
+			// Inserted then removed in the same transaction.
+		case old == nil:
+			changes = append(changes, Change{Table: tt, New: new})
+		case new == nil:
+			changes = append(changes, Change{Table: tt, Old: old})
+		case !reflect.DeepEqual(old, new):
+			changes = append(changes, Change{Table: tt, Old: old, New: new})
+		}
+	}
+
+	t.txnOld = make(map[int]row)
+	return changes
 }
 
-func newTable() *table {
-	return &table{
-		rows:        make(map[int]row),
-		triggers:    make(map[Trigger]struct{}),
-		shouldAlert: false,
+func (t *table) sortedIDs() []int {
+	var ids []int
+	for id := range t.rows {
+		ids = append(ids, id)
 	}
+	sort.Ints(ids)
+	return ids
 }
 
 func (t *table) alert() {
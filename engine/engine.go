@@ -30,27 +30,31 @@ func UpdatePolicy(conn db.Conn, stitch stitch.Stitch) error {
 }
 
 func updateTxn(view db.Database, stitch stitch.Stitch) error {
-	err := clusterTxn(view, stitch)
+	namespace := getNamespace(stitch)
+	err := clusterTxn(view, stitch, namespace)
 	if err != nil {
 		return err
 	}
 
-	if err = machineTxn(view, stitch); err != nil {
+	if err = machineTxn(view, stitch, namespace); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func clusterTxn(view db.Database, stitch stitch.Stitch) error {
+func getNamespace(stitch stitch.Stitch) string {
 	namespace := stitch.QueryNamespace()
 	if namespace == "" {
 		namespace = "default-namespace"
 		msg := "policy did not specify 'Namespace', defaulting to '%s'"
 		log.Warn(fmt.Sprintf(msg, namespace))
 	}
+	return namespace
+}
 
-	cluster, err := view.GetCluster()
+func clusterTxn(view db.Database, stitch stitch.Stitch, namespace string) error {
+	cluster, err := view.GetCluster(namespace)
 	if err != nil {
 		cluster = view.InsertCluster()
 	}
@@ -122,12 +126,47 @@ func toDBMachine(machines []stitch.Machine, maxPrice float64) []db.Machine {
 	return dbMachines
 }
 
-func machineTxn(view db.Database, stitch stitch.Stitch) error {
+func machineTxn(view db.Database, stitch stitch.Stitch, namespace string) error {
+	pairs, bootList, terminateList := joinMachines(view, stitch, namespace)
+
+	for _, toTerminate := range terminateList {
+		view.Remove(toTerminate)
+	}
+
+	for _, bootSet := range bootList {
+		pairs = append(pairs, join.Pair{L: bootSet, R: view.InsertMachine()})
+	}
+
+	for _, pair := range pairs {
+		stitchMachine := pair.L.(db.Machine)
+		dbMachine := pair.R.(db.Machine)
+
+		dbMachine.Namespace = namespace
+		dbMachine.Role = stitchMachine.Role
+		dbMachine.Size = stitchMachine.Size
+		dbMachine.DiskSize = stitchMachine.DiskSize
+		dbMachine.Provider = stitchMachine.Provider
+		dbMachine.Region = stitchMachine.Region
+		dbMachine.SSHKeys = stitchMachine.SSHKeys
+		view.Commit(dbMachine)
+	}
+
+	return nil
+}
+
+// joinMachines matches the machines requested by `stitch` against the machines in
+// `namespace`.  It returns the matched pairs, the stitch machines that have no match and
+// must be booted, and the database machines that have no match and must be terminated.
+func joinMachines(view db.Database, stitch stitch.Stitch, namespace string) (
+	pairs []join.Pair, bootList, terminateList []db.Machine) {
 	// XXX: How best to deal with machines that don't specify enough information?
 	maxPrice := stitch.QueryMaxPrice()
 	stitchMachines := toDBMachine(stitch.QueryMachines(), maxPrice)
 
-	dbMachines := view.SelectFromMachine(nil)
+	// Machines belonging to other namespaces are managed by other deployments.
+	dbMachines := view.SelectFromMachine(func(m db.Machine) bool {
+		return m.Namespace == namespace
+	})
 
 	scoreFun := func(left, right interface{}) int {
 		stitchMachine := left.(db.Machine)
@@ -144,6 +183,10 @@ func machineTxn(view db.Database, stitch stitch.Stitch) error {
 			return -1
 		case dbMachine.DiskSize != stitchMachine.DiskSize:
 			return -1
+		// Cordoned machines are matched last, so that they're the ones
+		// terminated when the stitch asks for fewer machines.
+		case dbMachine.Unschedulable:
+			return 3
 		case dbMachine.PrivateIP == "":
 			return 2
 		case dbMachine.PublicIP == "":
@@ -153,33 +196,18 @@ func machineTxn(view db.Database, stitch stitch.Stitch) error {
 		}
 	}
 
-	pairs, bootList, terminateList := join.Join(stitchMachines, dbMachines, scoreFun)
+	pairs, bootIface, terminateIface := join.Join(stitchMachines, dbMachines,
+		scoreFun)
 
-	for _, toTerminate := range terminateList {
-		toTerminate := toTerminate.(db.Machine)
-		view.Remove(toTerminate)
+	for _, m := range bootIface {
+		bootList = append(bootList, m.(db.Machine))
 	}
 
-	for _, bootSet := range bootList {
-		bootSet := bootSet.(db.Machine)
-
-		pairs = append(pairs, join.Pair{L: bootSet, R: view.InsertMachine()})
-	}
-
-	for _, pair := range pairs {
-		stitchMachine := pair.L.(db.Machine)
-		dbMachine := pair.R.(db.Machine)
-
-		dbMachine.Role = stitchMachine.Role
-		dbMachine.Size = stitchMachine.Size
-		dbMachine.DiskSize = stitchMachine.DiskSize
-		dbMachine.Provider = stitchMachine.Provider
-		dbMachine.Region = stitchMachine.Region
-		dbMachine.SSHKeys = stitchMachine.SSHKeys
-		view.Commit(dbMachine)
+	for _, m := range terminateIface {
+		terminateList = append(terminateList, m.(db.Machine))
 	}
 
-	return nil
+	return pairs, bootList, terminateList
 }
 
 func resolveACLs(acls []string) []string {
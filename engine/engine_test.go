@@ -28,7 +28,7 @@ func TestEngine(t *testing.T) {
 
 	UpdatePolicy(conn, prog(t, code))
 	err := conn.Transact(func(view db.Database) error {
-		cluster, err := view.GetCluster()
+		cluster, err := view.GetCluster("namespace")
 		masters := view.SelectFromMachine(func(m db.Machine) bool {
 			return m.Role == db.Master
 		})
@@ -134,19 +134,28 @@ func TestEngine(t *testing.T) {
 		t.Error(err.Error())
 	}
 
-	/* Empty Namespace does nothing. */
+	/* An empty namespace deploys into the default namespace, leaving the
+	 * machines in other namespaces alone. */
 	code = pre + `deployment.namespace = "";
 		deployment.deploy(baseMachine.asMaster())
 			.deploy(baseMachine.asWorker())`
 	UpdatePolicy(conn, prog(t, code))
 	err = conn.Transact(func(view db.Database) error {
 		masters := view.SelectFromMachine(func(m db.Machine) bool {
-			return m.Role == db.Master
+			return m.Role == db.Master && m.Namespace == "namespace"
 		})
 		workers := view.SelectFromMachine(func(m db.Machine) bool {
-			return m.Role == db.Worker
+			return m.Role == db.Worker && m.Namespace == "namespace"
+		})
+		defaults := view.SelectFromMachine(func(m db.Machine) bool {
+			return m.Namespace == "default-namespace"
 		})
 
+		if len(defaults) != 2 {
+			return fmt.Errorf("bad default machines: %s",
+				spew.Sdump(defaults))
+		}
+
 		if len(masters) != 1 || masters[0].CloudID != "1" ||
 			masters[0].PublicIP != "2" || masters[0].PrivateIP != "3" {
 			return fmt.Errorf("bad masters: %s", spew.Sdump(masters))
@@ -167,10 +176,10 @@ func TestEngine(t *testing.T) {
 	UpdatePolicy(conn, prog(t, code))
 	err = conn.Transact(func(view db.Database) error {
 		masters := view.SelectFromMachine(func(m db.Machine) bool {
-			return m.Role == db.Master
+			return m.Role == db.Master && m.Namespace == "namespace"
 		})
 		workers := view.SelectFromMachine(func(m db.Machine) bool {
-			return m.Role == db.Worker
+			return m.Role == db.Worker && m.Namespace == "namespace"
 		})
 
 		if len(masters) != 0 {
@@ -413,6 +422,50 @@ func TestSort(t *testing.T) {
 	}
 }
 
+func TestSortCordoned(t *testing.T) {
+	pre := `var baseMachine = new Machine({provider: "Amazon", size: "m4.large"});`
+	conn := db.New()
+
+	UpdatePolicy(conn, prog(t, pre+`deployment
+		.deploy(baseMachine.asMaster())
+		.deploy(baseMachine.asWorker().replicate(3))`))
+	conn.Transact(func(view db.Database) error {
+		workers := view.SelectFromMachine(func(m db.Machine) bool {
+			return m.Role == db.Worker
+		})
+
+		for i, m := range workers {
+			m.PublicIP = fmt.Sprintf("%d", i)
+			m.PrivateIP = m.PublicIP
+			m.Unschedulable = i == 1
+			view.Commit(m)
+		}
+		return nil
+	})
+
+	// The cordoned worker is the one terminated.
+	UpdatePolicy(conn, prog(t, pre+`deployment
+		.deploy(baseMachine.asMaster())
+		.deploy(baseMachine.asWorker().replicate(2))`))
+	var workers []db.Machine
+	conn.Transact(func(view db.Database) error {
+		workers = view.SelectFromMachine(func(m db.Machine) bool {
+			return m.Role == db.Worker
+		})
+		return nil
+	})
+
+	if len(workers) != 2 {
+		t.Fatalf("Expected 2 workers, got %v", workers)
+	}
+
+	for _, m := range workers {
+		if m.Unschedulable {
+			t.Errorf("Cordoned worker wasn't terminated: %v", m)
+		}
+	}
+}
+
 func TestACLs(t *testing.T) {
 	spew := spew.NewDefaultConfig()
 	spew.MaxDepth = 2
@@ -430,7 +483,7 @@ func TestACLs(t *testing.T) {
 	}
 	UpdatePolicy(conn, prog(t, code))
 	err := conn.Transact(func(view db.Database) error {
-		cluster, err := view.GetCluster()
+		cluster, err := view.GetCluster("default-namespace")
 
 		if err != nil {
 			return err
@@ -453,7 +506,7 @@ func TestACLs(t *testing.T) {
 	}
 	UpdatePolicy(conn, prog(t, code))
 	err = conn.Transact(func(view db.Database) error {
-		cluster, err := view.GetCluster()
+		cluster, err := view.GetCluster("default-namespace")
 
 		if err != nil {
 			return err
@@ -471,6 +524,119 @@ func TestACLs(t *testing.T) {
 	}
 }
 
+func TestMultipleNamespaces(t *testing.T) {
+	conn := db.New()
+
+	code := `createDeployment({namespace: "%s"}).deploy([
+		new Machine({provider: "Amazon", role: "Master"}),
+		new Machine({provider: "Amazon", role: "Worker"})
+	]);`
+
+	UpdatePolicy(conn, prog(t, fmt.Sprintf(code, "staging")))
+	UpdatePolicy(conn, prog(t, fmt.Sprintf(code, "production")))
+
+	countMachines := func(namespace string) (count int) {
+		conn.Transact(func(view db.Database) error {
+			count = len(view.SelectFromMachine(func(m db.Machine) bool {
+				return m.Namespace == namespace
+			}))
+			return nil
+		})
+		return count
+	}
+
+	if n := countMachines("staging"); n != 2 {
+		t.Errorf("Expected 2 staging machines, got %d", n)
+	}
+	if n := countMachines("production"); n != 2 {
+		t.Errorf("Expected 2 production machines, got %d", n)
+	}
+
+	// Stopping one namespace shouldn't affect the other.
+	UpdatePolicy(conn, prog(t, `createDeployment({namespace: "staging"});`))
+	if n := countMachines("staging"); n != 0 {
+		t.Errorf("Expected 0 staging machines, got %d", n)
+	}
+	if n := countMachines("production"); n != 2 {
+		t.Errorf("Expected 2 production machines, got %d", n)
+	}
+
+	if n := len(conn.SelectFromCluster(nil)); n != 2 {
+		t.Errorf("Expected 2 clusters, got %d", n)
+	}
+}
+
+func TestPlanPolicy(t *testing.T) {
+	conn := db.New()
+
+	code := `deployment.deploy([
+		new Machine({provider: "Amazon", size: "m4.large", role: "Master"}),
+		new Machine({provider: "Amazon", size: "%s", role: "Worker"})
+	]);
+	deployment.deploy(new Label("a", [new Container("%s")]));`
+
+	UpdatePolicy(conn, prog(t, fmt.Sprintf(code, "m4.large", "alpine")))
+
+	var plan Plan
+	conn.Transact(func(view db.Database) error {
+		plan = PlanPolicy(view, prog(t, fmt.Sprintf(code, "m4.xlarge", "ubuntu")))
+		return nil
+	})
+
+	if len(plan.BootMachines) != 1 || plan.BootMachines[0].Size != "m4.xlarge" {
+		t.Errorf("Expected to boot an m4.xlarge, got %v", plan.BootMachines)
+	}
+	if len(plan.TerminateMachines) != 1 ||
+		plan.TerminateMachines[0].Size != "m4.large" ||
+		plan.TerminateMachines[0].Role != db.Worker {
+		t.Errorf("Expected to terminate the worker, got %v",
+			plan.TerminateMachines)
+	}
+
+	// Only the lead minion plans containers.
+	if len(plan.StartContainers) != 0 || len(plan.StopContainers) != 0 {
+		t.Errorf("Daemon should not plan containers, got %v", plan)
+	}
+
+	conn.Transact(func(view db.Database) error {
+		if n := len(view.SelectFromMachine(nil)); n != 2 {
+			t.Errorf("Planning should not change the machines, got %d", n)
+		}
+		return nil
+	})
+
+	conn.Transact(func(view db.Database) error {
+		minion := view.InsertMinion()
+		minion.Self = true
+		view.Commit(minion)
+
+		etcd := view.InsertEtcd()
+		etcd.Leader = true
+		view.Commit(etcd)
+
+		dbc := view.InsertContainer()
+		dbc.Image = "alpine"
+		dbc.Labels = []string{"a"}
+		view.Commit(dbc)
+		return nil
+	})
+
+	conn.Transact(func(view db.Database) error {
+		plan = PlanPolicy(view, prog(t, fmt.Sprintf(code, "m4.xlarge", "ubuntu")))
+		return nil
+	})
+
+	if len(plan.BootMachines) != 0 || len(plan.TerminateMachines) != 0 {
+		t.Errorf("Minions should not plan machines, got %v", plan)
+	}
+	if len(plan.StartContainers) != 1 || plan.StartContainers[0].Image != "ubuntu" {
+		t.Errorf("Expected to start ubuntu, got %v", plan.StartContainers)
+	}
+	if len(plan.StopContainers) != 1 || plan.StopContainers[0].Image != "alpine" {
+		t.Errorf("Expected to stop alpine, got %v", plan.StopContainers)
+	}
+}
+
 func prog(t *testing.T, code string) stitch.Stitch {
 	result, err := stitch.New(code, stitch.DefaultImportGetter)
 	if err != nil {
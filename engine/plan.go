@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/join"
+	"github.com/NetSys/quilt/stitch"
+	"github.com/NetSys/quilt/util"
+)
+
+// A Plan describes the changes that running a Stitch would make to a deployment,
+// without actually making them.
+type Plan struct {
+	BootMachines      []db.Machine
+	TerminateMachines []db.Machine
+
+	// The containers started and stopped once the run has fully rolled out.  The
+	// lead minion makes these changes gradually, according to the update strategy
+	// of each label.
+	StartContainers []db.Container
+	StopContainers  []db.Container
+}
+
+// PlanPolicy computes the Plan for running `stitch` against the current contents of
+// `view` without modifying it.  Only the daemon knows about machines, and only the lead
+// minion knows about containers, so each plans just the half it's responsible for.
+func PlanPolicy(view db.Database, stitch stitch.Stitch) Plan {
+	var plan Plan
+	if _, err := view.MinionSelf(); err != nil {
+		_, plan.BootMachines, plan.TerminateMachines = joinMachines(view, stitch,
+			getNamespace(stitch))
+	}
+
+	if view.EtcdLeader() {
+		_, plan.StartContainers, plan.StopContainers = JoinContainers(
+			QueryContainers(stitch), view.SelectFromContainer(nil))
+	}
+
+	return plan
+}
+
+// QueryContainers converts the containers declared in `spec` into db.Containers that
+// can be joined against the container table.
+func QueryContainers(spec stitch.Stitch) []db.Container {
+	containers := map[int]*db.Container{}
+	for _, c := range spec.QueryContainers() {
+		containers[c.ID] = &db.Container{
+			StitchID: c.ID,
+			Command:  c.Command,
+			Image:    c.Image,
+			Env:      c.Env,
+			Volumes:  volumeBinds(c.Volumes),
+			CPU:      c.CPU,
+			RAM:      c.RAM,
+
+			HealthCheck:   db.HealthCheck(c.HealthCheck),
+			RestartPolicy: c.RestartPolicy,
+		}
+	}
+
+	for _, label := range spec.QueryLabels() {
+		for _, id := range label.IDs {
+			containers[id].Labels = append(containers[id].Labels, label.Name)
+		}
+	}
+
+	var ret []db.Container
+	for _, c := range containers {
+		ret = append(ret, *c)
+	}
+
+	return ret
+}
+
+// volumeBinds converts `volumes` into the docker bind specifications stored in the
+// container table.
+func volumeBinds(volumes []stitch.Volume) []string {
+	var binds []string
+	for _, v := range volumes {
+		source := v.Name
+		if v.HostPath != "" {
+			source = v.HostPath
+		}
+		binds = append(binds, source+":"+v.MountPoint)
+	}
+	return binds
+}
+
+// JoinContainers matches the containers declared in a Stitch against those in the
+// database.  It returns the matched pairs, the stitch containers that must be started,
+// and the database containers that must be stopped.
+func JoinContainers(stitchContainers, dbContainers []db.Container) (
+	pairs []join.Pair, toStart, toStop []db.Container) {
+
+	score := func(l, r interface{}) int {
+		left := l.(db.Container)
+		right := r.(db.Container)
+
+		if left.Image != right.Image ||
+			!util.StrSliceEqual(left.Command, right.Command) ||
+			!util.StrStrMapEqual(left.Env, right.Env) ||
+			!util.StrSliceEqual(left.Volumes, right.Volumes) ||
+			left.CPU != right.CPU || left.RAM != right.RAM ||
+			!left.HealthCheck.Equal(right.HealthCheck) ||
+			left.RestartPolicy != right.RestartPolicy {
+			return -1
+		}
+
+		score := util.EditDistance(left.Labels, right.Labels)
+		if left.StitchID != right.StitchID {
+			score++
+		}
+		return score
+	}
+
+	pairs, news, dbcs := join.Join(stitchContainers, dbContainers, score)
+
+	for _, new := range news {
+		toStart = append(toStart, new.(db.Container))
+	}
+
+	for _, dbc := range dbcs {
+		toStop = append(toStop, dbc.(db.Container))
+	}
+
+	return pairs, toStart, toStop
+}
@@ -14,10 +14,15 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	dkc "github.com/fsouza/go-dockerclient"
+	"golang.org/x/net/context"
 )
 
 var pullCacheTimeout = time.Minute
 
+// The length, in microseconds, of the CFS scheduling period that CPU quotas are
+// measured against.
+const cpuPeriod = 100000
+
 // ErrNoSuchContainer is the error returned when an operation is requested on a
 // non-existent container.
 var ErrNoSuchContainer = errors.New("container does not exist")
@@ -33,6 +38,33 @@ type Container struct {
 	Pid    int
 	Env    map[string]string
 	Labels map[string]string
+	Binds  []string
+
+	// Resource limits, in microseconds of CPU time per period, and bytes of memory.
+	CPUQuota int64
+	Memory   int64
+
+	// The exit code of the container's process, if it has exited.
+	ExitCode int
+}
+
+// ExecOptions describes an interactive execution of Cmd within a container.  Stdin
+// may be nil if the command takes no input.  When TTY is set, the command runs in a
+// pseudo-terminal that's resized to each size received on Resize, and its output is
+// all written to Stdout.
+type ExecOptions struct {
+	Cmd    []string
+	TTY    bool
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Resize <-chan TermSize
+}
+
+// TermSize is the size of a terminal in characters.
+type TermSize struct {
+	Height int
+	Width  int
 }
 
 // ContainerSlice is an alias for []Container to allow for joins
@@ -57,17 +89,59 @@ type RunOptions struct {
 	PidMode     string
 	Privileged  bool
 	VolumesFrom []string
+	Binds       []string
+	DNS         []string
+
+	CPUQuota int64
+	Memory   int64
+}
+
+// The smallest limits docker accepts, in microseconds of CPU time per period, and
+// bytes of memory.
+const minCPUQuota = 1000
+const minMemoryLimit = 4 << 20
+
+// CPUQuota returns the CPU quota that limits a container to `cpu` cores, or 0 for no
+// limit if `cpu` isn't positive.  Quotas too small for docker are rounded up.
+func CPUQuota(cpu float64) int64 {
+	if cpu <= 0 {
+		return 0
+	}
+
+	quota := int64(cpu * cpuPeriod)
+	if quota < minCPUQuota {
+		quota = minCPUQuota
+	}
+	return quota
+}
+
+// MemoryLimit returns the memory limit, in bytes, of `ram` gigabytes, or 0 for no
+// limit if `ram` isn't positive.  Limits too small for docker are rounded up.
+func MemoryLimit(ram float64) int64 {
+	if ram <= 0 {
+		return 0
+	}
+
+	limit := int64(ram * (1 << 30))
+	if limit < minMemoryLimit {
+		limit = minMemoryLimit
+	}
+	return limit
 }
 
 type client interface {
 	StartContainer(id string, hostConfig *dkc.HostConfig) error
 	CreateExec(opts dkc.CreateExecOptions) (*dkc.Exec, error)
 	StartExec(id string, opts dkc.StartExecOptions) error
+	InspectExec(id string) (*dkc.ExecInspect, error)
+	ResizeExecTTY(id string, height, width int) error
+	Logs(opts dkc.LogsOptions) error
 	UploadToContainer(id string, opts dkc.UploadToContainerOptions) error
 	DownloadFromContainer(id string, opts dkc.DownloadFromContainerOptions) error
 	RemoveContainer(opts dkc.RemoveContainerOptions) error
 	PullImage(opts dkc.PullImageOptions, auth dkc.AuthConfiguration) error
 	ListContainers(opts dkc.ListContainersOptions) ([]dkc.APIContainers, error)
+	ListVolumes(opts dkc.ListVolumesOptions) ([]dkc.Volume, error)
 	InspectContainer(id string) (*dkc.Container, error)
 	CreateContainer(dkc.CreateContainerOptions) (*dkc.Container, error)
 }
@@ -101,6 +175,13 @@ func (dk Client) Run(opts RunOptions) (string, error) {
 		PidMode:     opts.PidMode,
 		Privileged:  opts.Privileged,
 		VolumesFrom: opts.VolumesFrom,
+		Binds:       opts.Binds,
+		DNS:         opts.DNS,
+		CPUQuota:    opts.CPUQuota,
+		Memory:      opts.Memory,
+	}
+	if opts.CPUQuota != 0 {
+		hc.CPUPeriod = cpuPeriod
 	}
 	id, err := dk.create(opts.Name, opts.Image, opts.Args, opts.Labels, env, &hc)
 	if err != nil {
@@ -158,6 +239,124 @@ func (dk Client) ExecVerbose(name string, cmd ...string) ([]byte, error) {
 	return outBuff.Bytes(), nil
 }
 
+// ExecStatus executes a command within the container with the supplied ID, and returns
+// its exit code.  It gives up with the error of `ctx` if `ctx` is done first.
+func (dk Client) ExecStatus(ctx context.Context, id string, cmd ...string) (int,
+	error) {
+
+	exec, err := dk.CreateExec(dkc.CreateExecOptions{
+		Container: id,
+		Cmd:       cmd,
+		Context:   ctx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// The docker client doesn't cancel attached executions when their context is
+	// done, so instead stop waiting for the execution, and leave it to finish on
+	// its own.
+	started := make(chan error, 1)
+	go func() {
+		started <- dk.StartExec(exec.ID, dkc.StartExecOptions{Context: ctx})
+	}()
+
+	select {
+	case err := <-started:
+		if err != nil {
+			return 0, err
+		}
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	inspect, err := dk.InspectExec(exec.ID)
+	if err != nil {
+		return 0, err
+	}
+	return inspect.ExitCode, nil
+}
+
+// ExecInteractive executes a command within the container with the supplied ID,
+// connecting it to the streams in `opts`, and returns its exit code once it finishes.
+func (dk Client) ExecInteractive(ctx context.Context, id string,
+	opts ExecOptions) (int, error) {
+
+	exec, err := dk.CreateExec(dkc.CreateExecOptions{
+		Container:    id,
+		Cmd:          opts.Cmd,
+		Tty:          opts.TTY,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+		Context:      ctx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case size, ok := <-opts.Resize:
+				if !ok {
+					return
+				}
+				err := dk.ResizeExecTTY(exec.ID, size.Height,
+					size.Width)
+				if err != nil {
+					log.WithError(err).Debug(
+						"Failed to resize exec TTY.")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = dk.StartExec(exec.ID, dkc.StartExecOptions{
+		Tty:          opts.TTY,
+		RawTerminal:  opts.TTY,
+		InputStream:  opts.Stdin,
+		OutputStream: opts.Stdout,
+		ErrorStream:  opts.Stderr,
+		Context:      ctx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	inspect, err := dk.InspectExec(exec.ID)
+	if err != nil {
+		return 0, err
+	}
+	return inspect.ExitCode, nil
+}
+
+// StreamLogs writes the output of the container with the supplied ID to `w`.  Only
+// output produced after `since` is written, unless it's the zero time.  If `follow` is
+// true, output continues to be written as the container produces it until the
+// container exits or `ctx` is cancelled.
+func (dk Client) StreamLogs(ctx context.Context, id string, follow bool,
+	since time.Time, w io.Writer) error {
+
+	opts := dkc.LogsOptions{
+		Container:    id,
+		OutputStream: w,
+		ErrorStream:  w,
+		Follow:       follow,
+		Stdout:       true,
+		Stderr:       true,
+		Context:      ctx,
+	}
+	if !since.IsZero() {
+		opts.Since = since.Unix()
+	}
+	return dk.Logs(opts)
+}
+
 // WriteToContainer writes the contents of SRC into the file at path DST on the
 // container with id ID. Overwrites DST if it already exists.
 func (dk Client) WriteToContainer(id, src, dst, archiveName string,
@@ -278,6 +477,20 @@ func (dk Client) list(filters map[string][]string, all bool) ([]Container, error
 	return containers, nil
 }
 
+// ListVolumes returns the names of the docker volumes on this host.
+func (dk Client) ListVolumes() ([]string, error) {
+	volumes, err := dk.client.ListVolumes(dkc.ListVolumesOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, volume := range volumes {
+		names = append(names, volume.Name)
+	}
+	return names, nil
+}
+
 // Get returns a Container corresponding to the supplied ID.
 func (dk Client) Get(id string) (Container, error) {
 	c, err := dk.InspectContainer(id)
@@ -293,6 +506,14 @@ func (dk Client) Get(id string) (Container, error) {
 		}
 	}
 
+	var binds []string
+	var cpuQuota, memory int64
+	if c.HostConfig != nil {
+		binds = c.HostConfig.Binds
+		cpuQuota = c.HostConfig.CPUQuota
+		memory = c.HostConfig.Memory
+	}
+
 	return Container{
 		Name:   c.Name,
 		ID:     c.ID,
@@ -303,6 +524,11 @@ func (dk Client) Get(id string) (Container, error) {
 		Pid:    c.State.Pid,
 		Env:    env,
 		Labels: c.Config.Labels,
+		Binds:  binds,
+
+		CPUQuota: cpuQuota,
+		Memory:   memory,
+		ExitCode: c.State.ExitCode,
 	}, nil
 }
 
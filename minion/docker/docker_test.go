@@ -1,12 +1,15 @@
 package docker
 
 import (
+	"bytes"
+	"io"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	dkc "github.com/fsouza/go-dockerclient"
+	"golang.org/x/net/context"
 )
 
 func TestPull(t *testing.T) {
@@ -373,6 +376,101 @@ func TestExec(t *testing.T) {
 	}
 }
 
+func TestStreamLogs(t *testing.T) {
+	t.Parallel()
+	md, dk := NewMock()
+
+	id, err := dk.Run(RunOptions{Name: "name"})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	md.Output[id] = "output"
+
+	var out bytes.Buffer
+	err = dk.StreamLogs(context.Background(), id, false, time.Time{}, &out)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if out.String() != "output" {
+		t.Errorf("Bad logs %q", out.String())
+	}
+
+	err = dk.StreamLogs(context.Background(), "missing", true, time.Now(), &out)
+	if err == nil {
+		t.Error("Expected Error")
+	}
+}
+
+func TestExecInteractive(t *testing.T) {
+	t.Parallel()
+	md, dk := NewMock()
+
+	id, err := dk.Run(RunOptions{Name: "name"})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	resize := make(chan TermSize, 1)
+	resize <- TermSize{Height: 24, Width: 80}
+
+	var out bytes.Buffer
+	md.ExecExitCode = 3
+	exitCode, err := dk.ExecInteractive(context.Background(), id, ExecOptions{
+		Cmd:    []string{"cat"},
+		TTY:    true,
+		Stdin:  &resizedReader{md, "input"},
+		Stdout: &out,
+		Resize: resize,
+	})
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if exitCode != 3 {
+		t.Errorf("Exit code %d, expected 3", exitCode)
+	}
+
+	if out.String() != "input" {
+		t.Errorf("Bad output %q", out.String())
+	}
+
+	if !reflect.DeepEqual(md.Executions[id], []string{"cat"}) {
+		t.Errorf("Bad executions %v", md.Executions[id])
+	}
+
+	_, err = dk.ExecInteractive(context.Background(), "missing", ExecOptions{})
+	if err == nil {
+		t.Error("Expected Error")
+	}
+}
+
+// resizedReader yields `input` once the mock has resized an execution's TTY.
+type resizedReader struct {
+	md    *MockClient
+	input string
+}
+
+func (r *resizedReader) Read(p []byte) (int, error) {
+	for {
+		r.md.Lock()
+		resized := len(r.md.Resizes) != 0
+		r.md.Unlock()
+
+		if resized {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if r.input == "" {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.input)
+	r.input = r.input[n:]
+	return n, nil
+}
+
 func cacheKeys(cache map[string]time.Time) map[string]struct{} {
 	res := map[string]struct{}{}
 	for k := range cache {
@@ -380,3 +478,21 @@ func cacheKeys(cache map[string]time.Time) map[string]struct{} {
 	}
 	return res
 }
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+
+	quotas := map[float64]int64{-1: 0, 0: 0, 0.001: minCPUQuota, 1.5: 150000}
+	for cpu, exp := range quotas {
+		if quota := CPUQuota(cpu); quota != exp {
+			t.Errorf("CPUQuota(%g) = %d, expected %d", cpu, quota, exp)
+		}
+	}
+
+	limits := map[float64]int64{-1: 0, 0: 0, 0.001: minMemoryLimit, 2: 2 << 30}
+	for ram, exp := range limits {
+		if limit := MemoryLimit(ram); limit != exp {
+			t.Errorf("MemoryLimit(%g) = %d, expected %d", ram, limit, exp)
+		}
+	}
+}
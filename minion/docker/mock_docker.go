@@ -2,6 +2,7 @@ package docker
 
 import (
 	"errors"
+	"io"
 	"strings"
 	"sync"
 	"time"
@@ -22,9 +23,23 @@ type MockClient struct {
 	Pulled     map[string]struct{}
 	Containers map[string]mockContainer
 
+	// The named volumes created by the binds of containers.
+	Volumes map[string]struct{}
+
 	createdExecs map[string]dkc.CreateExecOptions
 	Executions   map[string][]string
 
+	// Executions exit with ExecExitCode.  If ExecBlock is set, they don't finish
+	// until it's closed.
+	ExecExitCode int
+	ExecBlock    chan struct{}
+
+	// The output of each container, by ID.
+	Output map[string]string
+
+	// The sizes each execution's TTY was resized to, by execution ID.
+	Resizes map[string][]TermSize
+
 	CreateError     bool
 	CreateExecError bool
 	InspectError    bool
@@ -42,8 +57,11 @@ func NewMock() (*MockClient, Client) {
 		Mutex:        &sync.Mutex{},
 		Pulled:       map[string]struct{}{},
 		Containers:   map[string]mockContainer{},
+		Volumes:      map[string]struct{}{},
 		createdExecs: map[string]dkc.CreateExecOptions{},
 		Executions:   map[string][]string{},
+		Output:       map[string]string{},
+		Resizes:      map[string][]TermSize{},
 	}
 	return md, Client{md, &sync.Mutex{}, map[string]time.Time{}}
 }
@@ -59,6 +77,7 @@ func (dk MockClient) StartContainer(id string, hostConfig *dkc.HostConfig) error
 
 	container := dk.Containers[id]
 	container.Running = true
+	container.State.Running = true
 	container.HostConfig = hostConfig
 	dk.Containers[id] = container
 	return nil
@@ -70,6 +89,18 @@ func (dk MockClient) StopContainer(id string) {
 	defer dk.Unlock()
 	container := dk.Containers[id]
 	container.Running = false
+	container.State.Running = false
+	dk.Containers[id] = container
+}
+
+// ExitContainer stops the given docker container as if its process exited with `code`.
+func (dk MockClient) ExitContainer(id string, code int) {
+	dk.StopContainer(id)
+
+	dk.Lock()
+	defer dk.Unlock()
+	container := dk.Containers[id]
+	container.State.ExitCode = code
 	dk.Containers[id] = container
 }
 
@@ -175,9 +206,34 @@ func (dk *MockClient) CreateContainer(opts dkc.CreateContainerOptions) (*dkc.Con
 		NetworkSettings: &dkc.NetworkSettings{},
 	}
 	dk.Containers[id] = mockContainer{container, false}
+
+	if opts.HostConfig != nil {
+		for _, bind := range opts.HostConfig.Binds {
+			source := strings.SplitN(bind, ":", 2)[0]
+			if !strings.HasPrefix(source, "/") {
+				dk.Volumes[source] = struct{}{}
+			}
+		}
+	}
 	return container, nil
 }
 
+// ListVolumes lists the named volumes created by containers.
+func (dk MockClient) ListVolumes(opts dkc.ListVolumesOptions) ([]dkc.Volume, error) {
+	dk.Lock()
+	defer dk.Unlock()
+
+	if dk.ListError {
+		return nil, errors.New("list error")
+	}
+
+	var volumes []dkc.Volume
+	for name := range dk.Volumes {
+		volumes = append(volumes, dkc.Volume{Name: name})
+	}
+	return volumes, nil
+}
+
 // CreateExec creates an execution option to be started by StartExec.
 func (dk MockClient) CreateExec(opts dkc.CreateExecOptions) (*dkc.Exec, error) {
 	dk.Lock()
@@ -208,9 +264,60 @@ func (dk MockClient) StartExec(id string, opts dkc.StartExecOptions) error {
 	exec, _ := dk.createdExecs[id]
 	dk.Executions[exec.Container] = append(dk.Executions[exec.Container],
 		strings.Join(exec.Cmd, " "))
+
+	if dk.ExecBlock != nil {
+		block := dk.ExecBlock
+		dk.Unlock()
+		<-block
+		dk.Lock()
+	}
+
+	// Interactive executions behave like `cat`, echoing their input.
+	if opts.InputStream != nil && opts.OutputStream != nil {
+		dk.Unlock()
+		_, err := io.Copy(opts.OutputStream, opts.InputStream)
+		dk.Lock()
+		return err
+	}
+	return nil
+}
+
+// ResizeExecTTY resizes the TTY of the supplied execution object.
+func (dk MockClient) ResizeExecTTY(id string, height, width int) error {
+	dk.Lock()
+	defer dk.Unlock()
+
+	if _, ok := dk.createdExecs[id]; !ok {
+		return errors.New("unknown exec")
+	}
+	dk.Resizes[id] = append(dk.Resizes[id], TermSize{height, width})
 	return nil
 }
 
+// InspectExec returns details of the specified execution.
+func (dk MockClient) InspectExec(id string) (*dkc.ExecInspect, error) {
+	dk.Lock()
+	defer dk.Unlock()
+
+	if _, ok := dk.createdExecs[id]; !ok {
+		return nil, errors.New("unknown exec")
+	}
+	return &dkc.ExecInspect{ID: id, ExitCode: dk.ExecExitCode}, nil
+}
+
+// Logs writes the output of the given container.
+func (dk MockClient) Logs(opts dkc.LogsOptions) error {
+	dk.Lock()
+	defer dk.Unlock()
+
+	if _, ok := dk.Containers[opts.Container]; !ok {
+		return ErrNoSuchContainer
+	}
+
+	_, err := io.WriteString(opts.OutputStream, dk.Output[opts.Container])
+	return err
+}
+
 // ResetExec clears the list of created and started executions, for use by the unit
 // tests.
 func (dk *MockClient) ResetExec() {
@@ -4,15 +4,35 @@ import (
 	"sort"
 
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/engine"
 	"github.com/NetSys/quilt/join"
 	"github.com/NetSys/quilt/stitch"
-	"github.com/NetSys/quilt/util"
 
 	log "github.com/Sirupsen/logrus"
 )
 
+// The policy is updated whenever the container table changes so that rollouts make
+// progress, so the most recently compiled spec is cached rather than recompiled each
+// time.
+var lastSpec struct {
+	valid  bool
+	spec   string
+	stitch stitch.Stitch
+	err    error
+}
+
+func compileSpec(spec string) (stitch.Stitch, error) {
+	if !lastSpec.valid || spec != lastSpec.spec {
+		lastSpec.stitch, lastSpec.err = stitch.New(spec,
+			stitch.DefaultImportGetter)
+		lastSpec.spec = spec
+		lastSpec.valid = true
+	}
+	return lastSpec.stitch, lastSpec.err
+}
+
 func updatePolicy(view db.Database, role db.Role, spec string) {
-	compiled, err := stitch.New(spec, stitch.DefaultImportGetter)
+	compiled, err := compileSpec(spec)
 	if err != nil {
 		log.WithError(err).Warn("Invalid spec.")
 		return
@@ -43,6 +63,9 @@ func updatePlacements(view db.Database, spec stitch.Stitch) {
 			Provider:    sp.Provider,
 			Size:        sp.Size,
 			Region:      sp.Region,
+			Spread:      sp.Spread,
+			MaxSkew:     sp.MaxSkew,
+			Rebalance:   sp.Rebalance,
 		})
 	}
 
@@ -75,10 +98,11 @@ func updateConnections(view db.Database, spec stitch.Stitch) {
 	dbcKey := func(val interface{}) interface{} {
 		c := val.(db.Connection)
 		return stitch.Connection{
-			From:    c.From,
-			To:      c.To,
-			MinPort: c.MinPort,
-			MaxPort: c.MaxPort,
+			From:     c.From,
+			To:       c.To,
+			MinPort:  c.MinPort,
+			MaxPort:  c.MaxPort,
+			Protocol: c.Protocol,
 		}
 	}
 
@@ -100,59 +124,31 @@ func updateConnections(view db.Database, spec stitch.Stitch) {
 		dbc.To = stitchc.To
 		dbc.MinPort = stitchc.MinPort
 		dbc.MaxPort = stitchc.MaxPort
+		dbc.Protocol = stitchc.Protocol
 		view.Commit(dbc)
 	}
 }
 
-func queryContainers(spec stitch.Stitch) []db.Container {
-	containers := map[int]*db.Container{}
-	for _, c := range spec.QueryContainers() {
-		containers[c.ID] = &db.Container{
-			StitchID: c.ID,
-			Command:  c.Command,
-			Image:    c.Image,
-			Env:      c.Env,
-		}
-	}
-
-	for _, label := range spec.QueryLabels() {
-		for _, id := range label.IDs {
-			containers[id].Labels = append(containers[id].Labels, label.Name)
-		}
-	}
-
-	var ret []db.Container
-	for _, c := range containers {
-		ret = append(ret, *c)
-	}
-
-	return ret
-}
-
 func updateContainers(view db.Database, spec stitch.Stitch) {
-	score := func(l, r interface{}) int {
-		left := l.(db.Container)
-		right := r.(db.Container)
-
-		if left.Image != right.Image ||
-			!util.StrSliceEqual(left.Command, right.Command) ||
-			!util.StrStrMapEqual(left.Env, right.Env) {
-			return -1
-		}
+	desired := engine.QueryContainers(spec)
+	pairs, toStart, toStop := engine.JoinContainers(desired,
+		view.SelectFromContainer(nil))
+	news, dbcs, progress := rollout(spec, pairs, toStart, toStop)
+	updateRollouts(view, progress)
 
-		score := util.EditDistance(left.Labels, right.Labels)
-		if left.StitchID != right.StitchID {
-			score++
-		}
-		return score
+	stopped := map[int]struct{}{}
+	for _, dbc := range dbcs {
+		stopped[dbc.ID] = struct{}{}
+		view.Remove(dbc)
 	}
 
-	pairs, news, dbcs := join.Join(queryContainers(spec),
-		view.SelectFromContainer(nil), score)
-
-	for _, dbc := range dbcs {
-		view.Remove(dbc.(db.Container))
+	var retained []db.Container
+	for _, dbc := range toStop {
+		if _, ok := stopped[dbc.ID]; !ok {
+			retained = append(retained, dbc)
+		}
 	}
+	renumberRetained(view, desired, retained)
 
 	for _, new := range news {
 		pairs = append(pairs, join.Pair{L: new, R: view.InsertContainer()})
@@ -170,7 +166,60 @@ func updateContainers(view db.Database, spec stitch.Stitch) {
 		dbc.Command = newc.Command
 		dbc.Image = newc.Image
 		dbc.Env = newc.Env
+		dbc.Volumes = newc.Volumes
+		dbc.CPU = newc.CPU
+		dbc.RAM = newc.RAM
+		dbc.HealthCheck = newc.HealthCheck
+		dbc.RestartPolicy = newc.RestartPolicy
 		dbc.StitchID = newc.StitchID
 		view.Commit(dbc)
 	}
 }
+
+// The containers kept running by a rollout are no longer in the spec, so their stitch
+// IDs may be reused by the containers replacing them.  They're renumbered so that
+// stitch IDs, and the IPs allocated by them, remain unique.
+func renumberRetained(view db.Database, desired, retained []db.Container) {
+	maxID := 0
+	used := map[int]struct{}{}
+	for _, dbc := range append(view.SelectFromContainer(nil), desired...) {
+		if dbc.StitchID > maxID {
+			maxID = dbc.StitchID
+		}
+	}
+
+	for _, dbc := range desired {
+		used[dbc.StitchID] = struct{}{}
+	}
+
+	for _, dbc := range retained {
+		if _, ok := used[dbc.StitchID]; ok {
+			maxID++
+			dbc.StitchID = maxID
+			view.Commit(dbc)
+		}
+	}
+}
+
+func updateRollouts(view db.Database, progress []db.Rollout) {
+	key := func(val interface{}) interface{} {
+		return val.(db.Rollout).Label
+	}
+
+	pairs, news, dbrs := join.HashJoin(db.RolloutSlice(progress),
+		db.RolloutSlice(view.SelectFromRollout(nil)), key, key)
+
+	for _, dbr := range dbrs {
+		view.Remove(dbr.(db.Rollout))
+	}
+
+	for _, new := range news {
+		pairs = append(pairs, join.Pair{L: new, R: view.InsertRollout()})
+	}
+
+	for _, pair := range pairs {
+		r := pair.L.(db.Rollout)
+		r.ID = pair.R.(db.Rollout).ID
+		view.Commit(r)
+	}
+}
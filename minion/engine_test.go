@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/engine"
 	"github.com/NetSys/quilt/stitch"
 	"github.com/NetSys/quilt/util"
 	"github.com/davecgh/go-spew/spew"
@@ -124,6 +125,75 @@ func TestContainerTxn(t *testing.T) {
 	}
 }
 
+func TestRollingUpdate(t *testing.T) {
+	conn := db.New()
+
+	spec := `deployment.deploy(new Label("a", [
+		new Container("alpine", ["tail"]), new Container("alpine", ["tail"])]));`
+	if err := testContainerTxn(conn, spec); err != "" {
+		t.Error(err)
+	}
+
+	conn.Transact(func(view db.Database) error {
+		for _, dbc := range view.SelectFromContainer(nil) {
+			dbc.DockerID = fmt.Sprintf("%d", dbc.ID)
+			view.Commit(dbc)
+		}
+		return nil
+	})
+
+	// Only one of the running containers is replaced at a time.
+	spec = `deployment.deploy(new Label("a", [
+		new Container("ubuntu", ["tail"]), new Container("ubuntu", ["tail"])]));`
+	var containers []db.Container
+	conn.Transact(func(view db.Database) error {
+		updatePolicy(view, db.Master, spec)
+		containers = view.SelectFromContainer(nil)
+		return nil
+	})
+
+	images := map[string]int{}
+	stitchIDs := map[int]struct{}{}
+	for _, dbc := range containers {
+		images[dbc.Image]++
+		stitchIDs[dbc.StitchID] = struct{}{}
+	}
+
+	expImages := map[string]int{"alpine": 1, "ubuntu": 1}
+	if !reflect.DeepEqual(images, expImages) {
+		t.Errorf("Images %v, expected %v", images, expImages)
+	}
+
+	if len(stitchIDs) != len(containers) {
+		t.Errorf("Containers share stitch IDs: %v", containers)
+	}
+
+	rollouts := conn.SelectFromRollout(nil)
+	expRollout := db.Rollout{Label: "a", Replicas: 2, Updated: 1, Old: 1,
+		MaxUnavailable: 1}
+	if len(rollouts) != 1 {
+		t.Fatalf("Unexpected rollouts: %v", rollouts)
+	}
+
+	expRollout.ID = rollouts[0].ID
+	if rollouts[0] != expRollout {
+		t.Errorf("Rollout %v, expected %v", rollouts[0], expRollout)
+	}
+
+	// Once the replacement is running, the rollout finishes.
+	conn.Transact(func(view db.Database) error {
+		for _, dbc := range view.SelectFromContainer(nil) {
+			dbc.DockerID = fmt.Sprintf("%d", dbc.ID)
+			view.Commit(dbc)
+		}
+		return nil
+	})
+
+	if err := testContainerTxn(conn, spec); err != "" {
+		t.Error(err)
+	}
+}
+
 func testContainerTxn(conn db.Conn, spec string) string {
 	var containers []db.Container
 	conn.Transact(func(view db.Database) error {
@@ -137,7 +207,7 @@ func testContainerTxn(conn db.Conn, spec string) string {
 		return err.Error()
 	}
 
-	for _, e := range queryContainers(compiled) {
+	for _, e := range engine.QueryContainers(compiled) {
 		found := false
 		for i, c := range containers {
 			if e.Image == c.Image &&
@@ -0,0 +1,129 @@
+package etcd
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/NetSys/quilt/db"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const healthStore = minionDir + "/health"
+
+// storeHealth is the state of a container as reported by the worker running it.
+type storeHealth struct {
+	StitchID int
+	DockerID string
+	Health   string
+	Restarts int
+}
+
+type storeHealthSlice []storeHealth
+
+// runHealthSync relays the state of the containers on each worker to the leader, so
+// that it may be reported to users, and so that rollouts know when new containers are
+// running.
+func runHealthSync(conn db.Conn, store Store) {
+	for range conn.TriggerTick(timeout/2, db.ContainerTable, db.EtcdTable).C {
+		self, err := conn.MinionSelf()
+		if err != nil {
+			continue
+		}
+
+		if self.Role == db.Worker {
+			writeHealth(conn, store, self.PrivateIP)
+		}
+
+		if conn.EtcdLeader() {
+			readHealth(conn, store)
+		}
+	}
+}
+
+func writeHealth(conn db.Conn, store Store, myIP string) {
+	if myIP == "" {
+		return
+	}
+
+	dbcs := conn.SelectFromContainerBy("Minion", myIP, func(dbc db.Container) bool {
+		return dbc.StitchID != 0
+	})
+
+	var health []storeHealth
+	for _, dbc := range dbcs {
+		health = append(health, storeHealth{
+			StitchID: dbc.StitchID,
+			DockerID: dbc.DockerID,
+			Health:   dbc.Health,
+			Restarts: dbc.Restarts,
+		})
+	}
+
+	// Sorted so that the value in Etcd only changes when the health does.
+	sort.Sort(storeHealthSlice(health))
+
+	js, err := json.Marshal(health)
+	if err != nil {
+		panic("Failed to convert container health to JSON")
+	}
+
+	key := healthStore + "/" + myIP
+	if err := store.Set(key, string(js), timeout*time.Second); err != nil {
+		log.WithError(err).Warning("Failed to update container health in Etcd.")
+	}
+}
+
+func readHealth(conn db.Conn, store Store) {
+	tree, err := store.GetTree(healthStore)
+	if err != nil {
+		log.WithError(err).Debug("Failed to get container health from Etcd.")
+		return
+	}
+
+	type healthKey struct {
+		minion   string
+		stitchID int
+	}
+
+	health := map[healthKey]storeHealth{}
+	for ip, t := range tree.Children {
+		var workerHealth []storeHealth
+		if err := json.Unmarshal([]byte(t.Value), &workerHealth); err != nil {
+			log.WithField("json", t.Value).Warning(
+				"Failed to parse container health.")
+			continue
+		}
+
+		for _, h := range workerHealth {
+			health[healthKey{ip, h.StitchID}] = h
+		}
+	}
+
+	conn.Transact(func(view db.Database) error {
+		for _, dbc := range view.SelectFromContainer(nil) {
+			h := health[healthKey{dbc.Minion, dbc.StitchID}]
+			if dbc.DockerID != h.DockerID || dbc.Health != h.Health ||
+				dbc.Restarts != h.Restarts {
+				dbc.DockerID = h.DockerID
+				dbc.Health = h.Health
+				dbc.Restarts = h.Restarts
+				view.Commit(dbc)
+			}
+		}
+		return nil
+	})
+}
+
+func (hs storeHealthSlice) Len() int {
+	return len(hs)
+}
+
+func (hs storeHealthSlice) Less(i, j int) bool {
+	return hs[i].StitchID < hs[j].StitchID
+}
+
+func (hs storeHealthSlice) Swap(i, j int) {
+	hs[i], hs[j] = hs[j], hs[i]
+}
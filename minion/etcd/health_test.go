@@ -0,0 +1,53 @@
+package etcd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestWriteHealth(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	store := NewMock()
+
+	conn.Transact(func(view db.Database) error {
+		for _, minion := range []string{"1.2.3.4", "1.2.3.4", "5.6.7.8"} {
+			dbc := view.InsertContainer()
+			dbc.StitchID = dbc.ID
+			dbc.Minion = minion
+			dbc.Health = db.HealthHealthy
+			view.Commit(dbc)
+		}
+
+		// Containers without a StitchID can't be matched on the leader.
+		dbc := view.InsertContainer()
+		dbc.Minion = "1.2.3.4"
+		view.Commit(dbc)
+		return nil
+	})
+
+	writeHealth(conn, store, "1.2.3.4")
+
+	val, err := store.Get(healthStore + "/1.2.3.4")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var health []storeHealth
+	if err := json.Unmarshal([]byte(val), &health); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	exp := []storeHealth{
+		{StitchID: 1, Health: db.HealthHealthy},
+		{StitchID: 2, Health: db.HealthHealthy},
+	}
+	if !reflect.DeepEqual(health, exp) {
+		t.Error(spew.Sprintf("Found health %v, expected %v", health, exp))
+	}
+}
@@ -2,6 +2,7 @@ package etcd
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/NetSys/quilt/db"
@@ -13,17 +14,29 @@ import (
 
 const timeout = 30
 
+// Each minion's record in Etcd expires unless it's refreshed, so it doubles as the
+// minion's heartbeat.  Minions whose heartbeats lapse are marked failed after
+// `failureGrace`, so that their containers are rescheduled, and forgotten after
+// `failedExpiry`.  The grace period keeps brief Etcd outages from moving containers.
+var failureGrace = 2 * time.Minute
+var failedExpiry = 30 * time.Minute
+
+var now = time.Now
+
 func runMinionSync(conn db.Conn, store Store) {
 	loopLog := util.NewEventTimer("Etcd")
+	lapsed := map[string]time.Time{}
 	for range conn.TriggerTick(timeout/2, db.MinionTable).C {
 		loopLog.LogStart()
 		writeMinion(conn, store)
-		readMinion(conn, store)
+		readMinion(conn, store, lapsed)
 		loopLog.LogEnd()
 	}
 }
 
-func readMinion(conn db.Conn, store Store) {
+// readMinion updates the minion table to match the minions in Etcd.  `lapsed` records
+// when the heartbeats of each minion missing from Etcd stopped.
+func readMinion(conn db.Conn, store Store, lapsed map[string]time.Time) {
 	tree, err := store.GetTree("/minion/nodes")
 	if err != nil {
 		log.WithError(err).Warning("Failed to get minions form Etcd.")
@@ -42,6 +55,7 @@ func readMinion(conn db.Conn, store Store) {
 
 	conn.Transact(func(view db.Database) error {
 		dbms, sms := filterSelf(view.SelectFromMinion(nil), storeMinions)
+		sms = append(sms, lapsedMinions(dbms, sms, lapsed)...)
 		del, add := diffMinion(dbms, sms)
 
 		for _, m := range del {
@@ -59,6 +73,49 @@ func readMinion(conn db.Conn, store Store) {
 	})
 }
 
+// lapsedMinions returns the minions in `dbMinions` whose heartbeats have lapsed, but
+// that should still be tracked, marked failed if their grace period is over.
+func lapsedMinions(dbMinions, storeMinions []db.Minion,
+	lapsed map[string]time.Time) []db.Minion {
+
+	alive := map[string]struct{}{}
+	for _, m := range storeMinions {
+		alive[m.PrivateIP] = struct{}{}
+	}
+
+	var res []db.Minion
+	missing := map[string]struct{}{}
+	for _, m := range dbMinions {
+		if _, ok := alive[m.PrivateIP]; ok {
+			continue
+		}
+		missing[m.PrivateIP] = struct{}{}
+
+		since, ok := lapsed[m.PrivateIP]
+		if !ok {
+			since = now()
+			lapsed[m.PrivateIP] = since
+		}
+
+		switch down := now().Sub(since); {
+		case down >= failedExpiry:
+			continue
+		case down >= failureGrace && !m.Failed:
+			log.WithField("minion", m.PrivateIP).Warning(
+				"Minion stopped sending heartbeats, marking it failed.")
+			m.Failed = true
+		}
+		res = append(res, m)
+	}
+
+	for ip := range lapsed {
+		if _, ok := missing[ip]; !ok {
+			delete(lapsed, ip)
+		}
+	}
+	return res
+}
+
 func filterSelf(dbMinions, storeMinions []db.Minion) ([]db.Minion, []db.Minion) {
 	var self db.Minion
 	var sms, dbms []db.Minion
@@ -81,12 +138,24 @@ func filterSelf(dbMinions, storeMinions []db.Minion) ([]db.Minion, []db.Minion)
 }
 
 func diffMinion(dbMinions, storeMinions []db.Minion) (del, add []db.Minion) {
+	// Slices aren't hashable, so the volumes are compared as a single string.
+	type minionKey struct {
+		role          db.Role
+		privateIP     string
+		provider      string
+		size          string
+		region        string
+		failed        bool
+		unschedulable bool
+		draining      bool
+		volumes       string
+	}
+
 	key := func(iface interface{}) interface{} {
 		m := iface.(db.Minion)
-		m.ID = 0
-		m.Spec = ""
-		m.Self = false
-		return m
+		return minionKey{m.Role, m.PrivateIP, m.Provider, m.Size, m.Region,
+			m.Failed, m.Unschedulable, m.Draining,
+			strings.Join(m.Volumes, ",")}
 	}
 
 	_, lefts, rights := join.HashJoin(db.MinionSlice(dbMinions),
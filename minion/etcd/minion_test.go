@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/NetSys/quilt/db"
 	"github.com/davecgh/go-spew/spew"
@@ -73,7 +74,7 @@ func TestReadMinion(t *testing.T) {
 	js, _ := json.Marshal(m)
 	store.Set("/minion/nodes/foo", string(js), 0)
 
-	readMinion(conn, store)
+	readMinion(conn, store, map[string]time.Time{})
 	minions := conn.SelectFromMinion(nil)
 	if len(minions) != 1 {
 		t.Error(spew.Sprintf("Wrong number of minions: %s", minions))
@@ -84,15 +85,63 @@ func TestReadMinion(t *testing.T) {
 		t.Error(spew.Sprintf("Incorrect DB Minion: %s", minions))
 	}
 
+	// Minions missing from Etcd are kept until their heartbeats have lapsed for long
+	// enough.
 	store = NewMock()
 	store.Mkdir("/minion/nodes")
-	readMinion(conn, store)
+	lapsed := map[string]time.Time{}
+	readMinion(conn, store, lapsed)
+	minions = conn.SelectFromMinion(nil)
+	if len(minions) != 1 {
+		t.Error(spew.Sprintf("Wrong number of minions: %s", minions))
+	}
+
+	lapsed[m.PrivateIP] = time.Now().Add(-failedExpiry)
+	readMinion(conn, store, lapsed)
 	minions = conn.SelectFromMinion(nil)
 	if len(minions) > 0 {
 		t.Error(spew.Sprintf("Expected zero minions, found: %s", minions))
 	}
 }
 
+func TestLapsedMinions(t *testing.T) {
+	start := time.Now()
+	clock := start
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	alive := db.Minion{PrivateIP: "1.1.1.1", Role: db.Worker}
+	dead := db.Minion{PrivateIP: "2.2.2.2", Role: db.Worker}
+	dbms := []db.Minion{alive, dead}
+	sms := []db.Minion{alive}
+	lapsed := map[string]time.Time{}
+
+	// Minions are kept unchanged during their grace period.
+	res := lapsedMinions(dbms, sms, lapsed)
+	if !reflect.DeepEqual(res, []db.Minion{dead}) {
+		t.Errorf("Got %v, expected %v", res, []db.Minion{dead})
+	}
+
+	clock = start.Add(failureGrace)
+	res = lapsedMinions(dbms, sms, lapsed)
+	failed := dead
+	failed.Failed = true
+	if !reflect.DeepEqual(res, []db.Minion{failed}) {
+		t.Errorf("Got %v, expected %v", res, []db.Minion{failed})
+	}
+
+	clock = start.Add(failedExpiry)
+	if res = lapsedMinions(dbms, sms, lapsed); len(res) != 0 {
+		t.Errorf("Expected expired minions to be forgotten, got %v", res)
+	}
+
+	// Minions that send heartbeats again are no longer tracked.
+	lapsedMinions(dbms, dbms, lapsed)
+	if len(lapsed) != 0 {
+		t.Errorf("Expected no lapsed minions, got %v", lapsed)
+	}
+}
+
 func TestReadDiff(t *testing.T) {
 	t.Parallel()
 
@@ -126,6 +175,27 @@ func TestReadDiff(t *testing.T) {
 	}
 }
 
+func TestReadDiffVolumes(t *testing.T) {
+	t.Parallel()
+
+	shared := db.Minion{PrivateIP: "1.2.3.4", Volumes: []string{"a", "b"}}
+	changedDB := db.Minion{PrivateIP: "5.6.7.8", Volumes: []string{"a"}}
+	changedEtcd := db.Minion{PrivateIP: "5.6.7.8", Volumes: []string{"a", "b"}}
+
+	del, add := diffMinion([]db.Minion{shared, changedDB},
+		[]db.Minion{shared, changedEtcd})
+
+	if exp := []db.Minion{changedDB}; !reflect.DeepEqual(del, exp) {
+		t.Error(spew.Sprintf("Diff Deletion Found:\n\t%s\nExpected:\n\t%s",
+			del, exp))
+	}
+
+	if exp := []db.Minion{changedEtcd}; !reflect.DeepEqual(add, exp) {
+		t.Error(spew.Sprintf("Diff Addition Found:\n\t%s\nExpected:\n\t%s",
+			add, exp))
+	}
+}
+
 func TestFilter(t *testing.T) {
 	newDB, newEtcd := filterSelf(nil, nil)
 	if len(newDB) > 0 || len(newEtcd) > 0 {
@@ -42,6 +42,12 @@ type storeContainer struct {
 	Image   string
 	Command []string
 	Env     map[string]string
+	Volumes []string
+	CPU     float64
+	RAM     float64
+
+	HealthCheck   db.HealthCheck
+	RestartPolicy string
 
 	Labels []string
 
@@ -169,7 +175,13 @@ func updateEtcdContainer(s Store, etcdData storeData, containers []db.Container)
 			Command:  c.Command,
 			Labels:   c.Labels,
 			Env:      c.Env,
+			Volumes:  c.Volumes,
+			CPU:      c.CPU,
+			RAM:      c.RAM,
 			IP:       "",
+
+			HealthCheck:   c.HealthCheck,
+			RestartPolicy: c.RestartPolicy,
 		}
 		dbContainerSlice = append(dbContainerSlice, sc)
 	}
@@ -299,8 +311,14 @@ func updateWorkerDBC(view db.Database, self db.Minion, etcdData storeData) {
 				Image:    dbc.Image,
 				Command:  dbc.Command,
 				Env:      dbc.Env,
+				Volumes:  dbc.Volumes,
+				CPU:      dbc.CPU,
+				RAM:      dbc.RAM,
 				Labels:   dbc.Labels,
-				IP:       dbc.IP,
+
+				HealthCheck:   dbc.HealthCheck,
+				RestartPolicy: dbc.RestartPolicy,
+				IP:            dbc.IP,
 			}
 			return containerJoinScore(l, right.(storeContainer))
 		})
@@ -326,6 +344,11 @@ func updateWorkerDBC(view db.Database, self db.Minion, etcdData storeData) {
 		dbc.Image = etcdc.Image
 		dbc.Command = etcdc.Command
 		dbc.Env = etcdc.Env
+		dbc.Volumes = etcdc.Volumes
+		dbc.CPU = etcdc.CPU
+		dbc.RAM = etcdc.RAM
+		dbc.HealthCheck = etcdc.HealthCheck
+		dbc.RestartPolicy = etcdc.RestartPolicy
 		dbc.Labels = etcdc.Labels
 		dbc.IP = etcdc.IP
 		dbc.Mac = macFromIP(dbc.IP)
@@ -394,7 +417,11 @@ func containerJoinScore(left, right storeContainer) int {
 	if left.Minion != right.Minion ||
 		left.Image != right.Image ||
 		!util.StrSliceEqual(left.Command, right.Command) ||
-		!util.StrStrMapEqual(left.Env, right.Env) {
+		!util.StrStrMapEqual(left.Env, right.Env) ||
+		!util.StrSliceEqual(left.Volumes, right.Volumes) ||
+		left.CPU != right.CPU || left.RAM != right.RAM ||
+		!left.HealthCheck.Equal(right.HealthCheck) ||
+		left.RestartPolicy != right.RestartPolicy {
 		return -1
 	}
 
@@ -16,6 +16,7 @@ func Run(conn db.Conn) {
 
 	go runElection(conn, store)
 	go runNetwork(conn, store)
+	go runHealthSync(conn, store)
 	runMinionSync(conn, store)
 }
 
@@ -0,0 +1,350 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/stitch"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	dnsPort = 53
+
+	// The largest UDP message we accept, as advertised by most EDNS clients.
+	dnsMaxSize = 4096
+
+	// How long to wait for the host's nameservers to answer a forwarded query.
+	dnsForwardTimeout = 5 * time.Second
+
+	dnsTypeA   = 1
+	dnsTypeAll = 255
+	dnsClassIN = 1
+
+	dnsRcodeSuccess  = 0
+	dnsRcodeServFail = 2
+	dnsRcodeNXDomain = 3
+)
+
+// dnsTable maps the IP of each container to the `.q` names it may resolve, and their
+// addresses.
+type dnsTable map[string]map[string]net.IP
+
+// dnsServer answers queries from containers for the `.q` names of the labels they may
+// access, and forwards all other queries to the host's nameservers.
+type dnsServer struct {
+	sync.Mutex
+	table dnsTable
+
+	// Addresses of the host's nameservers, e.g. "8.8.8.8:53".
+	nameservers []string
+}
+
+// runDNS serves DNS on the default gateway of the containers on this worker, keeping
+// its records in sync with the database.
+func runDNS(conn db.Conn) {
+	server := &dnsServer{}
+	listening := false
+	for range conn.TriggerTick(30, db.MinionTable, db.ContainerTable,
+		db.LabelTable, db.ConnectionTable).C {
+
+		var table dnsTable
+		var worker bool
+		conn.Transact(func(view db.Database) error {
+			self, err := view.MinionSelf()
+			worker = err == nil && self.Role == db.Worker
+			table = makeDNSTable(view.SelectFromContainer(nil),
+				view.SelectFromLabel(nil), view.SelectFromConnection(nil))
+			return nil
+		})
+
+		if !worker {
+			continue
+		}
+
+		server.setTable(table)
+		if !listening {
+			listening = true
+			go server.listen()
+		}
+	}
+}
+
+func makeDNSTable(containers []db.Container, labels []db.Label,
+	connections []db.Connection) dnsTable {
+
+	labelMap := map[string]db.Label{}
+	for _, l := range labels {
+		labelMap[l.Label] = l
+	}
+
+	conns := map[string][]string{}
+	for _, conn := range connections {
+		if conn.To != stitch.PublicInternetLabel {
+			conns[conn.From] = append(conns[conn.From], conn.To)
+		}
+	}
+
+	table := dnsTable{}
+	for _, dbc := range containers {
+		if dbc.IP == "" {
+			continue
+		}
+
+		names := map[string]net.IP{}
+		for _, from := range dbc.Labels {
+			for _, to := range conns[from] {
+				label := labelMap[to]
+				if ip := net.ParseIP(label.IP); ip != nil {
+					names[strings.ToLower(to+".q")] = ip
+				}
+
+				// The hostname prefix starts from 1 for readability.
+				for i, cIP := range label.ContainerIPs {
+					name := fmt.Sprintf("%d.%s.q", i+1, to)
+					if ip := net.ParseIP(cIP); ip != nil {
+						names[strings.ToLower(name)] = ip
+					}
+				}
+			}
+		}
+		table[dbc.IP] = names
+	}
+	return table
+}
+
+func (s *dnsServer) setTable(table dnsTable) {
+	s.Lock()
+	s.table = table
+	s.Unlock()
+}
+
+// lookup returns the address of `name` if the container at `src` may resolve it.
+func (s *dnsServer) lookup(src, name string) (net.IP, bool) {
+	s.Lock()
+	defer s.Unlock()
+	ip, ok := s.table[src][strings.ToLower(name)]
+	return ip, ok
+}
+
+func (s *dnsServer) listen() {
+	s.nameservers = hostNameservers()
+
+	// The gateway address isn't assigned until the quilt-int bridge is set up.
+	addr := &net.UDPAddr{IP: net.ParseIP(GatewayIP), Port: dnsPort}
+	var sock *net.UDPConn
+	for {
+		var err error
+		sock, err = net.ListenUDP("udp", addr)
+		if err == nil {
+			break
+		}
+		log.WithError(err).Debug("Failed to listen for DNS queries.")
+		time.Sleep(5 * time.Second)
+	}
+	defer sock.Close()
+
+	for {
+		buf := make([]byte, dnsMaxSize)
+		n, src, err := sock.ReadFromUDP(buf)
+		if err != nil {
+			log.WithError(err).Warning("Failed to read DNS query.")
+			continue
+		}
+
+		go func() {
+			if reply := s.handle(buf[:n], src.IP.String()); reply != nil {
+				sock.WriteToUDP(reply, src)
+			}
+		}()
+	}
+}
+
+// handle returns the reply to `query`, sent by the container at `src`, or nil if the
+// query should be dropped.
+func (s *dnsServer) handle(query []byte, src string) []byte {
+	q, err := parseDNSQuery(query)
+	if err != nil {
+		log.WithError(err).Debug("Dropping malformed DNS query.")
+		return nil
+	}
+
+	if !strings.HasSuffix(strings.ToLower(q.name), ".q") {
+		reply, err := forwardDNS(s.nameservers, query)
+		if err != nil {
+			log.WithError(err).Debug("Failed to forward DNS query.")
+			return q.reply(dnsRcodeServFail, nil)
+		}
+		return reply
+	}
+
+	ip, ok := s.lookup(src, q.name)
+	if !ok {
+		return q.reply(dnsRcodeNXDomain, nil)
+	}
+
+	// Other record types get an empty answer, as the name exists.
+	if q.class != dnsClassIN || (q.qtype != dnsTypeA && q.qtype != dnsTypeAll) {
+		return q.reply(dnsRcodeSuccess, nil)
+	}
+	return q.reply(dnsRcodeSuccess, ip)
+}
+
+// forwardDNS relays `query` to the first of `nameservers` that answers it.
+func forwardDNS(nameservers []string, query []byte) ([]byte, error) {
+	err := errors.New("no nameservers")
+	for _, ns := range nameservers {
+		var reply []byte
+		if reply, err = exchangeDNS(ns, query); err == nil {
+			return reply, nil
+		}
+	}
+	return nil, err
+}
+
+func exchangeDNS(nameserver string, query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", nameserver, dnsForwardTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(dnsForwardTimeout))
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, dnsMaxSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// hostNameservers returns the addresses of the nameservers the host uses.
+func hostNameservers() []string {
+	resolv, err := ioutil.ReadFile("/etc/resolv.conf")
+	if err != nil {
+		log.WithError(err).Error("failed to read /etc/resolv.conf")
+		return nil
+	}
+	return parseNameservers(string(resolv))
+}
+
+func parseNameservers(resolv string) []string {
+	nsRE := regexp.MustCompile("(?m)^\\s*nameserver\\s+(\\S+)")
+
+	var nameservers []string
+	for _, match := range nsRE.FindAllStringSubmatch(resolv, -1) {
+		if ip := net.ParseIP(match[1]); ip != nil {
+			nameservers = append(nameservers,
+				net.JoinHostPort(ip.String(), fmt.Sprint(dnsPort)))
+		}
+	}
+	return nameservers
+}
+
+// dnsQuery is a DNS query with a single question.
+type dnsQuery struct {
+	id       uint16
+	flags    uint16
+	name     string
+	qtype    uint16
+	class    uint16
+	question []byte // The question section, as it appeared in the query.
+}
+
+func parseDNSQuery(msg []byte) (dnsQuery, error) {
+	if len(msg) < 12 {
+		return dnsQuery{}, errors.New("message too short")
+	}
+
+	q := dnsQuery{
+		id:    binary.BigEndian.Uint16(msg[0:2]),
+		flags: binary.BigEndian.Uint16(msg[2:4]),
+	}
+
+	if q.flags&0x8000 != 0 {
+		return dnsQuery{}, errors.New("message is a response")
+	}
+
+	if binary.BigEndian.Uint16(msg[4:6]) != 1 {
+		return dnsQuery{}, errors.New("queries must have exactly one question")
+	}
+
+	var labels []string
+	i := 12
+	for {
+		if i >= len(msg) {
+			return dnsQuery{}, errors.New("truncated name")
+		}
+
+		length := int(msg[i])
+		i++
+		if length == 0 {
+			break
+		}
+
+		// Compression pointers never appear in the first question.
+		if length > 63 || i+length > len(msg) {
+			return dnsQuery{}, errors.New("invalid name")
+		}
+		labels = append(labels, string(msg[i:i+length]))
+		i += length
+	}
+
+	if i+4 > len(msg) {
+		return dnsQuery{}, errors.New("truncated question")
+	}
+
+	q.name = strings.Join(labels, ".")
+	q.qtype = binary.BigEndian.Uint16(msg[i : i+2])
+	q.class = binary.BigEndian.Uint16(msg[i+2 : i+4])
+	q.question = msg[12 : i+4]
+	return q, nil
+}
+
+// reply builds an authoritative response to the query with the given response code,
+// and if `ip` isn't nil, an A record answering it.
+func (q dnsQuery) reply(rcode uint16, ip net.IP) []byte {
+	ip = ip.To4()
+
+	// Keep the opcode and recursion desired bits, and set the response,
+	// authoritative answer, and recursion available bits.
+	flags := q.flags&0x7900 | 0x8000 | 0x0400 | 0x0080 | rcode
+
+	var ancount uint16
+	if ip != nil {
+		ancount = 1
+	}
+
+	msg := make([]byte, 12, 12+len(q.question)+16)
+	binary.BigEndian.PutUint16(msg[0:2], q.id)
+	binary.BigEndian.PutUint16(msg[2:4], flags)
+	binary.BigEndian.PutUint16(msg[4:6], 1)
+	binary.BigEndian.PutUint16(msg[6:8], ancount)
+	msg = append(msg, q.question...)
+
+	if ip != nil {
+		answer := make([]byte, 12)
+		binary.BigEndian.PutUint16(answer[0:2], 0xc00c) // Points at the question.
+		binary.BigEndian.PutUint16(answer[2:4], dnsTypeA)
+		binary.BigEndian.PutUint16(answer[4:6], dnsClassIN)
+
+		// A TTL of 0 keeps containers from caching names that may move.
+		binary.BigEndian.PutUint32(answer[6:10], 0)
+		binary.BigEndian.PutUint16(answer[10:12], uint16(len(ip)))
+		msg = append(append(msg, answer...), ip...)
+	}
+	return msg
+}
@@ -0,0 +1,215 @@
+package network
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NetSys/quilt/db"
+)
+
+func TestDNSTable(t *testing.T) {
+	t.Parallel()
+
+	labels := []db.Label{
+		{
+			Label:        "red",
+			IP:           "10.0.0.1",
+			ContainerIPs: []string{"1.2.2.2", "1.3.3.3", "1.4.4.4"},
+		},
+		{
+			Label:        "blue",
+			IP:           "10.0.0.2",
+			ContainerIPs: []string{"1.3.3.3", "1.4.4.4"},
+		},
+		{
+			Label:        "Green",
+			IP:           "10.0.0.3",
+			ContainerIPs: []string{"1.1.1.1"},
+		},
+	}
+
+	connections := []db.Connection{
+		{From: "red", To: "blue"},
+		{From: "red", To: "Green"},
+		{From: "blue", To: "red"},
+		{From: "blue", To: "Green"},
+		{From: "blue", To: "public"},
+	}
+
+	containers := []db.Container{
+		{IP: "1.1.1.1", Labels: []string{"Green"}},
+		{IP: "1.2.2.2", Labels: []string{"red"}},
+		{IP: "1.3.3.3", Labels: []string{"red", "blue"}},
+		{Labels: []string{"red"}},
+	}
+
+	table := makeDNSTable(containers, labels, connections)
+
+	names := func(ip string) map[string]string {
+		res := map[string]string{}
+		for name, addr := range table[ip] {
+			res[name] = addr.String()
+		}
+		return res
+	}
+
+	if len(table) != 3 {
+		t.Errorf("Expected only containers with IPs in the table, got %v", table)
+	}
+
+	// Containers may only resolve the labels they connect to.
+	if res := names("1.1.1.1"); len(res) != 0 {
+		t.Errorf("Green shouldn't resolve any names, but resolved %v", res)
+	}
+
+	exp := map[string]string{
+		"blue.q":    "10.0.0.2",
+		"1.blue.q":  "1.3.3.3",
+		"2.blue.q":  "1.4.4.4",
+		"green.q":   "10.0.0.3",
+		"1.green.q": "1.1.1.1",
+	}
+	if res := names("1.2.2.2"); !reflect.DeepEqual(res, exp) {
+		t.Errorf("Bad names for red: %v, expected %v", res, exp)
+	}
+
+	// Containers with several labels may resolve what any of them connect to.
+	exp["red.q"] = "10.0.0.1"
+	exp["1.red.q"] = "1.2.2.2"
+	exp["2.red.q"] = "1.3.3.3"
+	exp["3.red.q"] = "1.4.4.4"
+	if res := names("1.3.3.3"); !reflect.DeepEqual(res, exp) {
+		t.Errorf("Bad names for red and blue: %v, expected %v", res, exp)
+	}
+}
+
+func TestDNSHandle(t *testing.T) {
+	t.Parallel()
+
+	s := &dnsServer{}
+	s.setTable(dnsTable{"1.2.2.2": {"blue.q": net.ParseIP("10.0.0.2")}})
+
+	checkReply := func(reply []byte, expRcode uint16, expIP string) {
+		if len(reply) < 12 {
+			t.Errorf("Bad reply: %v", reply)
+			return
+		}
+
+		if id := binary.BigEndian.Uint16(reply[0:2]); id != 7 {
+			t.Errorf("Reply has ID %d, expected 7", id)
+		}
+
+		flags := binary.BigEndian.Uint16(reply[2:4])
+		if flags&0x8000 == 0 || flags&0x0100 == 0 {
+			t.Errorf("Reply should be a response that keeps RD: %x", flags)
+		}
+
+		if rcode := flags & 0xf; rcode != expRcode {
+			t.Errorf("Reply has rcode %d, expected %d", rcode, expRcode)
+		}
+
+		ancount := binary.BigEndian.Uint16(reply[6:8])
+		if expIP == "" {
+			if ancount != 0 {
+				t.Errorf("Expected no answers, got %d", ancount)
+			}
+			return
+		}
+
+		if ancount != 1 {
+			t.Errorf("Expected 1 answer, got %d", ancount)
+			return
+		}
+
+		if ip := net.IP(reply[len(reply)-4:]).String(); ip != expIP {
+			t.Errorf("Answered %s, expected %s", ip, expIP)
+		}
+	}
+
+	checkReply(s.handle(makeDNSQuery("blue.q", dnsTypeA), "1.2.2.2"),
+		dnsRcodeSuccess, "10.0.0.2")
+	checkReply(s.handle(makeDNSQuery("BLUE.q.", dnsTypeA), "1.2.2.2"),
+		dnsRcodeSuccess, "10.0.0.2")
+
+	// Names that exist, but have no records of the requested type.
+	checkReply(s.handle(makeDNSQuery("blue.q", 28), "1.2.2.2"),
+		dnsRcodeSuccess, "")
+
+	// Containers can't resolve labels they may not access.
+	checkReply(s.handle(makeDNSQuery("blue.q", dnsTypeA), "1.3.3.3"),
+		dnsRcodeNXDomain, "")
+	checkReply(s.handle(makeDNSQuery("red.q", dnsTypeA), "1.2.2.2"),
+		dnsRcodeNXDomain, "")
+
+	// Without nameservers, other names can't be resolved.
+	checkReply(s.handle(makeDNSQuery("quilt.io", dnsTypeA), "1.2.2.2"),
+		dnsRcodeServFail, "")
+
+	if reply := s.handle([]byte{1, 2, 3}, "1.2.2.2"); reply != nil {
+		t.Errorf("Expected malformed queries to be dropped, got %v", reply)
+	}
+}
+
+func TestForwardDNS(t *testing.T) {
+	t.Parallel()
+
+	upstream, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer upstream.Close()
+
+	go func() {
+		buf := make([]byte, dnsMaxSize)
+		n, src, err := upstream.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		q, err := parseDNSQuery(buf[:n])
+		if err != nil {
+			return
+		}
+		upstream.WriteToUDP(q.reply(dnsRcodeSuccess, net.ParseIP("8.8.8.8")),
+			src)
+	}()
+
+	s := &dnsServer{nameservers: []string{upstream.LocalAddr().String()}}
+	reply := s.handle(makeDNSQuery("quilt.io", dnsTypeA), "1.2.2.2")
+	if len(reply) < 4 {
+		t.Fatalf("Bad reply: %v", reply)
+	}
+
+	if ip := net.IP(reply[len(reply)-4:]).String(); ip != "8.8.8.8" {
+		t.Errorf("Forwarded reply answered %s, expected 8.8.8.8", ip)
+	}
+}
+
+func TestParseNameservers(t *testing.T) {
+	t.Parallel()
+
+	resolv := `# Generated by NetworkManager
+search example.com
+nameserver 8.8.8.8
+nameserver	127.0.0.53
+# nameserver 1.1.1.1
+nameserver bogus
+`
+	exp := []string{"8.8.8.8:53", "127.0.0.53:53"}
+	if res := parseNameservers(resolv); !reflect.DeepEqual(res, exp) {
+		t.Errorf("Parsed %v, expected %v", res, exp)
+	}
+}
+
+func makeDNSQuery(name string, qtype uint16) []byte {
+	msg := []byte{0, 7, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0}
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0, byte(qtype>>8), byte(qtype), 0, dnsClassIN)
+	return msg
+}
@@ -1,18 +1,20 @@
 // Package network manages the network services of the application dataplane.  This
 // means ensuring that containers can find and communicate with each other in accordance
-// with the policy specification.  It achieves this by manipulating IP addresses and
-// hostnames within the containers, Open vSwitch on each running worker, and the OVN
-// controller.
+// with the policy specification.  It achieves this by manipulating IP addresses within
+// the containers, Open vSwitch on each running worker, and the OVN controller, and by
+// serving DNS for the hostnames of labels and containers.
 package network
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/join"
 	"github.com/NetSys/quilt/minion/docker"
 	"github.com/NetSys/quilt/minion/ovsdb"
+	"github.com/NetSys/quilt/stitch"
 	"github.com/NetSys/quilt/util"
 
 	log "github.com/Sirupsen/logrus"
@@ -28,6 +30,9 @@ const GatewayIP = "10.0.0.1"
 
 const gatewayMAC = "02:00:0a:00:00:01"
 
+// The subnet of the logical network, which includes GatewayIP.
+const quiltSubnet = "10.0.0.0/8"
+
 type dbport struct {
 	bridge string
 	ip     string
@@ -39,6 +44,8 @@ type dbslice []dbport
 
 // Run blocks implementing the network services.
 func Run(conn db.Conn, dk docker.Client) {
+	go runDNS(conn)
+
 	for {
 		odb, err := ovsdb.Open()
 		if err == nil {
@@ -191,10 +198,11 @@ func updateACLs(connections []db.Connection, labels []db.Label,
 }
 
 type aclConnection struct {
-	fromIPs []string
-	toIPs   []string
-	minPort int
-	maxPort int
+	fromIPs  []string
+	toIPs    []string
+	minPort  int
+	maxPort  int
+	protocol string
 }
 
 func getACLConnections(connections []db.Connection, labels []db.Label,
@@ -237,10 +245,11 @@ func getACLConnections(connections []db.Connection, labels []db.Label,
 		}
 
 		res = append(res, aclConnection{
-			fromIPs: fromIPs,
-			toIPs:   toIPs,
-			minPort: conn.MinPort,
-			maxPort: conn.MaxPort,
+			fromIPs:  fromIPs,
+			toIPs:    toIPs,
+			minPort:  conn.MinPort,
+			maxPort:  conn.MaxPort,
+			protocol: conn.Protocol,
 		})
 	}
 
@@ -248,22 +257,59 @@ func getACLConnections(connections []db.Connection, labels []db.Label,
 }
 
 func (c aclConnection) acls() (acls []string) {
-	matchFmt := fmt.Sprintf("ip4.src==%%[1]s && ip4.dst==%%[3]s && "+
-		"(%[1]d <= udp.%%[2]s <= %[2]d || %[1]d <= tcp.%%[2]s <= %[2]d)",
-		c.minPort, c.maxPort)
+	var portMatches []string
+	for _, protocol := range transportProtocols(c.protocol) {
+		portMatches = append(portMatches, fmt.Sprintf(
+			"%[1]d <= %[3]s.%%[2]s <= %[2]d", c.minPort, c.maxPort, protocol))
+	}
+
+	var matchFmt string
+	switch len(portMatches) {
+	case 0:
+	case 1:
+		matchFmt = "ip4.src==%[1]s && ip4.dst==%[3]s && " + portMatches[0]
+	default:
+		matchFmt = "ip4.src==%[1]s && ip4.dst==%[3]s && (" +
+			strings.Join(portMatches, " || ") + ")"
+	}
+
 	icmpFmt := "ip4.src==%s && ip4.dst==%s && icmp"
 	for _, fromIP := range c.fromIPs {
 		for _, toIP := range c.toIPs {
-			acls = append(acls,
-				fmt.Sprintf(matchFmt, fromIP, "dst", toIP),
-				fmt.Sprintf(matchFmt, toIP, "src", fromIP),
-				fmt.Sprintf(icmpFmt, fromIP, toIP),
-				fmt.Sprintf(icmpFmt, toIP, fromIP))
+			if matchFmt != "" {
+				acls = append(acls,
+					fmt.Sprintf(matchFmt, fromIP, "dst", toIP),
+					fmt.Sprintf(matchFmt, toIP, "src", fromIP))
+			}
+
+			if allowsICMP(c.protocol) {
+				acls = append(acls,
+					fmt.Sprintf(icmpFmt, fromIP, toIP),
+					fmt.Sprintf(icmpFmt, toIP, fromIP))
+			}
 		}
 	}
 	return acls
 }
 
+// transportProtocols returns the transport protocols allowed by a connection with
+// `protocol`.
+func transportProtocols(protocol string) []string {
+	switch protocol {
+	case "":
+		return []string{stitch.ProtocolUDP, stitch.ProtocolTCP}
+	case stitch.ProtocolICMP:
+		return nil
+	default:
+		return []string{protocol}
+	}
+}
+
+// allowsICMP returns true if a connection with `protocol` allows ICMP.
+func allowsICMP(protocol string) bool {
+	return protocol == "" || protocol == stitch.ProtocolICMP
+}
+
 func generateACLs(connections []aclConnection) map[ovsdb.ACLCore]struct{} {
 	coreACLs := map[ovsdb.ACLCore]struct{}{
 		// Drop all ip traffic by default.
@@ -196,6 +196,14 @@ func TestACLGeneration(t *testing.T) {
 		"&& (80 <= udp.src <= 81 || 80 <= tcp.src <= 81)")
 	allowMatch(exp, "ip4.src==13.13.13.13 && ip4.dst==11.11.11.11 && icmp")
 
+	// Connections restricted to a protocol allow only it.
+	allowMatch(exp, "ip4.src==14.14.14.14 && ip4.dst==15.15.15.15 "+
+		"&& 53 <= udp.dst <= 53")
+	allowMatch(exp, "ip4.src==15.15.15.15 && ip4.dst==14.14.14.14 "+
+		"&& 53 <= udp.src <= 53")
+	allowMatch(exp, "ip4.src==14.14.14.14 && ip4.dst==16.16.16.16 && icmp")
+	allowMatch(exp, "ip4.src==16.16.16.16 && ip4.dst==14.14.14.14 && icmp")
+
 	actual := generateACLs(
 		[]aclConnection{
 			{
@@ -210,6 +218,20 @@ func TestACLGeneration(t *testing.T) {
 				minPort: 80,
 				maxPort: 81,
 			},
+			{
+				fromIPs:  []string{"14.14.14.14"},
+				toIPs:    []string{"15.15.15.15"},
+				minPort:  53,
+				maxPort:  53,
+				protocol: "udp",
+			},
+			{
+				fromIPs:  []string{"14.14.14.14"},
+				toIPs:    []string{"16.16.16.16"},
+				minPort:  0,
+				maxPort:  65535,
+				protocol: "icmp",
+			},
 		},
 	)
 	if !reflect.DeepEqual(actual, exp) {
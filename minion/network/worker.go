@@ -20,7 +20,6 @@ import (
 	"github.com/NetSys/quilt/minion/ovsdb"
 	"github.com/NetSys/quilt/minion/supervisor"
 	"github.com/NetSys/quilt/stitch"
-	"github.com/NetSys/quilt/util"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -31,6 +30,10 @@ const (
 	loopback         string = "lo"
 	innerMTU         int    = 1400
 	concurrencyLimit int    = 32 // Adjust to change per function goroutine limit
+
+	// The conntrack zone that tracks connections to containers, kept apart from
+	// the zone the host uses for its own connections.
+	ctZone int = 1
 )
 
 // This represents a network namespace
@@ -93,7 +96,6 @@ type OFRuleSlice []OFRule
 //      patch ports between br-int and quilt-int, then install flows to send traffic
 //      between the patch port on quilt-int and the container's outer interface
 //      (These flows live in Table 2)
-//    - Update the container's /etc/hosts file with the set of labels it may access.
 //    - Populate quilt-int with the OpenFlow rules necessary to facilitate forwarding.
 //
 // To connect to the public internet, we do the following setup:
@@ -106,15 +108,18 @@ type OFRuleSlice []OFRule
 //        * Make eth0 the route to the 10/8 subnet.
 //        * Make the quilt-int device on the host the default gateway (this is the LOCAL
 //          port on the quilt-int bridge).
-//        * Setup /etc/resolv.conf with the same nameservers as the host.
+//        * Use the DNS server on the default gateway, which resolves the labels
+//          the container may access, and forwards other queries to the host's
+//          nameservers.
 //    - On the quilt-int bridge:
 //        * Forward packets from containers to LOCAL, if their dst MAC is that of the
 //          default gateway.
 //        * Forward arp packets to both br-int and the default gateway.
 //        * Forward packets from LOCAL to the container with the packet's dst MAC.
-// XXX: The worker additionally has several basic jobs which are currently
-// unimplemented:
-//    - ACLS should be installed to guarantee only sanctioned communication.
+//
+// Each worker also installs OpenFlow rules on quilt-int that drop any IP traffic from
+// its containers that isn't permitted by the connections in the stitch.  See
+// containerFlows.
 
 func runWorker(conn db.Conn, dk docker.Client) {
 	minion, err := conn.MinionSelf()
@@ -133,33 +138,22 @@ func runWorker(conn db.Conn, dk docker.Client) {
 	// containers won't be removed while we're in the process of setting them up.
 	// Not ideal, but for now it's good enough.
 	conn.Transact(func(view db.Database) error {
-		containers := view.SelectFromContainer(func(c db.Container) bool {
-			return c.DockerID != "" && c.IP != "" && c.Mac != "" &&
-				c.Pid != 0
-		})
+		containers := view.SelectFromContainerBy("Minion", minion.PrivateIP,
+			func(c db.Container) bool {
+				return c.DockerID != "" && c.IP != "" && c.Mac != "" &&
+					c.Pid != 0
+			})
 		labels := view.SelectFromLabel(func(l db.Label) bool {
 			return l.IP != ""
 		})
 		connections := view.SelectFromConnection(nil)
 
-		var wg sync.WaitGroup
-		wg.Add(2)
-
-		go func() {
-			updateEtcHosts(dk, containers, labels, connections)
-			wg.Done()
-		}()
-
-		go func() {
-			updateNameservers(dk, containers)
-			wg.Done()
-		}()
-
 		updateNamespaces(containers)
 		updateVeths(containers)
 		updateNAT(containers, connections)
 		updatePorts(odb, containers)
 
+		var wg sync.WaitGroup
 		if exists, err := linkExists("", quiltBridge); exists {
 			updateDefaultGw(odb)
 			wg.Add(1)
@@ -466,10 +460,9 @@ func generateTargetNatRules(containers []db.Container,
 		"-A POSTROUTING -s 10.0.0.0/8 -o eth0 -j MASQUERADE",
 	}
 
-	protocols := []string{"tcp", "udp"}
-	// Map each container IP to all ports on which it can receive packets
-	// from the public internet.
-	portsFromWeb := make(map[string]map[int]struct{})
+	// Map each transport protocol to the ports on which each container IP can
+	// receive packets from the public internet.
+	portsFromWeb := make(map[string][]portTarget)
 
 	for _, dbc := range containers {
 		for _, conn := range connections {
@@ -484,25 +477,30 @@ func generateTargetNatRules(containers []db.Container,
 					continue
 				}
 
-				if _, ok := portsFromWeb[dbc.IP]; !ok {
-					portsFromWeb[dbc.IP] = make(map[int]struct{})
+				for _, proto := range transportProtocols(conn.Protocol) {
+					portsFromWeb[proto] = append(portsFromWeb[proto],
+						portTarget{dbc.IP, conn.MinPort,
+							conn.MaxPort})
 				}
-
-				portsFromWeb[dbc.IP][conn.MinPort] = struct{}{}
 			}
 		}
 	}
 
-	// Map the container's port to the same port of the host.
-	for ip, ports := range portsFromWeb {
-		for port := range ports {
-			for _, protocol := range protocols {
-				strRules = append(strRules, fmt.Sprintf(
-					"-A PREROUTING -i eth0 "+
-						"-p %s -m %s --dport %d -j "+
-						"DNAT --to-destination %s:%d",
-					protocol, protocol, port, ip, port))
+	// Map the container's ports to the same ports of the host.
+	for protocol, targets := range portsFromWeb {
+		for _, t := range claimPorts(targets) {
+			dport := fmt.Sprintf("%d", t.minPort)
+			dest := fmt.Sprintf("%s:%d", t.ip, t.minPort)
+			if t.maxPort != t.minPort {
+				dport += fmt.Sprintf(":%d", t.maxPort)
+				dest += fmt.Sprintf("-%d", t.maxPort)
 			}
+
+			strRules = append(strRules, fmt.Sprintf(
+				"-A PREROUTING -i eth0 "+
+					"-p %s -m %s --dport %s -j "+
+					"DNAT --to-destination %s",
+				protocol, protocol, dport, dest))
 		}
 	}
 
@@ -517,6 +515,61 @@ func generateTargetNatRules(containers []db.Container,
 	return rules
 }
 
+// portTarget is a range of host ports forwarded to the container at `ip`.
+type portTarget struct {
+	ip      string
+	minPort int
+	maxPort int
+}
+
+// claimPorts merges `targets` into disjoint ranges.  The placement rules keep
+// containers with overlapping public ports on separate machines, but should the
+// ranges of two containers overlap anyway, each port goes to the container whose range
+// starts first.
+func claimPorts(targets []portTarget) []portTarget {
+	sorted := append(portTargetSlice{}, targets...)
+	sort.Sort(sorted)
+
+	var claimed []portTarget
+	next := 0
+	for _, t := range sorted {
+		if t.minPort < next {
+			t.minPort = next
+		}
+
+		if t.minPort > t.maxPort {
+			continue
+		}
+		next = t.maxPort + 1
+
+		last := len(claimed) - 1
+		if last >= 0 && claimed[last].ip == t.ip &&
+			claimed[last].maxPort+1 == t.minPort {
+			claimed[last].maxPort = t.maxPort
+		} else {
+			claimed = append(claimed, t)
+		}
+	}
+	return claimed
+}
+
+type portTargetSlice []portTarget
+
+func (s portTargetSlice) Len() int {
+	return len(s)
+}
+
+func (s portTargetSlice) Less(i, j int) bool {
+	if s[i].minPort != s[j].minPort {
+		return s[i].minPort < s[j].minPort
+	}
+	return s[i].ip < s[j].ip
+}
+
+func (s portTargetSlice) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
 // There certain exceptions, as certain ports will never be deleted.
 func updatePorts(odb ovsdb.Client, containers []db.Container) {
 	// An Open vSwitch patch port is referred to as a "port".
@@ -905,11 +958,15 @@ func generateTargetOpenFlow(dk docker.Client, odb ovsdb.Client,
 		}
 	}
 
+	labelMap := map[string]db.Label{}
+	for _, label := range labels {
+		labelMap[label.Label] = label
+	}
+
 	var rules []string
 	for _, dbc := range containers {
 		_, vethOut := veths(dbc.DockerID)
 		_, peerQuilt := patchPorts(dbc.DockerID)
-		dbcMac := dbc.Mac
 
 		ofQuilt, ok := ifaceMap[peerQuilt]
 		if !ok {
@@ -925,97 +982,8 @@ func generateTargetOpenFlow(dk docker.Client, odb ovsdb.Client,
 			continue
 		}
 
-		rules = append(rules, []string{
-			fmt.Sprintf("table=0 priority=%d,in_port=%d "+
-				"actions=output:%d", 5000, ofQuilt, ofVeth),
-			fmt.Sprintf("table=2 priority=%d,in_port=%d "+
-				"actions=output:%d", 5000, ofVeth, ofQuilt),
-			fmt.Sprintf("table=0 priority=%d,in_port=%d "+
-				"actions=output:%d", 0, ofVeth, ofQuilt),
-		}...)
-
-		protocols := []string{"tcp", "udp"}
-
-		portsToWeb := make(map[int]struct{})
-		portsFromWeb := make(map[int]struct{})
-		for _, l := range dbc.Labels {
-			for _, conn := range connections {
-				if conn.From == l &&
-					conn.To == stitch.PublicInternetLabel {
-					portsToWeb[conn.MinPort] = struct{}{}
-				} else if conn.From ==
-					stitch.PublicInternetLabel && conn.To == l {
-					portsFromWeb[conn.MinPort] = struct{}{}
-				}
-			}
-		}
-
-		// LOCAL is the default quilt-int port created with the bridge.
-		egressRule := fmt.Sprintf("table=0 priority=%d,in_port=%d,",
-			5000, ofVeth) +
-			"%s,%s," + fmt.Sprintf("dl_dst=%s actions=LOCAL", dflGatewayMAC)
-		ingressRule := fmt.Sprintf("table=0 priority=%d,in_port=LOCAL,", 5000) +
-			"%s,%s," + fmt.Sprintf("dl_dst=%s actions=%d", dbcMac, ofVeth)
-
-		for port := range portsFromWeb {
-			for _, protocol := range protocols {
-				egressPort := fmt.Sprintf("tp_src=%d", port)
-				rules = append(rules, fmt.Sprintf(egressRule, protocol,
-					egressPort))
-
-				ingressPort := fmt.Sprintf("tp_dst=%d", port)
-				rules = append(rules, fmt.Sprintf(ingressRule, protocol,
-					ingressPort))
-			}
-		}
-
-		for port := range portsToWeb {
-			for _, protocol := range protocols {
-				egressPort := fmt.Sprintf("tp_dst=%d", port)
-				rules = append(rules, fmt.Sprintf(egressRule, protocol,
-					egressPort))
-
-				ingressPort := fmt.Sprintf("tp_src=%d", port)
-				rules = append(rules, fmt.Sprintf(ingressRule, protocol,
-					ingressPort))
-			}
-		}
-
-		var arpDst string
-		if len(portsToWeb) > 0 || len(portsFromWeb) > 0 {
-			// Allow ICMP
-			rules = append(rules,
-				fmt.Sprintf(
-					"table=0 priority=%d,icmp,in_port=%d,dl_dst=%s"+
-						" actions=LOCAL",
-					5000, ofVeth, dflGatewayMAC))
-			rules = append(rules,
-				fmt.Sprintf(
-					"table=0 priority=%d,icmp,in_port=LOCAL,"+
-						"dl_dst=%s actions=output:%d",
-					5000, dbcMac, ofVeth))
-
-			arpDst = fmt.Sprintf("%d,LOCAL", ofQuilt)
-		} else {
-			arpDst = fmt.Sprintf("%d", ofQuilt)
-		}
-
-		if len(portsFromWeb) > 0 {
-			// Allow default gateway to ARP for containers
-			rules = append(rules, fmt.Sprintf(
-				"table=0 priority=%d,arp,in_port=LOCAL,"+
-					"dl_dst=ff:ff:ff:ff:ff:ff actions=output:%d",
-				4500, ofVeth))
-		}
-
-		rules = append(rules, fmt.Sprintf(
-			"table=0 priority=%d,arp,in_port=%d "+
-				"actions=output:%s",
-			4500, ofVeth, arpDst))
-		rules = append(rules, fmt.Sprintf(
-			"table=0 priority=%d,arp,in_port=LOCAL,"+
-				"dl_dst=%s actions=output:%d",
-			4500, dbcMac, ofVeth))
+		rules = append(rules, containerFlows(dbc, ofQuilt, ofVeth,
+			dflGatewayMAC, labelMap, connections)...)
 	}
 
 	LabelMacs := make(map[string]map[string]struct{})
@@ -1092,166 +1060,234 @@ func generateTargetOpenFlow(dk docker.Client, odb ovsdb.Client,
 	return targetRules, nil
 }
 
-// updateNameservers assigns each container the same nameservers as the host.
-func updateNameservers(dk docker.Client, containers []db.Container) {
-	hostResolv, err := ioutil.ReadFile("/etc/resolv.conf")
-	if err != nil {
-		log.WithError(err).Error("failed to read /etc/resolv.conf")
-	}
-
-	nsRE := regexp.MustCompile("nameserver\\s([0-9]{1,3}\\.){3}[0-9]{1,3}\\s+")
-	matches := nsRE.FindAllString(string(hostResolv), -1)
-	newNameservers := strings.Join(matches, "\n")
-
-	containerChan := make(chan db.Container)
-	var wg sync.WaitGroup
-	wg.Add(concurrencyLimit)
-	for i := 0; i < concurrencyLimit; i++ {
-		go func() {
-			updateNS(dk, newNameservers, containerChan)
-			wg.Done()
-		}()
-	}
+// containerFlows returns the OpenFlow rules that connect `dbc` to the logical network
+// and the default gateway.  IP traffic sent by the container is dropped unless
+// `connections` permit it.
+func containerFlows(dbc db.Container, ofQuilt, ofVeth int, gatewayMAC string,
+	labels map[string]db.Label, connections []db.Connection) []string {
+
+	// IP traffic to the container is committed to conntrack, so that the
+	// container's replies can be told apart from connections it starts.
+	rules := []string{
+		fmt.Sprintf("table=0 priority=%d,in_port=%d "+
+			"actions=output:%d", 5000, ofQuilt, ofVeth),
+		fmt.Sprintf("table=0 priority=%d,ip,in_port=%d "+
+			"actions=ct(commit,zone=%d),output:%d", 5050, ofQuilt, ctZone,
+			ofVeth),
+		fmt.Sprintf("table=0 priority=%d,in_port=%d "+
+			"actions=output:%d", 0, ofVeth, ofQuilt),
+		fmt.Sprintf("table=0 priority=%d,ip,in_port=%d actions=drop",
+			100, ofVeth),
+		fmt.Sprintf("table=0 priority=%d,ipv6,in_port=%d actions=drop",
+			100, ofVeth),
+
+		// The gateway only forwards traffic to the public internet, so
+		// containers may not use it to reach the rest of the subnet.
+		fmt.Sprintf("table=0 priority=%d,ip,in_port=%d,dl_dst=%s,"+
+			"nw_dst=%s actions=drop", 5100, ofVeth, gatewayMAC, quiltSubnet),
+		fmt.Sprintf("table=0 priority=%d,ip,in_port=LOCAL,dl_dst=%s,"+
+			"nw_src=%s actions=drop", 5100, dbc.Mac, quiltSubnet),
+
+		// Allow ARP with the gateway.
+		fmt.Sprintf("table=0 priority=%d,arp,in_port=%d "+
+			"actions=output:%d,LOCAL", 4500, ofVeth, ofQuilt),
+		fmt.Sprintf("table=0 priority=%d,arp,in_port=LOCAL,"+
+			"dl_dst=ff:ff:ff:ff:ff:ff actions=output:%d", 4500, ofVeth),
+		fmt.Sprintf("table=0 priority=%d,arp,in_port=LOCAL,"+
+			"dl_dst=%s actions=output:%d", 4500, dbc.Mac, ofVeth),
+	}
+
+	// The gateway itself answers DNS queries, and probes the container's health.
+	egressGW := fmt.Sprintf("table=0 priority=%d,%%s,in_port=%d,dl_dst=%s,"+
+		"nw_dst=%s,%%s actions=LOCAL", 5200, ofVeth, gatewayMAC, GatewayIP)
+	ingressGW := fmt.Sprintf("table=0 priority=%d,%%s,in_port=LOCAL,dl_dst=%s,"+
+		"nw_src=%s,%%s actions=output:%d", 5200, dbc.Mac, GatewayIP, ofVeth)
+	for _, protocol := range []string{"tcp", "udp"} {
+		rules = append(rules,
+			fmt.Sprintf(egressGW, protocol, "tp_dst=53"),
+			fmt.Sprintf(ingressGW, protocol, "tp_src=53"))
+	}
+
+	for _, port := range []int{dbc.HealthCheck.TCP, dbc.HealthCheck.HTTP} {
+		if port == 0 {
+			continue
+		}
 
-	for _, dbc := range containers {
-		containerChan <- dbc
-	}
-	close(containerChan)
-	wg.Wait()
+		// The container may only answer probes, so the probes are committed to
+		// conntrack, and only replies to them are sent back to the gateway.
+		probe := fmt.Sprintf("table=0 priority=%d,tcp,in_port=LOCAL,"+
+			"dl_dst=%s,nw_src=%s,tp_dst=%d actions=ct(commit,zone=%d),"+
+			"output:%d", 5200, dbc.Mac, GatewayIP, port, ctZone, ofVeth)
+		reply := fmt.Sprintf("tcp,in_port=%d,dl_dst=%s,nw_dst=%s,tp_src=%d",
+			ofVeth, gatewayMAC, GatewayIP, port)
+		rules = append(rules, probe,
+			fmt.Sprintf("table=0 priority=%d,%s actions=ct(table=3,zone=%d)",
+				5200, reply, ctZone),
+			fmt.Sprintf("table=3 priority=%d,ct_state=+est+rpl+trk,%s "+
+				"actions=LOCAL", 5200, reply))
+	}
+
+	rules = append(rules, publicFlows(dbc, ofVeth, gatewayMAC, connections)...)
+	rules = append(rules, aclFlows(dbc, ofQuilt, ofVeth, labels, connections)...)
+	return rules
 }
 
-func updateNS(dk docker.Client, newNameservers string, in chan db.Container) {
-	for dbc := range in {
-		id := dbc.DockerID
-		currNameservers, err := dk.GetFromContainer(id,
-			"/etc/resolv.conf")
-		if err != nil {
-			log.WithError(err).Error("failed to get " +
-				"/etc/resolv.conf")
-			continue
+// publicFlows returns the OpenFlow rules that allow `dbc` to communicate with the
+// public internet through the default gateway, as permitted by `connections`.
+func publicFlows(dbc db.Container, ofVeth int, gatewayMAC string,
+	connections []db.Connection) []string {
+
+	// LOCAL is the default quilt-int port created with the bridge.
+	egressRule := fmt.Sprintf("table=0 priority=%d,in_port=%d,", 5000, ofVeth) +
+		"%s," + fmt.Sprintf("dl_dst=%s actions=LOCAL", gatewayMAC)
+	ingressRule := fmt.Sprintf("table=0 priority=%d,in_port=LOCAL,", 5000) +
+		"%s," + fmt.Sprintf("dl_dst=%s actions=output:%d", dbc.Mac, ofVeth)
+
+	rules := map[string]struct{}{}
+	allow := func(egressField, ingressField string, conn db.Connection) {
+		for _, match := range l4Matches(conn.Protocol, egressField,
+			conn.MinPort, conn.MaxPort) {
+			rules[fmt.Sprintf(egressRule, match)] = struct{}{}
+		}
+
+		for _, match := range l4Matches(conn.Protocol, ingressField,
+			conn.MinPort, conn.MaxPort) {
+			rules[fmt.Sprintf(ingressRule, match)] = struct{}{}
 		}
+	}
 
-		if newNameservers != currNameservers {
-			err = dk.WriteToContainer(id, newNameservers, "/etc",
-				"resolv.conf", 0644)
-			if err != nil {
-				log.WithError(err).Error(
-					"failed to update /etc/resolv.conf")
+	for _, l := range dbc.Labels {
+		for _, conn := range connections {
+			if conn.From == l && conn.To == stitch.PublicInternetLabel {
+				allow("tp_dst", "tp_src", conn)
+			} else if conn.From == stitch.PublicInternetLabel &&
+				conn.To == l {
+				allow("tp_src", "tp_dst", conn)
 			}
 		}
 	}
-}
 
-func updateEtcHosts(dk docker.Client, containers []db.Container, labels []db.Label,
-	connections []db.Connection) {
+	var res []string
+	for rule := range rules {
+		res = append(res, rule)
+	}
+	sort.Strings(res)
+	return res
+}
 
-	/* Map label name to the label itself. */
-	labelMap := make(map[string]db.Label)
+// aclFlows returns the OpenFlow rules that allow `dbc` to send the traffic that
+// `connections` permit between containers: traffic to the labels it connects to, and
+// replies to the labels that connect to it.  Traffic to a label's load balanced IP is
+// checked in table 2, once table 1 has chosen the container that receives it.
+// Replies are checked against conntrack in table 3, so that `dbc` can't start
+// connections of its own to the labels that connect to it.
+func aclFlows(dbc db.Container, ofQuilt, ofVeth int, labels map[string]db.Label,
+	connections []db.Connection) []string {
+
+	rules := map[string]struct{}{}
+	allow := func(peer db.Label, matches []string) {
+		for _, match := range matches {
+			ips := peer.ContainerIPs
+			if !peer.MultiHost && peer.IP != "" {
+				ips = append([]string{peer.IP}, ips...)
+			}
 
-	/* Map label to a list of all labels it connect to. */
-	conns := make(map[string][]string)
+			for _, ip := range ips {
+				rule := fmt.Sprintf("table=0 priority=%d,%s,in_port=%d,"+
+					"nw_dst=%s actions=output:%d",
+					1000, match, ofVeth, ip, ofQuilt)
+				rules[rule] = struct{}{}
+			}
 
-	for _, l := range labels {
-		labelMap[l.Label] = l
+			if peer.MultiHost && peer.IP != "" {
+				rule := fmt.Sprintf("table=2 priority=%d,%s,in_port=%d,"+
+					"nw_dst=%s actions=output:%d",
+					5000, match, ofVeth, peer.IP, ofQuilt)
+				rules[rule] = struct{}{}
+			}
+		}
 	}
 
-	for _, conn := range connections {
-		if conn.To == stitch.PublicInternetLabel ||
-			conn.From == stitch.PublicInternetLabel {
-			continue
-		}
-		conns[conn.From] = append(conns[conn.From], conn.To)
+	allowReplies := func(ip string) {
+		match := fmt.Sprintf("ip,in_port=%d,nw_dst=%s", ofVeth, ip)
+		rules[fmt.Sprintf("table=0 priority=%d,%s actions=ct(table=3,zone=%d)",
+			900, match, ctZone)] = struct{}{}
+		rules[fmt.Sprintf("table=3 priority=%d,ct_state=+est+rpl+trk,%s "+
+			"actions=output:%d", 1000, match, ofQuilt)] = struct{}{}
 	}
 
-	containerChannel := make(chan db.Container)
-	updateHosts := func(in chan db.Container) {
-		for dbc := range in {
-			id := dbc.DockerID
-			currHosts, err := dk.GetFromContainer(id, "/etc/hosts")
-			if err != nil {
-				log.WithError(err).Error("Failed to get /etc/hosts")
+	for _, l := range dbc.Labels {
+		for _, conn := range connections {
+			if conn.From == stitch.PublicInternetLabel ||
+				conn.To == stitch.PublicInternetLabel {
 				continue
 			}
 
-			newHosts := generateEtcHosts(dbc, labelMap, conns)
+			if conn.From == l {
+				allow(labels[conn.To], l4Matches(conn.Protocol,
+					"tp_dst", conn.MinPort, conn.MaxPort))
+			}
 
-			if newHosts != currHosts {
-				err = dk.WriteToContainer(id, newHosts, "/etc",
-					"hosts", 0644)
-				if err != nil {
-					log.WithError(err).Error("Failed to update " +
-						"/etc/hosts")
+			if conn.To == l {
+				// Replies are sent to the peer's containers, never
+				// to its label's IP.
+				for _, ip := range labels[conn.From].ContainerIPs {
+					allowReplies(ip)
 				}
 			}
 		}
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(concurrencyLimit)
-	for i := 0; i < concurrencyLimit; i++ {
-		go func() {
-			updateHosts(containerChannel)
-			wg.Done()
-		}()
-	}
-
-	for _, dbc := range containers {
-		containerChannel <- dbc
+	var res []string
+	for rule := range rules {
+		res = append(res, rule)
 	}
-	close(containerChannel)
-	wg.Wait()
+	sort.Strings(res)
+	return res
 }
 
-func generateEtcHosts(dbc db.Container, labels map[string]db.Label,
-	conns map[string][]string) string {
-
-	type entry struct {
-		ip, host string
-	}
-
-	localhosts := []entry{
-		{"127.0.0.1", "localhost"},
-		{"::1", "localhost ip6-localhost ip6-loopback"},
-		{"fe00::0", "ip6-localnet"},
-		{"ff00::0", "ip6-mcastprefix"},
-		{"ff02::1", "ip6-allnodes"},
-		{"ff02::2", "ip6-allrouters"},
-	}
-
-	if dbc.IP != "" && dbc.DockerID != "" {
-		entry := entry{dbc.IP, util.ShortUUID(dbc.DockerID)}
-		localhosts = append(localhosts, entry)
+// l4Matches returns OpenFlow matches for the traffic allowed by a connection with
+// `protocol`: TCP and UDP traffic with `portField` between `minPort` and `maxPort`,
+// and ICMP traffic.  Port ranges are split into masked matches, formatted as by
+// `ovs-ofctl dump-flows`.
+func l4Matches(protocol, portField string, minPort, maxPort int) []string {
+	var matches []string
+	if allowsICMP(protocol) {
+		matches = append(matches, "icmp")
 	}
 
-	newHosts := make(map[entry]struct{})
-	for _, entry := range localhosts {
-		newHosts[entry] = struct{}{}
+	protocols := transportProtocols(protocol)
+	if minPort <= 0 && maxPort >= 65535 {
+		if protocol == "" {
+			return []string{"ip"}
+		}
+		return append(matches, protocols...)
 	}
 
-	for _, l := range dbc.Labels {
-		for _, toLabel := range conns[l] {
-			if toLabel == stitch.PublicInternetLabel {
-				continue
-			}
+	var portMatches []string
+	for port := minPort; port <= maxPort; {
+		// Find the largest aligned block of ports that starts at `port`.
+		size := 1
+		for port%(size*2) == 0 && port+size*2-1 <= maxPort {
+			size *= 2
+		}
 
-			if ip := labels[toLabel].IP; ip != "" {
-				newHosts[entry{ip, toLabel + ".q"}] = struct{}{}
-			}
-			for i, cIP := range labels[toLabel].ContainerIPs {
-				// The hostname prefix starts from 1 for readability.
-				host := fmt.Sprintf("%d.%s.q", i+1, toLabel)
-				newHosts[entry{cIP, host}] = struct{}{}
-			}
+		if size == 1 {
+			portMatches = append(portMatches,
+				fmt.Sprintf("%s=%d", portField, port))
+		} else {
+			portMatches = append(portMatches, fmt.Sprintf("%s=0x%x/0x%x",
+				portField, port, 0xffff&^(size-1)))
 		}
+		port += size
 	}
 
-	var hosts []string
-	for h := range newHosts {
-		hosts = append(hosts, fmt.Sprintf("%-15s %s", h.ip, h.host))
+	for _, protocol := range protocols {
+		for _, portMatch := range portMatches {
+			matches = append(matches, protocol+","+portMatch)
+		}
 	}
-
-	sort.Strings(hosts)
-	return strings.Join(hosts, "\n") + "\n"
+	return matches
 }
 
 func namespaceExists(namespace string) (bool, error) {
@@ -1,111 +1,16 @@
 package network
 
 import (
+	"net"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/stitch"
 )
 
-func TestNoConnections(t *testing.T) {
-	labels, connections := defaultLabelsConnections()
-	dbc := db.Container{
-		ID:       1,
-		DockerID: "abcdefghijklmnopqrstuvwxyz",
-		IP:       "1.1.1.1",
-		Labels:   []string{"green"},
-	}
-
-	actual := generateEtcHosts(dbc, labels, connections)
-	exp := "1.1.1.1         abcdefghijkl" + localhosts()
-
-	if exp != actual {
-		t.Errorf("Generated wrong basic /etc/hosts."+
-			"\nExpected:\n%s\n\nGot:\n%s\n", exp, actual)
-	}
-}
-
-func TestImplementsSingleLabel(t *testing.T) {
-	labels, connections := defaultLabelsConnections()
-	dbc := db.Container{
-		ID:       2,
-		DockerID: "abcdefghijklmnopqrstuvwxyz",
-		IP:       "1.2.2.2",
-		Labels:   []string{"red"},
-	}
-
-	actual := generateEtcHosts(dbc, labels, connections)
-	exp := `1.1.1.1         1.green.q
-1.2.2.2         abcdefghijkl
-1.3.3.3         1.blue.q
-1.4.4.4         2.blue.q
-10.0.0.2        blue.q
-10.0.0.3        green.q` + localhosts()
-
-	if exp != actual {
-		t.Errorf("Generated wrong single label /etc/hosts."+
-			"\nExpected:\n%s\n\nGot:\n%s\n", exp, actual)
-	}
-}
-
-func TestImplementsMultipleLabels(t *testing.T) {
-	labels, connections := defaultLabelsConnections()
-	dbc := db.Container{
-		ID:       3,
-		DockerID: "abcdefghijklmnopqrstuvwxyz",
-		IP:       "1.3.3.3",
-		Labels:   []string{"red", "blue"},
-	}
-
-	actual := generateEtcHosts(dbc, labels, connections)
-	exp := `1.1.1.1         1.green.q
-1.2.2.2         1.red.q
-1.3.3.3         1.blue.q
-1.3.3.3         2.red.q
-1.3.3.3         abcdefghijkl
-1.4.4.4         2.blue.q
-1.4.4.4         3.red.q
-10.0.0.1        red.q
-10.0.0.2        blue.q
-10.0.0.3        green.q` + localhosts()
-
-	if exp != actual {
-		t.Errorf("Generated wrong multi-label /etc/hosts"+
-			"\nExpected:\n%s\n\nGot:\n%s\n", exp, actual)
-	}
-}
-
-// Both red and blue connect to green. Make sure that green.q only appears once in
-// /etc/hosts.
-func TestDuplicateConnections(t *testing.T) {
-	labels, connections := defaultLabelsConnections()
-	dbc := db.Container{
-		ID:       4,
-		DockerID: "abcdefghijklmnopqrstuvwxyz",
-		IP:       "1.4.4.4",
-		Labels:   []string{"red", "blue"},
-	}
-
-	connections["blue"] = append(connections["blue"], "green")
-
-	actual := generateEtcHosts(dbc, labels, connections)
-	exp := `1.1.1.1         1.green.q
-1.2.2.2         1.red.q
-1.3.3.3         1.blue.q
-1.3.3.3         2.red.q
-1.4.4.4         2.blue.q
-1.4.4.4         3.red.q
-1.4.4.4         abcdefghijkl
-10.0.0.1        red.q
-10.0.0.2        blue.q
-10.0.0.3        green.q` + localhosts()
-
-	if exp != actual {
-		t.Errorf("Generated wrong /etc/hosts for duplicate connections."+
-			"\nExpected:\n%s\n\nGot:\n%s\n", exp, actual)
-	}
-}
-
 func TestMakeIPRule(t *testing.T) {
 	inp := "-A INPUT -p tcp -i eth0 -m multiport --dports 465,110,995 -j ACCEPT"
 	rule, _ := makeIPRule(inp)
@@ -320,51 +225,361 @@ func TestMakeOFRule(t *testing.T) {
 	}
 }
 
-func defaultLabelsConnections() (map[string]db.Label, map[string][]string) {
+func routes() string {
+	return `default via 10.0.2.2 dev eth0
+	10.0.2.0/24 dev eth0  proto kernel  scope link  src 10.0.2.15
+	192.168.162.0/24 dev eth1  proto kernel  scope link  src 192.168.162.162`
+}
+
+func rules() string {
+	return `-P POSTROUTING ACCEPT
+-N DOCKER
+-A POSTROUTING -s 11.0.0.0/8,10.0.0.0/8 -o eth0 -j MASQUERADE
+-A POSTROUTING -s 10.0.3.0/24 ! -d 10.0.3.0/24 -j MASQUERADE`
+}
 
+func TestContainerFlows(t *testing.T) {
 	labels := map[string]db.Label{
-		"red": {
-			IP:           "10.0.0.1",
-			ContainerIPs: []string{"1.2.2.2", "1.3.3.3", "1.4.4.4"},
+		"web": {
+			Label:        "web",
+			IP:           "10.0.0.10",
+			MultiHost:    true,
+			ContainerIPs: []string{"10.1.0.1", "10.1.0.2"},
 		},
-		"blue": {
-			IP:           "10.0.0.2",
-			ContainerIPs: []string{"1.3.3.3", "1.4.4.4"},
+		"db": {
+			Label:        "db",
+			IP:           "10.0.0.20",
+			ContainerIPs: []string{"10.1.0.3"},
 		},
-		"green": {
-			IP:           "10.0.0.3",
-			ContainerIPs: []string{"1.1.1.1"},
+		"other": {
+			Label:        "other",
+			IP:           "10.0.0.30",
+			ContainerIPs: []string{"10.1.0.4"},
 		},
 	}
 
-	connections := map[string][]string{
-		"red":  {"blue", "green"},
-		"blue": {"red"},
+	connections := []db.Connection{
+		{From: "web", To: "db", MinPort: 5432, MaxPort: 5432},
+		{From: "other", To: "web", MinPort: 8000, MaxPort: 8100},
+		{From: "web", To: stitch.PublicInternetLabel, MinPort: 443, MaxPort: 443},
+		{From: stitch.PublicInternetLabel, To: "web", MinPort: 80, MaxPort: 80},
+		{From: "web", To: "other", MinPort: 53, MaxPort: 53, Protocol: "udp"},
+		{From: "web", To: stitch.PublicInternetLabel, MinPort: 53, MaxPort: 53,
+			Protocol: "udp"},
+		{From: stitch.PublicInternetLabel, To: "web", MinPort: 30000,
+			MaxPort: 30100, Protocol: "udp"},
+	}
+
+	web := db.Container{IP: "10.1.0.1", Mac: "00:00:00:00:00:01",
+		Labels: []string{"web"}, HealthCheck: db.HealthCheck{HTTP: 8080}}
+	webFlows := containerFlows(web, 1, 2, "00:00:00:00:00:ff", labels,
+		connections)
+
+	other := db.Container{IP: "10.1.0.4", Mac: "00:00:00:00:00:04",
+		Labels: []string{"other"}}
+	otherFlows := containerFlows(other, 3, 4, "00:00:00:00:00:ff", labels,
+		connections)
+
+	test := func(flows []string, table string, pkt ofPacket, allowed bool) {
+		actions := ofActions(t, flows, table, pkt)
+		if actions == "ct(table=3,zone=1)" {
+			actions = ofActions(t, flows, "3", pkt)
+		}
+		if allowed && actions == "drop" {
+			t.Errorf("Expected %+v to be allowed, but it was dropped", pkt)
+		} else if !allowed && actions != "drop" {
+			t.Errorf("Expected %+v to be dropped, but got actions %s",
+				pkt, actions)
+		}
 	}
 
-	return labels, connections
+	toPeer := func(proto, ip string, tpSrc, tpDst int) ofPacket {
+		return ofPacket{inPort: "2", dlDst: "00:00:00:00:00:03", proto: proto,
+			nwSrc: web.IP, nwDst: ip, tpSrc: tpSrc, tpDst: tpDst}
+	}
+
+	// Traffic to the labels web connects to.
+	test(webFlows, "0", toPeer("tcp", "10.1.0.3", 40000, 5432), true)
+	test(webFlows, "0", toPeer("udp", "10.0.0.20", 40000, 5432), true)
+	test(webFlows, "0", toPeer("icmp", "10.1.0.3", 0, 0), true)
+	test(webFlows, "0", toPeer("tcp", "10.1.0.3", 40000, 22), false)
+
+	// Replies to labels that connect to web, but nothing else.
+	reply := func(pkt ofPacket) ofPacket {
+		pkt.reply = true
+		return pkt
+	}
+	test(webFlows, "0", reply(toPeer("tcp", "10.1.0.4", 8050, 40000)), true)
+	test(webFlows, "0", reply(toPeer("icmp", "10.1.0.4", 0, 0)), true)
+	test(webFlows, "0", reply(toPeer("tcp", "10.1.0.2", 8050, 40000)), false)
+
+	// Web can't start connections to the labels that connect to it, even from the
+	// ports they connect to.
+	test(webFlows, "0", toPeer("tcp", "10.1.0.4", 8050, 40000), false)
+	test(webFlows, "0", toPeer("tcp", "10.1.0.4", 40000, 8050), false)
+	test(webFlows, "0", toPeer("icmp", "10.1.0.4", 0, 0), false)
+	test(webFlows, "0", toPeer("icmp", "10.1.0.2", 0, 0), false)
+
+	// Traffic to web is committed to conntrack, so that its replies are known.
+	fromPeer := ofPacket{inPort: "1", proto: "tcp", nwSrc: other.IP,
+		nwDst: web.IP, tpSrc: 40000, tpDst: 8050}
+	if actions := ofActions(t, webFlows, "0", fromPeer); actions !=
+		"ct(commit,zone=1),output:2" {
+		t.Errorf("Traffic to web got actions %s", actions)
+	}
+	if actions := ofActions(t, webFlows, "0", ofPacket{inPort: "1",
+		proto: "arp"}); actions != "output:2" {
+		t.Errorf("ARP to web got actions %s", actions)
+	}
+
+	// Connections restricted to a protocol don't allow the others.
+	test(webFlows, "0", toPeer("udp", "10.1.0.4", 40000, 53), true)
+	test(webFlows, "0", toPeer("tcp", "10.1.0.4", 40000, 53), false)
+
+	// Other connects to web's load balanced IP, which is checked once table 1 has
+	// picked a container.
+	toWeb := ofPacket{inPort: "4", dlDst: "00:00:00:00:00:01", proto: "tcp",
+		nwSrc: other.IP, nwDst: "10.0.0.10", tpSrc: 40000, tpDst: 8000}
+	test(otherFlows, "2", toWeb, true)
+	toWeb.tpDst = 8101
+	test(otherFlows, "2", toWeb, false)
+	test(webFlows, "2", toPeer("tcp", "10.0.0.10", 40000, 8000), false)
+
+	// The public internet, which web may only reach on the permitted ports.
+	toGW := func(proto, ip string, tpSrc, tpDst int) ofPacket {
+		return ofPacket{inPort: "2", dlDst: "00:00:00:00:00:ff", proto: proto,
+			nwSrc: web.IP, nwDst: ip, tpSrc: tpSrc, tpDst: tpDst}
+	}
+	test(webFlows, "0", toGW("tcp", "8.8.8.8", 40000, 443), true)
+	test(webFlows, "0", toGW("tcp", "8.8.8.8", 80, 40000), true)
+	test(webFlows, "0", toGW("tcp", "8.8.8.8", 40000, 22), false)
+	test(otherFlows, "0", toGW("tcp", "8.8.8.8", 40000, 443), false)
+	test(webFlows, "0", toGW("udp", "8.8.8.8", 40000, 53), true)
+	test(webFlows, "0", toGW("tcp", "8.8.8.8", 40000, 53), false)
+
+	// The gateway can't be used to bypass the ACLs.
+	test(webFlows, "0", toGW("tcp", "10.1.0.4", 40000, 443), false)
+	test(webFlows, "0", toGW("tcp", "10.1.0.4", 80, 40000), false)
+
+	// Except for DNS and health checks, which are served by the gateway itself.
+	// Web may only answer health checks, not use their port to reach the gateway.
+	test(webFlows, "0", toGW("udp", "10.0.0.1", 40000, 53), true)
+	test(webFlows, "0", reply(toGW("tcp", "10.0.0.1", 8080, 40000)), true)
+	test(webFlows, "0", toGW("tcp", "10.0.0.1", 8080, 40000), false)
+	test(webFlows, "0", toGW("tcp", "10.0.0.1", 8080, 22), false)
+	test(webFlows, "0", toGW("tcp", "10.0.0.1", 40000, 22), false)
+
+	fromGW := func(proto, ip string, tpSrc, tpDst int) ofPacket {
+		return ofPacket{inPort: "LOCAL", dlDst: web.Mac, proto: proto,
+			nwSrc: ip, nwDst: web.IP, tpSrc: tpSrc, tpDst: tpDst}
+	}
+	test(webFlows, "0", fromGW("tcp", "8.8.8.8", 40000, 80), true)
+	test(webFlows, "0", fromGW("tcp", "10.1.0.4", 40000, 80), false)
+	test(webFlows, "0", fromGW("udp", "8.8.8.8", 40000, 30050), true)
+	test(webFlows, "0", fromGW("tcp", "8.8.8.8", 40000, 30050), false)
+	test(webFlows, "0", fromGW("udp", "8.8.8.8", 40000, 30101), false)
+	test(webFlows, "0", toGW("udp", "8.8.8.8", 30050, 40000), true)
+	test(webFlows, "0", toGW("udp", "8.8.8.8", 30101, 40000), false)
+	test(webFlows, "0", fromGW("udp", "10.0.0.1", 53, 40000), true)
+	test(webFlows, "0", fromGW("tcp", "10.0.0.1", 40000, 8080), true)
+	if actions := ofActions(t, webFlows, "0", fromGW("tcp", "10.0.0.1", 40000,
+		8080)); actions != "ct(commit,zone=1),output:2" {
+		t.Errorf("Health probe got actions %s", actions)
+	}
+
+	// ARP is never dropped, but IPv6 always is.
+	test(otherFlows, "0", ofPacket{inPort: "4", proto: "arp"}, true)
+	test(otherFlows, "0", ofPacket{inPort: "4", proto: "ipv6"}, false)
 }
 
-func localhosts() string {
-	return `
-127.0.0.1       localhost
-::1             localhost ip6-localhost ip6-loopback
-fe00::0         ip6-localnet
-ff00::0         ip6-mcastprefix
-ff02::1         ip6-allnodes
-ff02::2         ip6-allrouters
-`
+func TestGenerateTargetNatRules(t *testing.T) {
+	containers := []db.Container{
+		{IP: "10.1.0.1", Labels: []string{"web"}},
+		{IP: "10.1.0.2", Labels: []string{"rtp"}},
+	}
+	connections := []db.Connection{
+		{From: stitch.PublicInternetLabel, To: "web", MinPort: 80, MaxPort: 80,
+			Protocol: "tcp"},
+		{From: stitch.PublicInternetLabel, To: "rtp", MinPort: 10000,
+			MaxPort: 10099, Protocol: "udp"},
+		{From: stitch.PublicInternetLabel, To: "rtp", MinPort: 10050,
+			MaxPort: 10199, Protocol: "udp"},
+	}
+
+	exp := map[ipRule]struct{}{}
+	for _, r := range []string{
+		"-P PREROUTING ACCEPT",
+		"-P INPUT ACCEPT",
+		"-P OUTPUT ACCEPT",
+		"-P POSTROUTING ACCEPT",
+		"-A POSTROUTING -s 10.0.0.0/8 -o eth0 -j MASQUERADE",
+		"-A PREROUTING -i eth0 -p tcp -m tcp --dport 80 -j DNAT " +
+			"--to-destination 10.1.0.1:80",
+		"-A PREROUTING -i eth0 -p udp -m udp --dport 10000:10199 -j DNAT " +
+			"--to-destination 10.1.0.2:10000-10199",
+	} {
+		rule, _ := makeIPRule(r)
+		exp[rule] = struct{}{}
+	}
+
+	actual := map[ipRule]struct{}{}
+	for _, rule := range generateTargetNatRules(containers, connections) {
+		actual[rule] = struct{}{}
+	}
+	if !reflect.DeepEqual(actual, exp) {
+		t.Errorf("Generated wrong NAT rules: got %v, expected %v", actual, exp)
+	}
 }
 
-func routes() string {
-	return `default via 10.0.2.2 dev eth0
-	10.0.2.0/24 dev eth0  proto kernel  scope link  src 10.0.2.15
-	192.168.162.0/24 dev eth1  proto kernel  scope link  src 192.168.162.162`
+func TestClaimPorts(t *testing.T) {
+	targets := []portTarget{
+		{"10.1.0.2", 8050, 8200},
+		{"10.1.0.1", 8000, 8100},
+		{"10.1.0.1", 8101, 8101},
+		{"10.1.0.1", 8300, 8399},
+		{"10.1.0.1", 8400, 8499},
+		{"10.1.0.3", 8060, 8070},
+		{"10.1.0.3", 9000, 9000},
+	}
+
+	exp := []portTarget{
+		{"10.1.0.1", 8000, 8100},
+		{"10.1.0.2", 8101, 8200},
+		{"10.1.0.1", 8300, 8499},
+		{"10.1.0.3", 9000, 9000},
+	}
+	if res := claimPorts(targets); !reflect.DeepEqual(res, exp) {
+		t.Errorf("Got %v, expected %v", res, exp)
+	}
 }
 
-func rules() string {
-	return `-P POSTROUTING ACCEPT
--N DOCKER
--A POSTROUTING -s 11.0.0.0/8,10.0.0.0/8 -o eth0 -j MASQUERADE
--A POSTROUTING -s 10.0.3.0/24 ! -d 10.0.3.0/24 -j MASQUERADE`
+func TestL4Matches(t *testing.T) {
+	matches := l4Matches("", "tp_dst", 8000, 8100)
+	for port := 7900; port < 8200; port++ {
+		pkt := ofPacket{proto: "tcp", tpDst: port}
+		matched := false
+		for _, match := range matches {
+			if ofMatches(t, match, pkt) {
+				matched = true
+			}
+		}
+
+		if exp := port >= 8000 && port <= 8100; matched != exp {
+			t.Errorf("Port %d matched: %t, expected %t", port, matched, exp)
+		}
+	}
+
+	if res := l4Matches("", "tp_dst", 0, 65535); !reflect.DeepEqual(res,
+		[]string{"ip"}) {
+		t.Errorf("Full port range should match all IP traffic, got %v", res)
+	}
+
+	test := func(protocol string, minPort, maxPort int, exp []string) {
+		res := l4Matches(protocol, "tp_src", minPort, maxPort)
+		if !reflect.DeepEqual(res, exp) {
+			t.Errorf("%q %d-%d: got %v, expected %v", protocol, minPort,
+				maxPort, res, exp)
+		}
+	}
+
+	test("", 80, 80, []string{"icmp", "udp,tp_src=80", "tcp,tp_src=80"})
+	test("tcp", 80, 80, []string{"tcp,tp_src=80"})
+	test("udp", 0, 65535, []string{"udp"})
+	test("icmp", 0, 65535, []string{"icmp"})
+}
+
+// ofPacket describes the fields of a packet that OpenFlow rules match on.
+type ofPacket struct {
+	inPort       string
+	dlDst        string
+	proto        string
+	nwSrc, nwDst string
+	tpSrc, tpDst int
+
+	// Whether conntrack knows the packet as a reply to a connection the
+	// destination started.
+	reply bool
+}
+
+// ofActions returns the actions of the highest priority rule in `table` that matches
+// `pkt`, or "drop" if none do.
+func ofActions(t *testing.T, flows []string, table string, pkt ofPacket) string {
+	actions, bestPriority := "drop", -1
+	for _, flow := range flows {
+		rule, err := makeOFRule(flow)
+		if err != nil {
+			t.Fatalf("Bad rule %s: %s", flow, err)
+		}
+
+		if rule.table != "table="+table || !ofMatches(t, rule.match, pkt) {
+			continue
+		}
+
+		priority := 0
+		for _, field := range strings.Split(rule.match, ",") {
+			if strings.HasPrefix(field, "priority=") {
+				priority, _ = strconv.Atoi(strings.TrimPrefix(field,
+					"priority="))
+			}
+		}
+
+		if priority > bestPriority {
+			actions, bestPriority = rule.actions, priority
+		}
+	}
+	return actions
+}
+
+func ofMatches(t *testing.T, match string, pkt ofPacket) bool {
+	for _, field := range strings.Split(match, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		var ok bool
+		switch kv[0] {
+		case "priority":
+			ok = true
+		case "ip":
+			ok = pkt.proto != "arp" && pkt.proto != "ipv6"
+		case "tcp", "udp", "icmp", "arp", "ipv6":
+			ok = pkt.proto == kv[0]
+		case "in_port":
+			ok = pkt.inPort == kv[1]
+		case "dl_dst":
+			ok = pkt.dlDst == kv[1]
+		case "nw_src":
+			ok = ipMatches(kv[1], pkt.nwSrc)
+		case "nw_dst":
+			ok = ipMatches(kv[1], pkt.nwDst)
+		case "tp_src":
+			ok = portMatches(kv[1], pkt.tpSrc)
+		case "tp_dst":
+			ok = portMatches(kv[1], pkt.tpDst)
+		case "ct_state":
+			ok = pkt.reply && kv[1] == "+est+rpl+trk"
+		default:
+			t.Fatalf("Unknown match field: %s", field)
+		}
+
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func ipMatches(match, ip string) bool {
+	if _, subnet, err := net.ParseCIDR(match); err == nil {
+		return subnet.Contains(net.ParseIP(ip))
+	}
+	return match == ip
+}
+
+func portMatches(match string, port int) bool {
+	parts := strings.Split(match, "/")
+	if len(parts) == 1 {
+		return match == strconv.Itoa(port)
+	}
+
+	value, _ := strconv.ParseInt(parts[0], 0, 32)
+	mask, _ := strconv.ParseInt(parts[1], 0, 32)
+	return int64(port)&mask == value
 }
@@ -13,6 +13,7 @@ It has these top-level messages:
 	Reply
 	Request
 	EtcdMembers
+	Certificate
 */
 package pb
 
@@ -61,13 +62,16 @@ func (x MinionConfig_Role) String() string {
 func (MinionConfig_Role) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 0} }
 
 type MinionConfig struct {
-	ID        string            `protobuf:"bytes,1,opt,name=ID,json=iD" json:"ID,omitempty"`
-	Role      MinionConfig_Role `protobuf:"varint,2,opt,name=role,enum=MinionConfig_Role" json:"role,omitempty"`
-	PrivateIP string            `protobuf:"bytes,3,opt,name=PrivateIP,json=privateIP" json:"PrivateIP,omitempty"`
-	Spec      string            `protobuf:"bytes,4,opt,name=Spec,json=spec" json:"Spec,omitempty"`
-	Provider  string            `protobuf:"bytes,5,opt,name=Provider,json=provider" json:"Provider,omitempty"`
-	Size      string            `protobuf:"bytes,6,opt,name=Size,json=size" json:"Size,omitempty"`
-	Region    string            `protobuf:"bytes,7,opt,name=Region,json=region" json:"Region,omitempty"`
+	ID            string            `protobuf:"bytes,1,opt,name=ID,json=iD" json:"ID,omitempty"`
+	Role          MinionConfig_Role `protobuf:"varint,2,opt,name=role,enum=MinionConfig_Role" json:"role,omitempty"`
+	PrivateIP     string            `protobuf:"bytes,3,opt,name=PrivateIP,json=privateIP" json:"PrivateIP,omitempty"`
+	Spec          string            `protobuf:"bytes,4,opt,name=Spec,json=spec" json:"Spec,omitempty"`
+	Provider      string            `protobuf:"bytes,5,opt,name=Provider,json=provider" json:"Provider,omitempty"`
+	Size          string            `protobuf:"bytes,6,opt,name=Size,json=size" json:"Size,omitempty"`
+	Region        string            `protobuf:"bytes,7,opt,name=Region,json=region" json:"Region,omitempty"`
+	Secrets       map[string]string `protobuf:"bytes,8,rep,name=Secrets,json=secrets" json:"Secrets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Unschedulable bool              `protobuf:"varint,9,opt,name=Unschedulable,json=unschedulable" json:"Unschedulable,omitempty"`
+	Draining      bool              `protobuf:"varint,10,opt,name=Draining,json=draining" json:"Draining,omitempty"`
 }
 
 func (m *MinionConfig) Reset()                    { *m = MinionConfig{} }
@@ -75,6 +79,13 @@ func (m *MinionConfig) String() string            { return proto.CompactTextStri
 func (*MinionConfig) ProtoMessage()               {}
 func (*MinionConfig) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+func (m *MinionConfig) GetSecrets() map[string]string {
+	if m != nil {
+		return m.Secrets
+	}
+	return nil
+}
+
 type Reply struct {
 	Success bool   `protobuf:"varint,1,opt,name=Success,json=success" json:"Success,omitempty"`
 	Error   string `protobuf:"bytes,2,opt,name=Error,json=error" json:"Error,omitempty"`
@@ -102,11 +113,21 @@ func (m *EtcdMembers) String() string            { return proto.CompactTextStrin
 func (*EtcdMembers) ProtoMessage()               {}
 func (*EtcdMembers) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
+type Certificate struct {
+	Cert string `protobuf:"bytes,1,opt,name=Cert,json=cert" json:"Cert,omitempty"`
+}
+
+func (m *Certificate) Reset()                    { *m = Certificate{} }
+func (m *Certificate) String() string            { return proto.CompactTextString(m) }
+func (*Certificate) ProtoMessage()               {}
+func (*Certificate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
 func init() {
 	proto.RegisterType((*MinionConfig)(nil), "MinionConfig")
 	proto.RegisterType((*Reply)(nil), "Reply")
 	proto.RegisterType((*Request)(nil), "Request")
 	proto.RegisterType((*EtcdMembers)(nil), "EtcdMembers")
+	proto.RegisterType((*Certificate)(nil), "Certificate")
 	proto.RegisterEnum("MinionConfig_Role", MinionConfig_Role_name, MinionConfig_Role_value)
 }
 
@@ -124,6 +145,7 @@ type MinionClient interface {
 	SetMinionConfig(ctx context.Context, in *MinionConfig, opts ...grpc.CallOption) (*Reply, error)
 	GetMinionConfig(ctx context.Context, in *Request, opts ...grpc.CallOption) (*MinionConfig, error)
 	BootEtcd(ctx context.Context, in *EtcdMembers, opts ...grpc.CallOption) (*Reply, error)
+	SetCertificate(ctx context.Context, in *Certificate, opts ...grpc.CallOption) (*Reply, error)
 }
 
 type minionClient struct {
@@ -161,12 +183,22 @@ func (c *minionClient) BootEtcd(ctx context.Context, in *EtcdMembers, opts ...gr
 	return out, nil
 }
 
+func (c *minionClient) SetCertificate(ctx context.Context, in *Certificate, opts ...grpc.CallOption) (*Reply, error) {
+	out := new(Reply)
+	err := grpc.Invoke(ctx, "/Minion/SetCertificate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Minion service
 
 type MinionServer interface {
 	SetMinionConfig(context.Context, *MinionConfig) (*Reply, error)
 	GetMinionConfig(context.Context, *Request) (*MinionConfig, error)
 	BootEtcd(context.Context, *EtcdMembers) (*Reply, error)
+	SetCertificate(context.Context, *Certificate) (*Reply, error)
 }
 
 func RegisterMinionServer(s *grpc.Server, srv MinionServer) {
@@ -227,6 +259,24 @@ func _Minion_BootEtcd_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Minion_SetCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Certificate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinionServer).SetCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Minion/SetCertificate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinionServer).SetCertificate(ctx, req.(*Certificate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Minion_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "Minion",
 	HandlerType: (*MinionServer)(nil),
@@ -243,6 +293,10 @@ var _Minion_serviceDesc = grpc.ServiceDesc{
 			MethodName: "BootEtcd",
 			Handler:    _Minion_BootEtcd_Handler,
 		},
+		{
+			MethodName: "SetCertificate",
+			Handler:    _Minion_SetCertificate_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: fileDescriptor0,
@@ -251,27 +305,35 @@ var _Minion_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("pb/pb.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 344 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x5c, 0x51, 0x5d, 0x4b, 0xeb, 0x40,
-	0x10, 0x6d, 0xbe, 0x93, 0x69, 0x6f, 0x5b, 0x86, 0xcb, 0x65, 0x29, 0x17, 0x94, 0x3c, 0x48, 0x11,
-	0x89, 0x50, 0x1f, 0x7c, 0x56, 0x1b, 0xa4, 0x48, 0xdb, 0xb0, 0x11, 0x7c, 0x36, 0xe9, 0x5a, 0x16,
-	0x6a, 0x37, 0xee, 0xa6, 0x05, 0xfd, 0x01, 0xfe, 0x58, 0x7f, 0x85, 0x9b, 0xb5, 0x62, 0xeb, 0xdb,
-	0x39, 0x67, 0xce, 0xce, 0x9c, 0x99, 0x85, 0x76, 0x55, 0x9c, 0x57, 0x45, 0x52, 0x49, 0x51, 0x8b,
-	0xf8, 0xc3, 0x82, 0xce, 0x94, 0xaf, 0xb9, 0x58, 0xdf, 0x88, 0xf5, 0x13, 0x5f, 0x62, 0x17, 0xec,
-	0xc9, 0x98, 0x58, 0xc7, 0xd6, 0x30, 0xa2, 0x36, 0x1f, 0xe3, 0x09, 0xb8, 0x52, 0xac, 0x18, 0xb1,
-	0xb5, 0xd2, 0x1d, 0x61, 0xb2, 0x6f, 0x4e, 0xa8, 0xae, 0x50, 0x53, 0xc7, 0xff, 0x10, 0x65, 0x92,
-	0x6f, 0x1f, 0x6b, 0x36, 0xc9, 0x88, 0x63, 0x9e, 0x47, 0xd5, 0xb7, 0x80, 0x08, 0x6e, 0x5e, 0xb1,
-	0x92, 0xb8, 0xa6, 0xe0, 0x2a, 0x8d, 0x71, 0x00, 0x61, 0x26, 0xc5, 0x96, 0x2f, 0x98, 0x24, 0x9e,
-	0xd1, 0xc3, 0x6a, 0xc7, 0x8d, 0x9f, 0xbf, 0x31, 0xe2, 0xef, 0xfc, 0x1a, 0xe3, 0x3f, 0xf0, 0x29,
-	0x5b, 0xea, 0xe1, 0x24, 0x30, 0xaa, 0x2f, 0x0d, 0x8b, 0x87, 0xe0, 0x36, 0x39, 0x30, 0x04, 0x77,
-	0x36, 0x9f, 0xa5, 0xfd, 0x16, 0x02, 0xf8, 0x0f, 0x73, 0x7a, 0x97, 0xd2, 0xbe, 0xd5, 0xe0, 0xe9,
-	0x55, 0x7e, 0xaf, 0xb1, 0x1d, 0x5f, 0x82, 0x47, 0x59, 0xb5, 0x7a, 0x45, 0x02, 0x41, 0xbe, 0x29,
-	0x4b, 0xa6, 0x94, 0xd9, 0x34, 0xa4, 0x81, 0xfa, 0xa2, 0xf8, 0x17, 0xbc, 0x54, 0x4a, 0x21, 0xcd,
-	0xbe, 0x11, 0xf5, 0x58, 0x43, 0xe2, 0x08, 0x02, 0xca, 0x5e, 0x36, 0x4c, 0xd5, 0xf1, 0x11, 0xb4,
-	0xd3, 0xba, 0x5c, 0x4c, 0xd9, 0x73, 0xc1, 0xa4, 0xc2, 0x3e, 0x38, 0x93, 0xac, 0xe9, 0xe2, 0x68,
-	0xb7, 0xc3, 0x33, 0x35, 0x7a, 0xb7, 0xf4, 0x44, 0x73, 0x24, 0x3c, 0x85, 0x5e, 0xce, 0xea, 0x83,
-	0xf3, 0xfe, 0x39, 0x38, 0xe0, 0xc0, 0x4f, 0x4c, 0xa0, 0xb8, 0x85, 0x67, 0xd0, 0xbb, 0xfd, 0xe5,
-	0x0d, 0x93, 0xdd, 0xd0, 0xc1, 0xe1, 0x2b, 0xed, 0x8e, 0x21, 0xbc, 0x16, 0xa2, 0x6e, 0x92, 0x60,
-	0x27, 0xd9, 0x0b, 0xf4, 0xd3, 0xb1, 0xf0, 0xcd, 0x0f, 0x5f, 0x7c, 0x06, 0x00, 0x00, 0xff, 0xff,
-	0x89, 0x60, 0x91, 0x75, 0xf0, 0x01, 0x00, 0x00,
+	// 466 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x5c, 0x92, 0x6f, 0x8b, 0xd3, 0x40,
+	0x10, 0xc6, 0x2f, 0x6d, 0x9a, 0x3f, 0xd3, 0x3f, 0x57, 0x16, 0x91, 0xa5, 0x08, 0xd6, 0x20, 0x12,
+	0x44, 0x22, 0x54, 0x41, 0xb9, 0x77, 0x7a, 0x0d, 0x52, 0xa4, 0x77, 0x65, 0xa3, 0xf8, 0xba, 0x4d,
+	0xe7, 0xea, 0x62, 0xcc, 0xc6, 0xdd, 0x4d, 0xa1, 0xf7, 0x61, 0xfc, 0x0c, 0x7e, 0x44, 0xd9, 0x4d,
+	0x0e, 0x53, 0xdf, 0xed, 0x33, 0xf3, 0xec, 0xcc, 0x6f, 0x1f, 0x16, 0x86, 0xd5, 0xee, 0x75, 0xb5,
+	0x4b, 0x2a, 0x29, 0xb4, 0x88, 0x7e, 0xf7, 0x61, 0xb4, 0xe6, 0x25, 0x17, 0xe5, 0xb5, 0x28, 0xef,
+	0xf8, 0x81, 0x4c, 0xa0, 0xb7, 0x5a, 0x52, 0x67, 0xee, 0xc4, 0x21, 0xeb, 0xf1, 0x25, 0x79, 0x01,
+	0xae, 0x14, 0x05, 0xd2, 0xde, 0xdc, 0x89, 0x27, 0x0b, 0x92, 0x74, 0xcd, 0x09, 0x13, 0x05, 0x32,
+	0xdb, 0x27, 0x4f, 0x20, 0xdc, 0x48, 0x7e, 0xdc, 0x6a, 0x5c, 0x6d, 0x68, 0xdf, 0x5e, 0x0f, 0xab,
+	0x87, 0x02, 0x21, 0xe0, 0x66, 0x15, 0xe6, 0xd4, 0xb5, 0x0d, 0x57, 0x55, 0x98, 0x93, 0x19, 0x04,
+	0x1b, 0x29, 0x8e, 0x7c, 0x8f, 0x92, 0x0e, 0x6c, 0x3d, 0xa8, 0x5a, 0x6d, 0xfd, 0xfc, 0x1e, 0xa9,
+	0xd7, 0xfa, 0xf9, 0x3d, 0x92, 0xc7, 0xe0, 0x31, 0x3c, 0x70, 0x51, 0x52, 0xdf, 0x56, 0x3d, 0x69,
+	0x15, 0x79, 0x0b, 0x7e, 0x86, 0xb9, 0x44, 0xad, 0x68, 0x30, 0xef, 0xc7, 0xc3, 0xc5, 0xec, 0x1c,
+	0xb2, 0x6d, 0xa6, 0xa5, 0x96, 0x27, 0xe6, 0xab, 0x46, 0x91, 0xe7, 0x30, 0xfe, 0x5a, 0xaa, 0xfc,
+	0x3b, 0xee, 0xeb, 0x62, 0xbb, 0x2b, 0x90, 0x86, 0x73, 0x27, 0x0e, 0xd8, 0xb8, 0xee, 0x16, 0x0d,
+	0xe3, 0x52, 0x6e, 0x79, 0xc9, 0xcb, 0x03, 0x05, 0x6b, 0x08, 0xf6, 0xad, 0x9e, 0x5d, 0xc1, 0xa8,
+	0x3b, 0x9a, 0x4c, 0xa1, 0xff, 0x03, 0x4f, 0x6d, 0x74, 0xe6, 0x48, 0x1e, 0xc1, 0xe0, 0xb8, 0x2d,
+	0xea, 0x26, 0xbc, 0x90, 0x35, 0xe2, 0xaa, 0xf7, 0xde, 0x89, 0x62, 0x70, 0x4d, 0x76, 0x24, 0x00,
+	0xf7, 0xe6, 0xf6, 0x26, 0x9d, 0x5e, 0x10, 0x00, 0xef, 0xdb, 0x2d, 0xfb, 0x9c, 0xb2, 0xa9, 0x63,
+	0xce, 0xeb, 0x0f, 0xd9, 0x97, 0x94, 0x4d, 0x7b, 0xd1, 0x3b, 0x18, 0x30, 0xac, 0x8a, 0x13, 0xa1,
+	0xe0, 0x67, 0x75, 0x9e, 0xa3, 0x52, 0x76, 0x45, 0xc0, 0x7c, 0xd5, 0x48, 0xb3, 0x26, 0x95, 0x52,
+	0xc8, 0x87, 0x35, 0x68, 0x44, 0x14, 0x82, 0xcf, 0xf0, 0x57, 0x8d, 0x4a, 0x47, 0x4f, 0x61, 0x98,
+	0xea, 0x7c, 0xbf, 0xc6, 0x9f, 0x3b, 0x94, 0xca, 0x80, 0xae, 0x36, 0x66, 0x4a, 0xdf, 0x80, 0xf2,
+	0x8d, 0x8a, 0x9e, 0xc1, 0xf0, 0x1a, 0xa5, 0xe6, 0x77, 0x3c, 0xdf, 0x6a, 0x34, 0xe9, 0x1b, 0xd9,
+	0x3e, 0xc5, 0xcd, 0x51, 0xea, 0xc5, 0x1f, 0x07, 0xbc, 0x26, 0x56, 0xf2, 0x12, 0x2e, 0x33, 0xd4,
+	0x67, 0xbf, 0x66, 0x7c, 0x16, 0xf9, 0xcc, 0x4b, 0x2c, 0x73, 0x74, 0x41, 0x5e, 0xc1, 0xe5, 0xa7,
+	0xff, 0xbc, 0x41, 0xd2, 0x72, 0xcd, 0xce, 0x6f, 0x45, 0x17, 0x24, 0x82, 0xe0, 0xa3, 0x10, 0xda,
+	0xc0, 0x92, 0x51, 0xd2, 0x61, 0xee, 0x4c, 0x8c, 0x61, 0x92, 0xa1, 0xee, 0xe2, 0x8e, 0x92, 0x8e,
+	0xfa, 0xe7, 0xdc, 0x79, 0xf6, 0x8b, 0xbf, 0xf9, 0x3b, 0x00, 0xdc, 0xc7, 0xa7, 0xb1, 0xf1, 0x02,
+	0x00, 0x00,
 }
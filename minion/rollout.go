@@ -0,0 +1,146 @@
+package minion
+
+import (
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/join"
+	"github.com/NetSys/quilt/stitch"
+)
+
+// A rolloutGroup is the set of containers that are updated together, according to the
+// update strategy of their label.
+type rolloutGroup struct {
+	strategy stitch.UpdateStrategy
+	current  []db.Container // Containers that already match the spec.
+	news     []db.Container // Containers in the spec that aren't running yet.
+	olds     []db.Container // Containers that are no longer in the spec.
+}
+
+// rollout limits the containers started and stopped by a change in the spec so that
+// each label is updated according to its update strategy, rather than all at once.
+// It returns the containers that should be started and stopped now, and the progress
+// of each label's update.
+func rollout(spec stitch.Stitch, pairs []join.Pair, news, olds []db.Container) (
+	start, stop []db.Container, progress []db.Rollout) {
+
+	groups := map[string]*rolloutGroup{}
+	getGroup := func(dbc db.Container) *rolloutGroup {
+		label := dbc.RolloutLabel()
+		if groups[label] == nil {
+			groups[label] = &rolloutGroup{}
+		}
+		return groups[label]
+	}
+
+	for _, label := range spec.QueryLabels() {
+		groups[label.Name] = &rolloutGroup{strategy: label.UpdateStrategy}
+	}
+
+	for _, pair := range pairs {
+		group := getGroup(pair.L.(db.Container))
+		group.current = append(group.current, pair.R.(db.Container))
+	}
+
+	for _, dbc := range news {
+		group := getGroup(dbc)
+		group.news = append(group.news, dbc)
+	}
+
+	for _, dbc := range olds {
+		group := getGroup(dbc)
+		group.olds = append(group.olds, dbc)
+	}
+
+	for label, group := range groups {
+		groupStart, groupStop := group.step()
+		start = append(start, groupStart...)
+		stop = append(stop, groupStop...)
+
+		if label == "" {
+			continue
+		}
+
+		progress = append(progress, db.Rollout{
+			Label:    label,
+			Replicas: len(group.current) + len(group.news),
+			Updated:  len(group.current) + len(groupStart),
+			Ready:    group.countReady(group.current),
+			Old:      len(group.olds) - len(groupStop),
+
+			MaxUnavailable: group.maxUnavailable(),
+		})
+	}
+
+	return start, stop, progress
+}
+
+// step decides which containers of the group may be started and stopped without
+// violating its update strategy.
+func (group rolloutGroup) step() (start, stop []db.Container) {
+	// Labels that are only growing or shrinking have nothing to replace.
+	if len(group.news) == 0 || len(group.olds) == 0 {
+		return group.news, group.olds
+	}
+
+	maxUnavailable := group.maxUnavailable()
+	maxSurge := group.strategy.MaxSurge
+
+	replicas := len(group.current) + len(group.news)
+	available := group.countReady(group.current) + group.countReady(group.olds)
+
+	// Old containers that are down anyway are replaced first.
+	for _, dbc := range group.olds {
+		if !group.ready(dbc) {
+			stop = append(stop, dbc)
+		}
+	}
+
+	for _, dbc := range group.olds {
+		if group.ready(dbc) && available-1 >= replicas-maxUnavailable {
+			available--
+			stop = append(stop, dbc)
+		}
+	}
+
+	running := len(group.current) + len(group.olds) - len(stop)
+	surge := replicas + maxSurge - running
+	if surge > len(group.news) {
+		surge = len(group.news)
+	}
+	if surge > 0 {
+		start = group.news[:surge]
+	}
+	return start, stop
+}
+
+// maxUnavailable returns how many of the group's containers may be down at once.  The
+// zero UpdateStrategy replaces one container at a time.
+func (group rolloutGroup) maxUnavailable() int {
+	if group.strategy.MaxUnavailable == 0 && group.strategy.MaxSurge == 0 {
+		return 1
+	}
+	return group.strategy.MaxUnavailable
+}
+
+func (group rolloutGroup) countReady(dbcs []db.Container) int {
+	count := 0
+	for _, dbc := range dbcs {
+		if group.ready(dbc) {
+			count++
+		}
+	}
+	return count
+}
+
+// ready returns true if `dbc` is running, and if the group waits for health checks,
+// healthy.
+func (group rolloutGroup) ready(dbc db.Container) bool {
+	switch {
+	case dbc.DockerID == "", dbc.Health == db.HealthExited,
+		dbc.Health == db.HealthUnhealthy:
+		return false
+	case group.strategy.WaitHealthy && !dbc.HealthCheck.Empty():
+		return dbc.Health == db.HealthHealthy
+	default:
+		return true
+	}
+}
@@ -0,0 +1,128 @@
+package minion
+
+import (
+	"testing"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/join"
+	"github.com/NetSys/quilt/stitch"
+)
+
+func TestRolloutStep(t *testing.T) {
+	t.Parallel()
+
+	running := db.Container{Image: "old", DockerID: "a"}
+	down := db.Container{Image: "old"}
+	newc := db.Container{Image: "new"}
+
+	test := func(strategy stitch.UpdateStrategy, current, news,
+		olds []db.Container, expStart, expStop int) {
+
+		group := rolloutGroup{strategy, current, news, olds}
+		start, stop := group.step()
+		if len(start) != expStart || len(stop) != expStop {
+			t.Errorf("%+v: started %d and stopped %d, expected %d and %d",
+				strategy, len(start), len(stop), expStart, expStop)
+		}
+	}
+
+	three := []db.Container{running, running, running}
+	threeNew := []db.Container{newc, newc, newc}
+
+	// By default, one old container is replaced at a time.
+	test(stitch.UpdateStrategy{}, nil, threeNew, three, 1, 1)
+
+	// The next is replaced only once the first replacement is running.
+	test(stitch.UpdateStrategy{}, []db.Container{newc}, threeNew[:2],
+		three[:2], 0, 0)
+	test(stitch.UpdateStrategy{}, []db.Container{{Image: "new", DockerID: "b"}},
+		threeNew[:2], three[:2], 1, 1)
+
+	// Surging starts new containers before any old ones are stopped.
+	test(stitch.UpdateStrategy{MaxSurge: 2}, nil, threeNew, three, 2, 0)
+
+	test(stitch.UpdateStrategy{MaxUnavailable: 2, MaxSurge: 1}, nil, threeNew,
+		three, 3, 2)
+
+	// Old containers that are already down are replaced immediately.
+	test(stitch.UpdateStrategy{}, nil, threeNew,
+		[]db.Container{down, down, running}, 2, 2)
+
+	// Labels that are only growing or shrinking are changed at once.
+	test(stitch.UpdateStrategy{}, nil, threeNew, nil, 3, 0)
+	test(stitch.UpdateStrategy{}, nil, nil, three, 0, 3)
+}
+
+func TestRolloutReady(t *testing.T) {
+	t.Parallel()
+
+	check := db.HealthCheck{TCP: 80}
+	group := rolloutGroup{strategy: stitch.UpdateStrategy{WaitHealthy: true}}
+
+	if group.ready(db.Container{}) {
+		t.Error("Containers that aren't running shouldn't be ready")
+	}
+
+	if !group.ready(db.Container{DockerID: "a"}) {
+		t.Error("Running containers without a health check should be ready")
+	}
+
+	dbc := db.Container{DockerID: "a", HealthCheck: check}
+	dbc.Health = db.HealthStarting
+	if group.ready(dbc) {
+		t.Error("Containers shouldn't be ready until they are healthy")
+	}
+
+	dbc.Health = db.HealthHealthy
+	if !group.ready(dbc) {
+		t.Error("Healthy containers should be ready")
+	}
+
+	group.strategy.WaitHealthy = false
+	dbc.Health = db.HealthStarting
+	if !group.ready(dbc) {
+		t.Error("Running containers should be ready unless waiting for health")
+	}
+}
+
+func TestRollout(t *testing.T) {
+	t.Parallel()
+
+	spec, err := stitch.New(`deployment.deploy(new Label("web", [
+		new Container("new"), new Container("new")])
+		.withUpdateStrategy({maxSurge: 1}));`, stitch.DefaultImportGetter)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	web := []string{"web"}
+	pairs := []join.Pair{{
+		L: db.Container{Image: "new", Labels: web},
+		R: db.Container{Image: "new", Labels: web, DockerID: "a"},
+	}}
+	news := []db.Container{{Image: "new", Labels: web}}
+	olds := []db.Container{
+		{Image: "old", Labels: web, DockerID: "b"},
+		{Image: "other", Labels: []string{"gone"}, DockerID: "c"},
+	}
+
+	start, stop, progress := rollout(spec, pairs, news, olds)
+
+	// The surge allows the second replacement to start, but the old container must
+	// keep running until both are ready.  The removed label is stopped at once.
+	if len(start) != 1 || len(stop) != 1 || stop[0].Image != "other" {
+		t.Errorf("Started %v and stopped %v", start, stop)
+	}
+
+	exp := db.Rollout{Label: "web", Replicas: 2, Updated: 2, Ready: 1, Old: 1}
+	var webProgress db.Rollout
+	for _, r := range progress {
+		if r.Label == "web" {
+			webProgress = r
+		}
+	}
+
+	if webProgress != exp {
+		t.Errorf("Progress %v, expected %v", webProgress, exp)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/NetSys/quilt/api"
 	apiServer "github.com/NetSys/quilt/api/server"
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/minion/docker"
 	"github.com/NetSys/quilt/minion/etcd"
@@ -18,8 +19,9 @@ import (
 	log "github.com/Sirupsen/logrus"
 )
 
-// Run blocks executing the minion.
-func Run() {
+// Run blocks executing the minion.  All of the minion's gRPC traffic is mutually
+// authenticated with the credentials in `creds`.
+func Run(creds *certs.Store) {
 	// XXX Uncomment the following line to run the profiler
 	//runProfiler(5 * time.Minute)
 
@@ -27,16 +29,17 @@ func Run() {
 
 	conn := db.New()
 	dk := docker.New("unix:///var/run/docker.sock")
-	go minionServerRun(conn)
+	go minionServerRun(conn, creds)
 	go supervisor.Run(conn, dk)
 	go scheduler.Run(conn, dk)
 	go network.Run(conn, dk)
 	go etcd.Run(conn)
 
-	go apiServer.Run(conn, fmt.Sprintf("tcp://0.0.0.0:%d", api.DefaultRemotePort))
+	go apiServer.RunMinion(conn,
+		fmt.Sprintf("tcp://0.0.0.0:%d", api.DefaultRemotePort), creds, dk)
 
 	loopLog := util.NewEventTimer("Minion-Update")
-	for range conn.Trigger(db.MinionTable).C {
+	for range conn.Trigger(db.MinionTable, db.ContainerTable).C {
 		loopLog.LogStart()
 		conn.Transact(func(view db.Database) error {
 			minion, err := view.MinionSelf()
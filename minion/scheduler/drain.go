@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"github.com/NetSys/quilt/db"
+	log "github.com/Sirupsen/logrus"
+)
+
+// drain unassigns the containers of draining minions, so that they're placed on other
+// minions.  Like a rollout, it takes down at most MaxUnavailable of each label's
+// containers at once, and waits for those already moved to start on their new minions
+// before moving more.
+func drain(ctx *context, rollouts []db.Rollout) {
+	maxUnavailable := map[string]int{}
+	for _, r := range rollouts {
+		maxUnavailable[r.Label] = r.MaxUnavailable
+	}
+
+	unavailable := map[string]int{}
+	for _, dbc := range allContainers(ctx) {
+		if !available(dbc) {
+			unavailable[dbc.RolloutLabel()]++
+		}
+	}
+
+	for _, dbc := range ctx.draining {
+		// Moving a container that's down doesn't make its label less available.
+		label := dbc.RolloutLabel()
+		if available(dbc) {
+			limit := maxUnavailable[label]
+			if limit < 1 {
+				limit = 1
+			}
+
+			if unavailable[label] >= limit {
+				continue
+			}
+			unavailable[label]++
+		}
+
+		log.WithField("container", dbc).Info("Moving container off of " +
+			"draining minion.")
+		dbc.Minion = ""
+		ctx.unassigned = append(ctx.unassigned, dbc)
+		ctx.changed = append(ctx.changed, dbc)
+	}
+}
+
+func allContainers(ctx *context) []*db.Container {
+	dbcs := append([]*db.Container{}, ctx.unassigned...)
+	dbcs = append(dbcs, ctx.draining...)
+	for _, m := range ctx.minions {
+		dbcs = append(dbcs, m.containers...)
+	}
+	return dbcs
+}
+
+// available returns true if `dbc` is placed, and its minion reports it running.
+func available(dbc *db.Container) bool {
+	return dbc.Minion != "" && dbc.DockerID != "" &&
+		dbc.Health != db.HealthExited && dbc.Health != db.HealthUnhealthy
+}
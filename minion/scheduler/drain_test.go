@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker, Draining: true},
+		{PrivateIP: "2", Role: db.Worker},
+	}
+	containers := []db.Container{
+		{ID: 1, Labels: []string{"web"}, Minion: "1", DockerID: "a"},
+		{ID: 2, Labels: []string{"web"}, Minion: "1", DockerID: "b"},
+		{ID: 3, Labels: []string{"web"}, Minion: "1", DockerID: "c"},
+		{ID: 4, Labels: []string{"web"}, Minion: "1"},
+		{ID: 5, Labels: []string{"db"}, Minion: "1", DockerID: "d"},
+		{ID: 6, Labels: []string{"web"}, Minion: "2", DockerID: "e"},
+	}
+	rollouts := []db.Rollout{{Label: "web", MaxUnavailable: 2}}
+
+	// The web container that's down is moved, along with one more to reach its
+	// label's limit.  Labels without a rollout move one container at a time.
+	ctx := makeContext(minions, nil, containers)
+	drain(ctx, rollouts)
+	if moved := changedIDs(ctx); !eq(moved, []int{1, 4, 5}) {
+		t.Error(spew.Sprintf("Moved %v, expected [1 4 5]", moved))
+	}
+
+	// Nothing more is moved until the moved containers are running again.
+	for i := range containers {
+		if containers[i].Minion == "" {
+			containers[i].Minion = "2"
+			containers[i].DockerID = ""
+		}
+	}
+
+	ctx = makeContext(minions, nil, containers)
+	drain(ctx, rollouts)
+	if len(ctx.changed) != 0 {
+		t.Error(spew.Sprintf("Unexpected moves: %v", ctx.changed))
+	}
+
+	containers[0].DockerID = "f"
+	ctx = makeContext(minions, nil, containers)
+	drain(ctx, rollouts)
+	if moved := changedIDs(ctx); !eq(moved, []int{2}) {
+		t.Error(spew.Sprintf("Moved %v, expected [2]", moved))
+	}
+
+	// The containers left on the draining minion aren't placed on it again.
+	placeUnassigned(ctx)
+	if containers[1].Minion != "2" {
+		t.Errorf("Container placed on %s, expected 2", containers[1].Minion)
+	}
+}
+
+func changedIDs(ctx *context) []int {
+	var ids []int
+	for _, dbc := range ctx.changed {
+		ids = append(ids, dbc.ID)
+	}
+	return ids
+}
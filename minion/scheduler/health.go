@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/minion/docker"
+	log "github.com/Sirupsen/logrus"
+	netctx "golang.org/x/net/context"
+)
+
+const healthTick = time.Second
+
+// Mocked out for unit testing.
+var probeTimeout = 5 * time.Second
+var dialTimeout = net.DialTimeout
+var httpClient = &http.Client{Timeout: probeTimeout}
+
+type probeState struct {
+	dockerID string
+	next     time.Time
+	failures int
+}
+
+// healthChecker probes the containers running on this worker, and kills those that
+// fail their health check too many times in a row so that they are restarted.
+type healthChecker struct {
+	dk     docker.Client
+	states map[int]*probeState
+}
+
+func runHealthChecks(conn db.Conn, dk docker.Client) {
+	hc := healthChecker{dk: dk, states: map[int]*probeState{}}
+	for range time.Tick(healthTick) {
+		self, err := conn.MinionSelf()
+		if err != nil || self.Role != db.Worker {
+			continue
+		}
+		hc.runOnce(conn, self.PrivateIP, time.Now())
+	}
+}
+
+func (hc *healthChecker) runOnce(conn db.Conn, myIP string, now time.Time) {
+	dbcs := conn.SelectFromContainerBy("Minion", myIP, func(dbc db.Container) bool {
+		return dbc.DockerID != "" && !dbc.HealthCheck.Empty() &&
+			dbc.Health != db.HealthExited
+	})
+
+	health := map[int]string{}
+	live := map[int]struct{}{}
+	var due []db.Container
+	for _, dbc := range dbcs {
+		live[dbc.ID] = struct{}{}
+
+		// A new docker container gets a grace period of one interval to start.
+		state := hc.states[dbc.ID]
+		if state == nil || state.dockerID != dbc.DockerID {
+			state = &probeState{
+				dockerID: dbc.DockerID,
+				next:     now.Add(probeInterval(dbc)),
+			}
+			hc.states[dbc.ID] = state
+			health[dbc.ID] = db.HealthStarting
+		}
+
+		if !now.Before(state.next) {
+			due = append(due, dbc)
+		}
+	}
+
+	for id := range hc.states {
+		if _, ok := live[id]; !ok {
+			delete(hc.states, id)
+		}
+	}
+
+	errs := make([]error, len(due))
+	var wg sync.WaitGroup
+	wg.Add(len(due))
+	for i, dbc := range due {
+		go func(i int, dbc db.Container) {
+			errs[i] = hc.probe(dbc)
+			wg.Done()
+		}(i, dbc)
+	}
+	wg.Wait()
+
+	for i, dbc := range due {
+		state := hc.states[dbc.ID]
+		state.next = now.Add(probeInterval(dbc))
+		if errs[i] == nil {
+			state.failures = 0
+			health[dbc.ID] = db.HealthHealthy
+			continue
+		}
+
+		state.failures++
+		log.WithError(errs[i]).WithField("container", dbc).Debug(
+			"Failed health check.")
+		if state.failures < probeRetries(dbc) {
+			continue
+		}
+
+		health[dbc.ID] = db.HealthUnhealthy
+		if dbc.RestartPolicy == db.RestartNever {
+			continue
+		}
+
+		log.WithField("container", dbc).Info("Kill unhealthy container")
+		if err := hc.dk.RemoveID(dbc.DockerID); err != nil {
+			log.WithError(err).WithField("id", dbc.DockerID).Warning(
+				"Failed to remove unhealthy container.")
+		}
+		delete(hc.states, dbc.ID)
+	}
+
+	if len(health) == 0 {
+		return
+	}
+
+	conn.Transact(func(view db.Database) error {
+		for _, dbc := range view.SelectFromContainer(nil) {
+			if h, ok := health[dbc.ID]; ok && h != dbc.Health {
+				dbc.Health = h
+				view.Commit(dbc)
+			}
+		}
+		return nil
+	})
+}
+
+// probe runs the health check of `dbc` once, returning an error if it fails.
+func (hc *healthChecker) probe(dbc db.Container) error {
+	check := dbc.HealthCheck
+	if len(check.Exec) > 0 {
+		// A wedged container may never finish the command, so give up on it
+		// like on an unresponsive server.
+		ctx, cancel := netctx.WithTimeout(netctx.Background(), probeTimeout)
+		defer cancel()
+
+		code, err := hc.dk.ExecStatus(ctx, dbc.DockerID, check.Exec...)
+		if err == nil && code != 0 {
+			err = fmt.Errorf("exit status %d", code)
+		}
+		return err
+	}
+
+	dkc, err := hc.dk.Get(dbc.DockerID)
+	if err != nil {
+		return err
+	}
+
+	if check.TCP != 0 {
+		addr := net.JoinHostPort(dkc.IP, strconv.Itoa(check.TCP))
+		conn, err := dialTimeout("tcp", addr, probeTimeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	addr := net.JoinHostPort(dkc.IP, strconv.Itoa(check.HTTP))
+	resp, err := httpClient.Get("http://" + addr + check.Path)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+func probeInterval(dbc db.Container) time.Duration {
+	if dbc.HealthCheck.Interval <= 0 {
+		return healthTick
+	}
+	return time.Duration(dbc.HealthCheck.Interval) * time.Second
+}
+
+func probeRetries(dbc db.Container) int {
+	if dbc.HealthCheck.Retries <= 0 {
+		return 1
+	}
+	return dbc.HealthCheck.Retries
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/minion/docker"
+)
+
+func TestHealthChecks(t *testing.T) {
+	md, dk := docker.NewMock()
+	conn := db.New()
+
+	id, err := dk.Run(docker.RunOptions{Image: "image"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	conn.Transact(func(view db.Database) error {
+		dbc := view.InsertContainer()
+		dbc.Image = "image"
+		dbc.Minion = "1.2.3.4"
+		dbc.DockerID = id
+		dbc.HealthCheck = db.HealthCheck{
+			Exec:     []string{"true"},
+			Interval: 1,
+			Retries:  2,
+		}
+		view.Commit(dbc)
+		return nil
+	})
+
+	health := func() string {
+		return conn.SelectFromContainer(nil)[0].Health
+	}
+
+	hc := healthChecker{dk: dk, states: map[int]*probeState{}}
+	now := time.Now()
+	tick := func() {
+		hc.runOnce(conn, "1.2.3.4", now)
+		now = now.Add(time.Second)
+	}
+
+	// New containers aren't probed until their first interval passes.
+	tick()
+	if h := health(); h != db.HealthStarting {
+		t.Error(expLog("health", h, db.HealthStarting))
+	}
+
+	tick()
+	if h := health(); h != db.HealthHealthy {
+		t.Error(expLog("health", h, db.HealthHealthy))
+	}
+
+	// A single failure isn't enough to mark the container unhealthy.
+	md.ExecExitCode = 1
+	tick()
+	if h := health(); h != db.HealthHealthy {
+		t.Error(expLog("health", h, db.HealthHealthy))
+	}
+
+	tick()
+	if h := health(); h != db.HealthUnhealthy {
+		t.Error(expLog("health", h, db.HealthUnhealthy))
+	}
+
+	if _, err := dk.Get(id); err == nil {
+		t.Error("Expected the unhealthy container to be removed")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	md, dk := docker.NewMock()
+	hc := healthChecker{dk: dk, states: map[int]*probeState{}}
+
+	id, err := dk.Run(docker.RunOptions{Image: "image"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	dbc := db.Container{DockerID: id}
+	dbc.HealthCheck.Exec = []string{"true"}
+	if err := hc.probe(dbc); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	md.ExecExitCode = 2
+	if err := hc.probe(dbc); err == nil {
+		t.Error("Expected a nonzero exit status to fail the probe")
+	}
+
+	// Executions that hang count as failures.
+	md.Lock()
+	md.ExecExitCode = 0
+	md.ExecBlock = make(chan struct{})
+	md.Unlock()
+	probeTimeout = 10 * time.Millisecond
+	if err := hc.probe(dbc); err == nil {
+		t.Error("Expected a hung execution to fail the probe")
+	}
+	md.Lock()
+	close(md.ExecBlock)
+	md.ExecBlock = nil
+	md.Unlock()
+	probeTimeout = 5 * time.Second
+
+	var dialed string
+	dialTimeout = func(network, addr string, _ time.Duration) (net.Conn, error) {
+		dialed = addr
+		return nil, errors.New("connection refused")
+	}
+	defer func() { dialTimeout = net.DialTimeout }()
+
+	dbc.HealthCheck = db.HealthCheck{TCP: 5432}
+	if err := hc.probe(dbc); err == nil {
+		t.Error("Expected a refused connection to fail the probe")
+	}
+
+	if dialed != ":5432" {
+		t.Error(expLog("dialed address", dialed, ":5432"))
+	}
+}
@@ -2,14 +2,30 @@ package scheduler
 
 import (
 	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/NetSys/quilt/constants"
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/stitch"
 	log "github.com/Sirupsen/logrus"
 )
 
+// resources are a number of cores, and gigabytes of memory.
+type resources struct {
+	cpu, ram float64
+}
+
 type minion struct {
 	db.Minion
 	containers []*db.Container
+
+	// The minion's capacity, and the part of it that isn't reserved by containers.
+	// Minions whose size is unknown have no capacity, and accept any container.
+	capacity, free resources
 }
 
 type context struct {
@@ -17,6 +33,9 @@ type context struct {
 	constraints []db.Placement
 	unassigned  []*db.Container
 	changed     []*db.Container
+
+	// Containers on draining minions that haven't been moved off of them yet.
+	draining []*db.Container
 }
 
 func runMaster(conn db.Conn) {
@@ -34,47 +53,388 @@ func placeContainers(view db.Database) {
 	minions := view.SelectFromMinion(nil)
 
 	ctx := makeContext(minions, constraints, containers)
+	drain(ctx, view.SelectFromRollout(nil))
 	cleanupPlacements(ctx)
 	placeUnassigned(ctx)
+	rebalance(ctx, time.Now())
 
 	for _, change := range ctx.changed {
 		view.Commit(*change)
 	}
 }
 
-// Unassign all containers that are placed incorrectly.
+// Unassign all containers that are placed incorrectly.  Unassigning a container may
+// invalidate the placement of those co-located with it, so repeat until nothing changes.
 func cleanupPlacements(ctx *context) {
-	for _, m := range ctx.minions {
-		for i, dbc := range m.containers {
-			if validPlacement(ctx.constraints, *m, dbc) {
-				continue
+	for changed := true; changed; {
+		changed = false
+		for _, m := range ctx.minions {
+			var valid []*db.Container
+			for _, dbc := range m.containers {
+				constraints := applicableConstraints(ctx, dbc)
+				if validPlacement(constraints, *m, dbc) {
+					valid = append(valid, dbc)
+					continue
+				}
+				dbc.Minion = ""
+				m.release(dbc)
+				ctx.unassigned = append(ctx.unassigned, dbc)
+				ctx.changed = append(ctx.changed, dbc)
+				changed = true
 			}
-			dbc.Minion = ""
-			m.containers = append(m.containers[:i], m.containers[i+1:]...)
-			ctx.unassigned = append(ctx.unassigned, dbc)
-			ctx.changed = append(ctx.changed, dbc)
+			m.containers = valid
 		}
 	}
 }
 
+// placeUnassigned assigns each unassigned container a minion.  Containers are kept
+// apart from those sharing their labels where possible.  Beyond that, containers that
+// request resources are bin-packed, largest first, onto the valid minion they fit most
+// tightly, while the rest are spread across the minions running the fewest containers.
 func placeUnassigned(ctx *context) {
 	minions := minionHeap(ctx.minions)
 	heap.Init(&minions)
 
-Outer:
-	for _, dbc := range ctx.unassigned {
-		for i, minion := range minions {
-			if validPlacement(ctx.constraints, *minion, dbc) {
-				dbc.Minion = minion.PrivateIP
-				ctx.changed = append(ctx.changed, dbc)
-				minion.containers = append(minion.containers, dbc)
-				heap.Fix(&minions, i)
-				log.WithField("container", dbc).Info("Placed container.")
-				continue Outer
+	sort.Stable(bySize(ctx.unassigned))
+
+	// A container with an inclusive constraint can't be placed until a container it
+	// must be co-located with has been, so keep making passes over the unplaced
+	// containers until one fails to place any.
+	unplaced := ctx.unassigned
+	for len(unplaced) > 0 {
+		var retry []*db.Container
+		for _, dbc := range unplaced {
+			if !placeContainer(ctx, &minions, dbc) {
+				retry = append(retry, dbc)
+			}
+		}
+
+		if len(retry) == len(unplaced) {
+			break
+		}
+		unplaced = retry
+	}
+
+	for _, dbc := range unplaced {
+		reportUnplaced(ctx, dbc)
+	}
+}
+
+// placeContainer assigns `dbc` to the best of `minions` that may run it, and returns
+// false if there are none.  Of the valid minions, those with the fewest containers
+// sharing a label with `dbc` are preferred, so that replicas are spread across
+// minions, regions, and providers even without a SpreadRule.
+func placeContainer(ctx *context, minions *minionHeap, dbc *db.Container) bool {
+	constraints := applicableConstraints(ctx, dbc)
+	pinned := volumeMinions(ctx.minions, dbc)
+	peers := newPeerCounts(ctx.minions, dbc)
+
+	best := -1
+	var bestPeers peerScore
+	for i, minion := range *minions {
+		// Cordoned minions keep the containers they have, but take no more.
+		if minion.Unschedulable {
+			continue
+		}
+
+		if pinned != nil && !pinned[minion.PrivateIP] {
+			continue
+		}
+
+		if !validPlacement(constraints, *minion, dbc) || !minion.fits(dbc) ||
+			!validSpread(ctx, constraints, *minion, dbc) {
+			continue
+		}
+
+		score := peers.score(*minion)
+		switch {
+		case best < 0 || score.less(bestPeers):
+		case score == bestPeers && (dbc.CPU != 0 || dbc.RAM != 0) &&
+			minion.slack(dbc) < (*minions)[best].slack(dbc):
+		default:
+			continue
+		}
+		best, bestPeers = i, score
+	}
+
+	if best < 0 {
+		return false
+	}
+
+	minion := (*minions)[best]
+	dbc.Minion = minion.PrivateIP
+	dbc.Unplaced = ""
+	ctx.changed = append(ctx.changed, dbc)
+	minion.reserve(dbc)
+	minion.containers = append(minion.containers, dbc)
+	heap.Fix(minions, best)
+	log.WithField("container", dbc).Info("Placed container.")
+	return true
+}
+
+// reportUnplaced logs why `dbc` couldn't be placed, and records it on the container so
+// that users can see it.
+func reportUnplaced(ctx *context, dbc *db.Container) {
+	reason := unplacedReason(ctx, dbc)
+	log.WithField("container", dbc).Warning(reason)
+
+	if dbc.Unplaced != reason {
+		dbc.Unplaced = reason
+		ctx.changed = append(ctx.changed, dbc)
+	}
+}
+
+func unplacedReason(ctx *context, dbc *db.Container) string {
+	constraints := applicableConstraints(ctx, dbc)
+
+	var missing []string
+	for _, constraint := range constraints {
+		if constraint.OtherLabel != "" && !constraint.Exclusive &&
+			hasLabel(dbc, constraint.TargetLabel) &&
+			!labelPlaced(ctx, constraint.OtherLabel, dbc) {
+			missing = append(missing, constraint.OtherLabel)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Sprintf("Unsatisfiable placement: no minion runs a "+
+			"container it must be co-located with (%s).",
+			strings.Join(missing, ", "))
+	}
+
+	for _, m := range ctx.minions {
+		if validPlacement(constraints, *m, dbc) && m.fits(dbc) &&
+			!m.Unschedulable && !validSpread(ctx, constraints, *m, dbc) {
+			return "Unsatisfiable spread: placing it on any minion would " +
+				"exceed its label's maximum skew."
+		}
+	}
+
+	for _, m := range ctx.minions {
+		if validPlacement(constraints, *m, dbc) {
+			return "Unschedulable container: no minion has the capacity " +
+				"to run it."
+		}
+	}
+
+	return "Failed to place container: no minion satisfies its placement " +
+		"rules."
+}
+
+// applicableConstraints returns the placement constraints `dbc` must satisfy.  An
+// inclusive constraint on a label that `dbc` carries itself is waived while no other
+// container with that label is placed, so that the first member of a co-located group
+// has somewhere to go.
+func applicableConstraints(ctx *context, dbc *db.Container) []db.Placement {
+	var constraints []db.Placement
+	for _, constraint := range ctx.constraints {
+		if !constraint.Exclusive && constraint.OtherLabel != "" &&
+			hasLabel(dbc, constraint.OtherLabel) &&
+			!labelPlaced(ctx, constraint.OtherLabel, dbc) {
+			continue
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints
+}
+
+// labelPlaced returns true if a container other than `except` with `label` is placed
+// on a minion.
+func labelPlaced(ctx *context, label string, except *db.Container) bool {
+	for _, m := range ctx.minions {
+		for _, dbc := range m.containers {
+			if dbc.ID != except.ID && hasLabel(dbc, label) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasLabel(dbc *db.Container, label string) bool {
+	for _, l := range dbc.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// fits returns true if `m` has the spare capacity to run `dbc`.
+func (m minion) fits(dbc *db.Container) bool {
+	if m.capacity == (resources{}) {
+		return true
+	}
+	return dbc.CPU <= m.free.cpu && dbc.RAM <= m.free.ram
+}
+
+// slack returns the fraction of `m`'s capacity that would remain free after placing
+// `dbc` on it.  Minions with no capacity have infinite slack.
+func (m minion) slack(dbc *db.Container) float64 {
+	if m.capacity == (resources{}) {
+		return math.Inf(1)
+	}
+	return (m.free.cpu-dbc.CPU)/m.capacity.cpu + (m.free.ram-dbc.RAM)/m.capacity.ram
+}
+
+func (m *minion) reserve(dbc *db.Container) {
+	m.free.cpu -= dbc.CPU
+	m.free.ram -= dbc.RAM
+}
+
+func (m *minion) release(dbc *db.Container) {
+	m.free.cpu += dbc.CPU
+	m.free.ram += dbc.RAM
+}
+
+// volumeMinions returns the IPs of the minions that hold the data of `dbc`'s volumes,
+// or nil if none of them do.  Containers must be placed with their data.
+func volumeMinions(minions []*minion, dbc *db.Container) map[string]bool {
+	pins := map[string]struct{}{}
+	for _, bind := range dbc.Volumes {
+		pins[volumePin(dbc.StitchID, bind)] = struct{}{}
+	}
+
+	var pinned map[string]bool
+	for _, m := range minions {
+		for _, volume := range m.Volumes {
+			if _, ok := pins[volume]; !ok {
+				continue
+			}
+
+			if pinned == nil {
+				pinned = map[string]bool{}
+			}
+			pinned[m.PrivateIP] = true
+		}
+	}
+	return pinned
+}
+
+// validSpread returns true if placing `dbc` on `m` keeps the containers of each label
+// it must spread within the label's maximum skew.  Only the domains of the minions
+// that may take the label's containers count towards the skew.
+func validSpread(ctx *context, constraints []db.Placement, m minion,
+	dbc *db.Container) bool {
+
+	var machineRules []db.Placement
+	for _, constraint := range constraints {
+		if constraint.OtherLabel == "" && constraint.Spread == "" {
+			machineRules = append(machineRules, constraint)
+		}
+	}
+
+	for _, constraint := range constraints {
+		if constraint.Spread == "" || !hasLabel(dbc, constraint.TargetLabel) {
+			continue
+		}
+
+		var eligible []*minion
+		for _, other := range ctx.minions {
+			if !other.Unschedulable &&
+				validPlacement(machineRules, *other, dbc) {
+				eligible = append(eligible, other)
+			}
+		}
+
+		label := constraint.TargetLabel
+		counts := domainCounts(eligible, constraint.Spread, dbc,
+			func(peer *db.Container) bool { return hasLabel(peer, label) })
+
+		min := -1
+		for _, count := range counts {
+			if min < 0 || count < min {
+				min = count
+			}
+		}
+
+		domain := spreadDomain(m, constraint.Spread)
+		if counts[domain]+1-min > constraint.MaxSkew {
+			return false
+		}
+	}
+	return true
+}
+
+// peerScore is the number of containers sharing a label with the container being
+// placed on a minion, in its region, and with its provider.
+type peerScore struct {
+	minion, region, provider int
+}
+
+func (s peerScore) less(other peerScore) bool {
+	switch {
+	case s.minion != other.minion:
+		return s.minion < other.minion
+	case s.region != other.region:
+		return s.region < other.region
+	default:
+		return s.provider < other.provider
+	}
+}
+
+// peerCounts holds the number of containers sharing a label with some container in
+// each minion, region, and provider.
+type peerCounts struct {
+	minion, region, provider map[string]int
+}
+
+func newPeerCounts(minions []*minion, dbc *db.Container) peerCounts {
+	isPeer := func(peer *db.Container) bool {
+		for _, label := range dbc.Labels {
+			if hasLabel(peer, label) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return peerCounts{
+		minion:   domainCounts(minions, stitch.SpreadMinion, dbc, isPeer),
+		region:   domainCounts(minions, stitch.SpreadRegion, dbc, isPeer),
+		provider: domainCounts(minions, stitch.SpreadProvider, dbc, isPeer),
+	}
+}
+
+func (pc peerCounts) score(m minion) peerScore {
+	return peerScore{
+		minion:   pc.minion[spreadDomain(m, stitch.SpreadMinion)],
+		region:   pc.region[spreadDomain(m, stitch.SpreadRegion)],
+		provider: pc.provider[spreadDomain(m, stitch.SpreadProvider)],
+	}
+}
+
+// domainCounts returns the number of containers other than `dbc` for which `count`
+// is true in the spread domain of each of `minions`.
+func domainCounts(minions []*minion, spread string, dbc *db.Container,
+	count func(*db.Container) bool) map[string]int {
+
+	counts := map[string]int{}
+	for _, m := range minions {
+		domain := spreadDomain(*m, spread)
+		if _, ok := counts[domain]; !ok {
+			counts[domain] = 0
+		}
+
+		for _, peer := range m.containers {
+			if peer.ID != dbc.ID && count(peer) {
+				counts[domain]++
 			}
 		}
+	}
+	return counts
+}
 
-		log.WithField("container", dbc).Warning("Failed to place container.")
+// spreadDomain returns the domain of `m` in the given spread.  Region names are only
+// unique within a provider.
+func spreadDomain(m minion, spread string) string {
+	switch spread {
+	case stitch.SpreadProvider:
+		return m.Provider
+	case stitch.SpreadRegion:
+		return m.Provider + "/" + m.Region
+	default:
+		return m.PrivateIP
 	}
 }
 
@@ -105,17 +465,9 @@ func validPlacement(constraints []db.Placement, m minion, dbc *db.Container) boo
 				}
 			}
 
-			if constraint.Exclusive {
-				if _, ok := peerLabels[constraint.OtherLabel]; ok {
-					return false
-				}
-			} else {
-				// XXX: Inclusive OtherLabel is hard because we can't
-				// make placement decisions without considering all the
-				// containers on all of the minions.
-				log.WithField("constraint", constraint).Warning(
-					"Quilt currently does not support inclusive" +
-						" label placement constraints")
+			_, ok := peerLabels[constraint.OtherLabel]
+			if constraint.Exclusive == ok {
+				return false
 			}
 		}
 
@@ -151,12 +503,25 @@ func makeContext(minions []db.Minion, constraints []db.Placement,
 	ctx.constraints = constraints
 
 	ipMinion := map[string]*minion{}
+	draining := map[string]bool{}
 	for _, dbm := range minions {
-		if dbm.Role != db.Worker || dbm.PrivateIP == "" {
+		// The containers of failed minions are unassigned below, so that they are
+		// rescheduled on healthy ones.  Those of draining minions are moved
+		// gradually by drain().
+		if dbm.Role != db.Worker || dbm.PrivateIP == "" || dbm.Failed {
+			continue
+		}
+
+		if dbm.Draining {
+			draining[dbm.PrivateIP] = true
 			continue
 		}
 
-		m := minion{dbm, nil}
+		m := minion{Minion: dbm}
+		if desc, ok := constants.LookupSize(dbm.Size); ok {
+			m.capacity = resources{cpu: float64(desc.CPU), ram: desc.RAM}
+			m.free = m.capacity
+		}
 		ctx.minions = append(ctx.minions, &m)
 		ipMinion[m.PrivateIP] = &m
 	}
@@ -164,6 +529,11 @@ func makeContext(minions []db.Minion, constraints []db.Placement,
 	for i := range containers {
 		dbc := &containers[i]
 		minion := ipMinion[dbc.Minion]
+		if draining[dbc.Minion] {
+			ctx.draining = append(ctx.draining, dbc)
+			continue
+		}
+
 		if minion == nil && dbc.Minion != "" {
 			dbc.Minion = ""
 			ctx.changed = append(ctx.changed, dbc)
@@ -174,12 +544,26 @@ func makeContext(minions []db.Minion, constraints []db.Placement,
 			continue
 		}
 
+		minion.reserve(dbc)
 		minion.containers = append(minion.containers, dbc)
 	}
 
 	return &ctx
 }
 
+// bySize sorts containers by the resources they request, largest first.
+type bySize []*db.Container
+
+func (s bySize) Len() int      { return len(s) }
+func (s bySize) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s bySize) Less(i, j int) bool {
+	if s[i].CPU != s[j].CPU {
+		return s[i].CPU > s[j].CPU
+	}
+	return s[i].RAM > s[j].RAM
+}
+
 // Minion Heap.  Minions are sorted based on the number of containers scheduled on them
 // with fewer containers being higher priority.
 type minionHeap []*minion
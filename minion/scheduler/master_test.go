@@ -2,9 +2,12 @@ package scheduler
 
 import (
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/stitch"
 	"github.com/davecgh/go-spew/spew"
 )
 
@@ -47,6 +50,86 @@ func TestPlaceContainers(t *testing.T) {
 	})
 }
 
+func TestPlaceContainersFailedMinion(t *testing.T) {
+	t.Parallel()
+	conn := db.New()
+
+	conn.Transact(func(view db.Database) error {
+		for _, ip := range []string{"1", "2"} {
+			m := view.InsertMinion()
+			m.PrivateIP = ip
+			m.Role = db.Worker
+			m.Failed = ip == "1"
+			view.Commit(m)
+		}
+
+		e := view.InsertEtcd()
+		e.Leader = true
+		view.Commit(e)
+
+		c := view.InsertContainer()
+		c.Minion = "1"
+		view.Commit(c)
+		return nil
+	})
+
+	conn.Transact(func(view db.Database) error {
+		placeContainers(view)
+		return nil
+	})
+
+	// The container is moved off of the failed minion.
+	dbcs := conn.SelectFromContainer(nil)
+	if len(dbcs) != 1 || dbcs[0].Minion != "2" {
+		t.Error(spew.Sprintf("Expected the container on minion 2: %v", dbcs))
+	}
+}
+
+func TestPlaceContainersCordoned(t *testing.T) {
+	t.Parallel()
+	conn := db.New()
+
+	conn.Transact(func(view db.Database) error {
+		for _, ip := range []string{"1", "2", "3"} {
+			m := view.InsertMinion()
+			m.PrivateIP = ip
+			m.Role = db.Worker
+			m.Unschedulable = ip != "3"
+			m.Draining = ip == "1"
+			view.Commit(m)
+		}
+
+		e := view.InsertEtcd()
+		e.Leader = true
+		view.Commit(e)
+
+		for _, ip := range []string{"1", "2", ""} {
+			c := view.InsertContainer()
+			c.Minion = ip
+			view.Commit(c)
+		}
+		return nil
+	})
+
+	conn.Transact(func(view db.Database) error {
+		placeContainers(view)
+		return nil
+	})
+
+	// The drained minion's container is moved, the cordoned minion's container
+	// stays, and the new container is only placed on the schedulable minion.
+	var minions []string
+	for _, dbc := range conn.SelectFromContainer(nil) {
+		minions = append(minions, dbc.Minion)
+	}
+	sort.Strings(minions)
+
+	exp := []string{"2", "3", "3"}
+	if !eq(minions, exp) {
+		t.Errorf("Containers placed on %v, expected %v", minions, exp)
+	}
+}
+
 func TestCleanup(t *testing.T) {
 	t.Parallel()
 
@@ -192,11 +275,26 @@ func TestPlaceUnassigned(t *testing.T) {
 			exp))
 	}
 
+	// Containers that can't be placed record why.
 	placements[0].Exclusive = false
 	placements[0].Region = "Nowhere"
 	containers[0].Minion = ""
 	ctx = makeContext(minions, placements, containers)
 	placeUnassigned(ctx)
+
+	unplaced := containers[0]
+	unplaced.Unplaced = "Failed to place container: no minion satisfies its " +
+		"placement rules."
+	exp = []*db.Container{&unplaced}
+	if !eq(ctx.changed, exp) {
+		t.Error(spew.Sprintf("\nChanged    %v\nExpChanged %v\n", ctx.changed,
+			exp))
+	}
+
+	// The reason is only recorded once.
+	containers[0] = unplaced
+	ctx = makeContext(minions, placements, containers)
+	placeUnassigned(ctx)
 	exp = nil
 	if !eq(ctx.changed, exp) {
 		t.Error(spew.Sprintf("\nChanged    %v\nExpChanged %v\n", ctx.changed,
@@ -204,6 +302,252 @@ func TestPlaceUnassigned(t *testing.T) {
 	}
 }
 
+func TestPlaceVolumes(t *testing.T) {
+	t.Parallel()
+
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker},
+		{PrivateIP: "2", Role: db.Worker, Volumes: []string{"1:mysql"}},
+		{PrivateIP: "3", Role: db.Worker},
+	}
+	containers := []db.Container{
+		{ID: 1, StitchID: 1, Volumes: []string{"mysql:/var/lib/mysql"}},
+		{ID: 2, StitchID: 2, Volumes: []string{"zookeeper:/data"}},
+	}
+
+	// The container with data on minion 2 must be placed there, even though other
+	// minions are less loaded, while the other may be placed anywhere.
+	ctx := makeContext(minions, nil, containers)
+	placeUnassigned(ctx)
+	if len(ctx.changed) != 2 || ctx.changed[0].Minion != "2" ||
+		ctx.changed[1].Minion == "" {
+		t.Error(spew.Sprintf("Unexpected placement: %v", ctx.changed))
+	}
+
+	// If the minion holding the data can't run the container, it isn't placed.
+	placements := []db.Placement{
+		{TargetLabel: "mysql", Exclusive: true, Region: "Region2"},
+	}
+	minions[1].Region = "Region2"
+	containers[0].Labels = []string{"mysql"}
+	ctx = makeContext(minions, placements, containers[:1])
+	placeUnassigned(ctx)
+	if len(ctx.changed) != 0 {
+		t.Error(spew.Sprintf("Unexpected placement: %v", ctx.changed))
+	}
+
+	// Replicas that share a named volume each have their own data, so only the
+	// replica that ran on minion 2 is pinned there, and the others are spread.
+	placements = []db.Placement{
+		{TargetLabel: "mysql", Exclusive: true, OtherLabel: "mysql"},
+	}
+	minions[1].Region = ""
+	containers = []db.Container{
+		{ID: 1, StitchID: 1, Labels: []string{"mysql"},
+			Volumes: []string{"mysql:/var/lib/mysql"}},
+		{ID: 2, StitchID: 2, Labels: []string{"mysql"},
+			Volumes: []string{"mysql:/var/lib/mysql"}},
+		{ID: 3, StitchID: 3, Labels: []string{"mysql"},
+			Volumes: []string{"mysql:/var/lib/mysql"}},
+	}
+	ctx = makeContext(minions, placements, containers)
+	placeUnassigned(ctx)
+
+	placed := map[int]string{}
+	for _, dbc := range ctx.changed {
+		placed[dbc.StitchID] = dbc.Minion
+	}
+	if len(placed) != 3 || placed[1] != "2" || placed[2] == placed[3] ||
+		placed[2] == "2" || placed[3] == "2" {
+		t.Error(spew.Sprintf("Unexpected placement: %v", ctx.changed))
+	}
+}
+
+func TestBinPack(t *testing.T) {
+	t.Parallel()
+
+	// m4.large minions have 2 cores and 8GB of memory.
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker, Size: "m4.large"},
+		{PrivateIP: "2", Role: db.Worker, Size: "m4.large"},
+	}
+	containers := []db.Container{
+		{ID: 1, CPU: 1, RAM: 2},
+		{ID: 2, CPU: 0.5, RAM: 2},
+		{ID: 3, CPU: 3},
+		{ID: 4, CPU: 1.5, RAM: 6},
+	}
+
+	ctx := makeContext(minions, nil, containers)
+	placeUnassigned(ctx)
+
+	placed := map[int]string{}
+	reasons := map[int]string{}
+	for _, dbc := range ctx.changed {
+		placed[dbc.ID] = dbc.Minion
+		reasons[dbc.ID] = dbc.Unplaced
+	}
+
+	// Container 3 is larger than any minion, so it can't be placed.  Container 2
+	// fills the space left by container 4 rather than sharing with container 1.
+	exp := map[int]string{1: "2", 2: "1", 3: "", 4: "1"}
+	if !eq(placed, exp) {
+		t.Error(spew.Sprintf("\nPlaced   %v\nExpected %v", placed, exp))
+	}
+
+	expReason := "Unschedulable container: no minion has the capacity to run it."
+	if reasons[3] != expReason {
+		t.Error(expLog("unplaced reason", reasons[3], expReason))
+	}
+
+	// Containers already running on a minion count against its capacity.
+	containers = []db.Container{
+		{ID: 1, CPU: 2, Minion: "1"},
+		{ID: 2, CPU: 1},
+	}
+	ctx = makeContext(minions, nil, containers)
+	placeUnassigned(ctx)
+	if len(ctx.changed) != 1 || ctx.changed[0].Minion != "2" {
+		t.Error(spew.Sprintf("Unexpected placement: %v", ctx.changed))
+	}
+}
+
+func TestPlaceInclusive(t *testing.T) {
+	t.Parallel()
+
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker},
+		{PrivateIP: "2", Role: db.Worker},
+		{PrivateIP: "3", Role: db.Worker},
+	}
+	containers := []db.Container{
+		// The shipper must wait for the cache, which must wait for the app.
+		{ID: 1, Labels: []string{"shipper"}},
+		{ID: 2, Labels: []string{"cache"}},
+		{ID: 3, Labels: []string{"app"}, Minion: "2"},
+
+		// The first red container may go anywhere, but the rest must follow it.
+		{ID: 4, Labels: []string{"red"}},
+		{ID: 5, Labels: []string{"red"}},
+
+		// No container is labeled "ghost", so the orphan can't be placed.
+		{ID: 6, Labels: []string{"orphan"}},
+	}
+	placements := []db.Placement{
+		{TargetLabel: "shipper", OtherLabel: "cache"},
+		{TargetLabel: "cache", OtherLabel: "app"},
+		{TargetLabel: "red", OtherLabel: "red"},
+		{TargetLabel: "orphan", OtherLabel: "ghost"},
+	}
+
+	ctx := makeContext(minions, placements, containers)
+	cleanupPlacements(ctx)
+	placeUnassigned(ctx)
+
+	placed := map[int]string{}
+	for _, dbc := range ctx.changed {
+		placed[dbc.ID] = dbc.Minion
+	}
+
+	if placed[1] != "2" || placed[2] != "2" {
+		t.Error(spew.Sprintf("Expected containers with the app: %v", placed))
+	}
+
+	if placed[4] == "" || placed[4] != placed[5] {
+		t.Error(spew.Sprintf("Expected red containers together: %v", placed))
+	}
+
+	if placed[6] != "" {
+		t.Error(spew.Sprintf("Unexpected placement of orphan: %v", placed))
+	}
+
+	// Once placed, the containers shouldn't move.
+	ctx = makeContext(minions, placements, containers)
+	cleanupPlacements(ctx)
+	placeUnassigned(ctx)
+	if len(ctx.changed) != 0 {
+		t.Error(spew.Sprintf("Unexpected changes: %v", ctx.changed))
+	}
+
+	// If the app leaves, the containers that follow it must be unassigned.
+	ctx = makeContext(minions, placements, containers[:2])
+	cleanupPlacements(ctx)
+	if len(ctx.changed) != 2 || ctx.changed[0].Minion != "" ||
+		ctx.changed[1].Minion != "" {
+		t.Error(spew.Sprintf("Unexpected cleanup: %v", ctx.changed))
+	}
+}
+
+func TestPlaceSpread(t *testing.T) {
+	t.Parallel()
+
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker, Provider: "Amazon", Region: "r1"},
+		{PrivateIP: "2", Role: db.Worker, Provider: "Amazon", Region: "r1"},
+		{PrivateIP: "3", Role: db.Worker, Provider: "Amazon", Region: "r2"},
+		{PrivateIP: "4", Role: db.Worker, Provider: "Google", Region: "r1"},
+	}
+
+	// Without a spread rule, replicas prefer minions, then regions, then
+	// providers that run fewer of their peers.
+	containers := []db.Container{
+		{ID: 1, Labels: []string{"zk"}, Minion: "1"},
+		{ID: 2, Labels: []string{"zk"}},
+		{ID: 3, Labels: []string{"zk"}},
+		{ID: 4, Labels: []string{"zk"}},
+	}
+	ctx := makeContext(minions, nil, containers)
+	placeUnassigned(ctx)
+
+	var placed []string
+	for _, dbc := range ctx.changed {
+		placed = append(placed, dbc.Minion)
+	}
+	sort.Strings(placed)
+	if exp := []string{"2", "3", "4"}; !eq(placed, exp) {
+		t.Error(spew.Sprintf("Placed on %v, expected %v", placed, exp))
+	}
+
+	// With a spread rule, a replica isn't placed if it would unbalance the
+	// providers by more than the maximum skew.  Minion 4 is full.
+	minions[3].Size = "m4.large"
+	containers = []db.Container{
+		{ID: 1, Labels: []string{"zk"}, Minion: "1"},
+		{ID: 2, Labels: []string{"zk"}, Minion: "2"},
+		{ID: 3, Labels: []string{"other"}, Minion: "4", CPU: 2},
+		{ID: 4, Labels: []string{"zk"}, CPU: 1},
+	}
+	placements := []db.Placement{
+		{TargetLabel: "zk", Spread: stitch.SpreadProvider, MaxSkew: 2},
+	}
+
+	ctx = makeContext(minions, placements, containers)
+	placeUnassigned(ctx)
+	if len(ctx.changed) != 1 || ctx.changed[0].Minion != "" ||
+		!strings.HasPrefix(ctx.changed[0].Unplaced, "Unsatisfiable spread") {
+		t.Error(spew.Sprintf("Unexpected placement: %v", ctx.changed))
+	}
+
+	placements[0].MaxSkew = 3
+	ctx = makeContext(minions, placements, containers)
+	placeUnassigned(ctx)
+	if len(ctx.changed) != 1 || ctx.changed[0].Minion != "3" {
+		t.Error(spew.Sprintf("Unexpected placement: %v", ctx.changed))
+	}
+
+	// Minions the label may not run on don't count towards the skew.
+	containers[3].Minion = ""
+	placements = []db.Placement{
+		{TargetLabel: "zk", Spread: stitch.SpreadProvider, MaxSkew: 1},
+		{TargetLabel: "zk", Exclusive: true, Provider: "Google"},
+	}
+	ctx = makeContext(minions, placements, containers)
+	placeUnassigned(ctx)
+	if len(ctx.changed) != 1 || ctx.changed[0].Minion != "3" {
+		t.Error(spew.Sprintf("Unexpected placement: %v", ctx.changed))
+	}
+}
+
 func TestMakeContext(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,202 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+
+	"github.com/NetSys/quilt/db"
+	log "github.com/Sirupsen/logrus"
+)
+
+// The rebalancer moves at most one container per interval, so that a new worker fills
+// gradually rather than restarting many containers at once.
+const rebalanceInterval = time.Minute
+
+// When the rebalancer last moved a container.
+var lastRebalance time.Time
+
+// rebalance moves a container of a label with a RebalanceRule from a busy minion to
+// an idle one, if doing so evens out their load and keeps every container validly
+// placed.  Nothing is moved while any container is waiting to be placed.
+func rebalance(ctx *context, now time.Time) {
+	if now.Sub(lastRebalance) < rebalanceInterval {
+		return
+	}
+
+	for _, dbc := range ctx.unassigned {
+		if dbc.Minion == "" {
+			return
+		}
+	}
+
+	if moveOne(ctx) {
+		lastRebalance = now
+	}
+}
+
+// moveOne moves a single container from the most loaded minion possible to the least
+// loaded one that may take it, and returns true if it did.
+func moveOne(ctx *context) bool {
+	movable := map[string]struct{}{}
+	for _, constraint := range ctx.constraints {
+		if constraint.Rebalance {
+			movable[constraint.TargetLabel] = struct{}{}
+		}
+	}
+
+	if len(movable) == 0 {
+		return false
+	}
+
+	// Cordoned minions keep the containers they have, and take no more.
+	byLoad := loadSlice{byResources: balanceByResources(ctx)}
+	for _, m := range ctx.minions {
+		if !m.Unschedulable {
+			byLoad.minions = append(byLoad.minions, m)
+		}
+	}
+	sort.Stable(byLoad)
+
+	for i := len(byLoad.minions) - 1; i > 0; i-- {
+		src := byLoad.minions[i]
+		for _, dbc := range src.containers {
+			if !isMovable(ctx, movable, dbc, byLoad.byResources) {
+				continue
+			}
+
+			for _, dst := range byLoad.minions[:i] {
+				if tryMove(ctx, src, dst, dbc, byLoad.byResources) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func isMovable(ctx *context, movable map[string]struct{}, dbc *db.Container,
+	byResources bool) bool {
+
+	// Moving a container that requests no resources doesn't change the load of
+	// minions balanced by their resources.
+	if byResources && dbc.CPU == 0 && dbc.RAM == 0 {
+		return false
+	}
+
+	// Containers must stay with their data.
+	if volumeMinions(ctx.minions, dbc) != nil {
+		return false
+	}
+
+	for _, label := range dbc.Labels {
+		if _, ok := movable[label]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// tryMove moves `dbc` from `src` to `dst` if `dst` would be left less loaded than
+// `src` is now, and every container on either would still be validly placed.
+func tryMove(ctx *context, src, dst *minion, dbc *db.Container,
+	byResources bool) bool {
+
+	if !dst.fits(dbc) {
+		return false
+	}
+
+	srcLoad := src.load(byResources)
+	src.remove(dbc)
+	dst.add(dbc)
+
+	valid := dst.load(byResources) < srcLoad &&
+		validSpread(ctx, applicableConstraints(ctx, dbc), *dst, dbc) &&
+		allValid(ctx, src) && allValid(ctx, dst)
+	if !valid {
+		dst.remove(dbc)
+		src.add(dbc)
+		return false
+	}
+
+	dbc.Minion = dst.PrivateIP
+	ctx.changed = append(ctx.changed, dbc)
+	log.WithFields(log.Fields{
+		"container": dbc,
+		"from":      src.PrivateIP,
+	}).Info("Rebalanced container.")
+	return true
+}
+
+// allValid returns true if every container on `m` satisfies its placement constraints.
+func allValid(ctx *context, m *minion) bool {
+	for _, dbc := range m.containers {
+		if !validPlacement(applicableConstraints(ctx, dbc), *m, dbc) {
+			return false
+		}
+	}
+	return true
+}
+
+// balanceByResources returns true if minions should be balanced by the fraction of
+// their capacity that's reserved, rather than by their number of containers.  This is
+// only possible if the size of every minion is known, and is only useful if some
+// container requests resources.
+func balanceByResources(ctx *context) bool {
+	requests := false
+	for _, m := range ctx.minions {
+		if m.capacity == (resources{}) {
+			return false
+		}
+
+		for _, dbc := range m.containers {
+			requests = requests || dbc.CPU != 0 || dbc.RAM != 0
+		}
+	}
+	return requests
+}
+
+// load returns the fraction of `m`'s capacity that's reserved, or its number of
+// containers.
+func (m minion) load(byResources bool) float64 {
+	if !byResources {
+		return float64(len(m.containers))
+	}
+
+	cpu := (m.capacity.cpu - m.free.cpu) / m.capacity.cpu
+	ram := (m.capacity.ram - m.free.ram) / m.capacity.ram
+	return (cpu + ram) / 2
+}
+
+func (m *minion) add(dbc *db.Container) {
+	m.reserve(dbc)
+	m.containers = append(m.containers, dbc)
+}
+
+func (m *minion) remove(dbc *db.Container) {
+	var containers []*db.Container
+	for _, c := range m.containers {
+		if c != dbc {
+			containers = append(containers, c)
+		}
+	}
+	m.release(dbc)
+	m.containers = containers
+}
+
+// loadSlice sorts minions from the least to the most loaded.
+type loadSlice struct {
+	minions     []*minion
+	byResources bool
+}
+
+func (s loadSlice) Len() int {
+	return len(s.minions)
+}
+
+func (s loadSlice) Swap(i, j int) {
+	s.minions[i], s.minions[j] = s.minions[j], s.minions[i]
+}
+
+func (s loadSlice) Less(i, j int) bool {
+	return s.minions[i].load(s.byResources) < s.minions[j].load(s.byResources)
+}
@@ -0,0 +1,200 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NetSys/quilt/db"
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestMoveOne(t *testing.T) {
+	t.Parallel()
+
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker},
+		{PrivateIP: "2", Role: db.Worker},
+		{PrivateIP: "3", Role: db.Worker},
+	}
+	containers := []db.Container{
+		{ID: 1, Labels: []string{"web"}, Minion: "1"},
+		{ID: 2, Labels: []string{"web"}, Minion: "1"},
+		{ID: 3, Labels: []string{"web"}, Minion: "1"},
+		{ID: 4, Labels: []string{"web"}, Minion: "1"},
+		{ID: 5, Labels: []string{"db"}, Minion: "2"},
+	}
+	placements := []db.Placement{{TargetLabel: "web", Rebalance: true}}
+
+	// Containers are moved one at a time until the load is even.
+	ctx := makeContext(minions, placements, containers)
+	for i := 0; i < 2; i++ {
+		if !moveOne(ctx) {
+			t.Fatalf("Expected move %d to succeed", i)
+		}
+	}
+
+	if moveOne(ctx) {
+		t.Error(spew.Sprintf("Unexpected move: %v", ctx.changed))
+	}
+
+	exp := map[string]int{"1": 2, "2": 2, "3": 1}
+	if counts := containerCounts(ctx); !eq(counts, exp) {
+		t.Errorf("Containers per minion %v, expected %v", counts, exp)
+	}
+
+	// Labels without a RebalanceRule stay put.
+	ctx = makeContext(minions, nil, containers)
+	if moveOne(ctx) {
+		t.Error(spew.Sprintf("Unexpected move: %v", ctx.changed))
+	}
+}
+
+func TestMoveOneConstraints(t *testing.T) {
+	t.Parallel()
+
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker, Region: "r1"},
+		{PrivateIP: "2", Role: db.Worker, Region: "r2"},
+		{PrivateIP: "3", Role: db.Worker, Region: "r1", Unschedulable: true},
+		{PrivateIP: "4", Role: db.Worker, Region: "r1",
+			Volumes: []string{"1:data"}},
+	}
+	containers := []db.Container{
+		{ID: 1, Labels: []string{"web"}, Minion: "1"},
+		{ID: 2, Labels: []string{"web"}, Minion: "1"},
+		{ID: 3, Labels: []string{"web"}, Minion: "1"},
+		{ID: 4, Labels: []string{"web"}, Minion: "1"},
+		{ID: 5, Labels: []string{"web"}, Minion: "4"},
+		{ID: 6, Labels: []string{"web"}, Minion: "4"},
+	}
+	placements := []db.Placement{
+		{TargetLabel: "web", Rebalance: true},
+		{TargetLabel: "web", Exclusive: true, Region: "r2"},
+	}
+
+	// Web can't run in r2, and minion 3 is cordoned, so the only move is from
+	// minion 1 to minion 4.
+	ctx := makeContext(minions, placements, containers)
+	if !moveOne(ctx) || moveOne(ctx) {
+		t.Error(spew.Sprintf("Unexpected moves: %v", ctx.changed))
+	}
+
+	exp := map[string]int{"1": 3, "2": 0, "3": 0, "4": 3}
+	if counts := containerCounts(ctx); !eq(counts, exp) {
+		t.Errorf("Containers per minion %v, expected %v", counts, exp)
+	}
+
+	// Containers stay with their data.
+	for i := range containers {
+		containers[i].Minion = "4"
+		containers[i].StitchID = i + 1
+		containers[i].Volumes = []string{"data:/data"}
+		minions[3].Volumes = append(minions[3].Volumes,
+			volumePin(containers[i].StitchID, "data:/data"))
+	}
+	ctx = makeContext(minions, placements, containers)
+	if moveOne(ctx) {
+		t.Error(spew.Sprintf("Unexpected move: %v", ctx.changed))
+	}
+
+	// Moving a container may not break the constraints of those it joins.
+	containers = []db.Container{
+		{ID: 1, Labels: []string{"web"}, Minion: "1"},
+		{ID: 2, Labels: []string{"web"}, Minion: "1"},
+		{ID: 3, Labels: []string{"web"}, Minion: "1"},
+		{ID: 4, Labels: []string{"db"}, Minion: "2"},
+	}
+	placements = []db.Placement{
+		{TargetLabel: "web", Rebalance: true},
+		{TargetLabel: "db", Exclusive: true, OtherLabel: "web"},
+	}
+	ctx = makeContext(minions[:2], placements, containers)
+	if moveOne(ctx) {
+		t.Error(spew.Sprintf("Unexpected move: %v", ctx.changed))
+	}
+}
+
+func TestMoveOneResources(t *testing.T) {
+	t.Parallel()
+
+	// m4.large minions have 2 cores and 8GB of memory.
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker, Size: "m4.large"},
+		{PrivateIP: "2", Role: db.Worker, Size: "m4.large"},
+	}
+	containers := []db.Container{
+		{ID: 1, Labels: []string{"web"}, Minion: "1", CPU: 1, RAM: 4},
+		{ID: 2, Labels: []string{"web"}, Minion: "1"},
+		{ID: 3, Labels: []string{"web"}, Minion: "1"},
+		{ID: 4, Labels: []string{"web"}, Minion: "2", CPU: 0.5, RAM: 2},
+	}
+	placements := []db.Placement{{TargetLabel: "web", Rebalance: true}}
+
+	// Minion 1 runs more containers, but moving its large one would leave minion 2
+	// as busy as minion 1 is now, and the others don't change the load.
+	ctx := makeContext(minions, placements, containers)
+	if moveOne(ctx) {
+		t.Error(spew.Sprintf("Unexpected move: %v", ctx.changed))
+	}
+
+	containers = append(containers, db.Container{
+		ID: 5, Labels: []string{"web"}, Minion: "1", CPU: 0.5, RAM: 2})
+	ctx = makeContext(minions, placements, containers)
+	if !moveOne(ctx) || len(ctx.changed) != 1 || ctx.changed[0].ID != 5 {
+		t.Error(spew.Sprintf("Unexpected moves: %v", ctx.changed))
+	}
+}
+
+func TestRebalanceInterval(t *testing.T) {
+	minions := []db.Minion{
+		{PrivateIP: "1", Role: db.Worker},
+		{PrivateIP: "2", Role: db.Worker},
+	}
+	placements := []db.Placement{{TargetLabel: "web", Rebalance: true}}
+	newContainers := func() []db.Container {
+		return []db.Container{
+			{ID: 1, Labels: []string{"web"}, Minion: "1"},
+			{ID: 2, Labels: []string{"web"}, Minion: "1"},
+			{ID: 3, Labels: []string{"web"}, Minion: "1"},
+			{ID: 4, Labels: []string{"web"}, Minion: "1"},
+		}
+	}
+
+	start := time.Now()
+	lastRebalance = time.Time{}
+
+	ctx := makeContext(minions, placements, newContainers())
+	rebalance(ctx, start)
+	if len(ctx.changed) != 1 {
+		t.Error(spew.Sprintf("Expected one move: %v", ctx.changed))
+	}
+
+	// Nothing more is moved until the interval has passed.
+	ctx = makeContext(minions, placements, newContainers())
+	rebalance(ctx, start.Add(rebalanceInterval/2))
+	if len(ctx.changed) != 0 {
+		t.Error(spew.Sprintf("Unexpected move: %v", ctx.changed))
+	}
+
+	// Nor while containers are waiting to be placed.
+	ctx = makeContext(minions, placements,
+		append(newContainers(), db.Container{ID: 5}))
+	rebalance(ctx, start.Add(rebalanceInterval))
+	if len(ctx.changed) != 0 {
+		t.Error(spew.Sprintf("Unexpected move: %v", ctx.changed))
+	}
+
+	ctx = makeContext(minions, placements, newContainers())
+	rebalance(ctx, start.Add(rebalanceInterval))
+	if len(ctx.changed) != 1 {
+		t.Error(spew.Sprintf("Expected one move: %v", ctx.changed))
+	}
+}
+
+func containerCounts(ctx *context) map[string]int {
+	counts := map[string]int{}
+	for _, m := range ctx.minions {
+		counts[m.PrivateIP] = len(m.containers)
+	}
+	return counts
+}
@@ -17,6 +17,7 @@ import (
 // Run blocks implementing the scheduler module.
 func Run(conn db.Conn, dk docker.Client) {
 	bootWait(conn)
+	go runHealthChecks(conn, dk)
 
 	loopLog := util.NewEventTimer("Scheduler")
 	trig := conn.TriggerTick(60, db.MinionTable, db.ContainerTable,
@@ -1,11 +1,17 @@
 package scheduler
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/join"
 	"github.com/NetSys/quilt/minion/docker"
+	"github.com/NetSys/quilt/minion/network"
+	"github.com/NetSys/quilt/stitch"
 	"github.com/NetSys/quilt/util"
 	log "github.com/Sirupsen/logrus"
 )
@@ -30,35 +36,71 @@ func runWorker(conn db.Conn, dk docker.Client, myIP string) {
 			return
 		}
 
+		volumes, err := dk.ListVolumes()
+		if err != nil {
+			log.WithError(err).Warning("Failed to list docker volumes.")
+			return
+		}
+
 		conn.Transact(func(view db.Database) error {
-			dbcs := view.SelectFromContainer(func(dbc db.Container) bool {
-				return dbc.Minion == myIP
-			})
+			dbcs := view.SelectFromContainerBy("Minion", myIP, nil)
+			secrets := view.SecretMap()
+			backoff.prune(dbcs)
 
 			var changed []db.Container
-			changed, toBoot, toKill = syncWorker(dbcs, dkcs)
+			changed, toBoot, toKill = syncWorker(dbcs, dkcs, secrets)
 			for _, dbc := range changed {
 				view.Commit(dbc)
 			}
+
+			recordVolumes(view, volumes)
 			return nil
 		})
 
+		toBoot = restartContainers(conn, dk, toBoot)
 		doContainers(dk, toBoot, dockerRun)
 		doContainers(dk, toKill, dockerKill)
 	}
 }
 
-func syncWorker(dbcs []db.Container, dkcs []docker.Container) (changed []db.Container,
+// syncWorker compares the containers scheduled on this worker with those running in
+// docker.  Secret references in the environment of `dbcs` are resolved with `secrets`
+// before the comparison, but the containers returned in `changed` keep their
+// references so that secret values are never written to the database.  Containers
+// that reference unknown secrets are compared without those variables, so that they
+// keep running if they already are, but aren't booted.
+func syncWorker(dbcs []db.Container, dkcs []docker.Container,
+	secrets map[string]string) (changed []db.Container,
 	toBoot, toKill []interface{}) {
 
-	pairs, dbci, dkci := join.Join(dbcs, dkcs, syncJoinScore)
+	var resolved []db.Container
+	envs := map[int]map[string]string{}
+	unresolved := map[int]error{}
+	for _, dbc := range dbcs {
+		env, err := resolveEnv(dbc.Env, secrets)
+		if err != nil {
+			unresolved[dbc.ID] = err
+		}
+
+		envs[dbc.ID] = dbc.Env
+		dbc.Env = env
+		resolved = append(resolved, dbc)
+	}
+
+	pairs, dbci, dkci := join.Join(resolved, dkcs, syncJoinScore)
 
 	for _, i := range dkci {
 		toKill = append(toKill, i.(docker.Container))
 	}
 
 	for _, i := range dbci {
-		toBoot = append(toBoot, i.(db.Container))
+		dbc := i.(db.Container)
+		if err, ok := unresolved[dbc.ID]; ok {
+			log.WithError(err).WithField("container", dbc).Warning(
+				"Failed to resolve container environment.")
+			continue
+		}
+		toBoot = append(toBoot, dbc)
 	}
 
 	for _, pair := range pairs {
@@ -66,6 +108,7 @@ func syncWorker(dbcs []db.Container, dkcs []docker.Container) (changed []db.Cont
 		dkc := pair.R.(docker.Container)
 
 		if dbc.DockerID != dkc.ID {
+			dbc.Env = envs[dbc.ID]
 			dbc.DockerID = dkc.ID
 			dbc.Pid = dkc.Pid
 			changed = append(changed, dbc)
@@ -75,6 +118,212 @@ func syncWorker(dbcs []db.Container, dkcs []docker.Container) (changed []db.Cont
 	return changed, toBoot, toKill
 }
 
+// restartContainers filters `toBoot` down to the containers that should be started
+// now.  Containers that ran before are restarted according to their restart policy,
+// backing off exponentially so that a crashing container doesn't monopolize the worker.
+func restartContainers(conn db.Conn, dk docker.Client,
+	toBoot []interface{}) []interface{} {
+
+	var boot []interface{}
+	exited := map[int]struct{}{}
+	restarted := map[int]struct{}{}
+	for _, i := range toBoot {
+		dbc := i.(db.Container)
+		if dbc.DockerID == "" {
+			boot = append(boot, dbc)
+			continue
+		}
+
+		if !shouldRestart(dk, dbc) {
+			exited[dbc.ID] = struct{}{}
+			continue
+		}
+
+		if !backoff.ready(dbc.ID, dbc.Restarts) {
+			continue
+		}
+
+		// The old container is removed as it would otherwise accumulate with
+		// each restart.
+		if err := dk.RemoveID(dbc.DockerID); err != nil {
+			log.WithError(err).WithField("id", dbc.DockerID).Debug(
+				"Failed to remove exited container.")
+		}
+
+		restarted[dbc.ID] = struct{}{}
+		boot = append(boot, dbc)
+	}
+
+	if len(exited) == 0 && len(restarted) == 0 {
+		return boot
+	}
+
+	conn.Transact(func(view db.Database) error {
+		for _, dbc := range view.SelectFromContainer(nil) {
+			_, isExited := exited[dbc.ID]
+			_, isRestarted := restarted[dbc.ID]
+			switch {
+			case isExited && dbc.Health != db.HealthExited:
+				dbc.Health = db.HealthExited
+			case isRestarted:
+				dbc.Restarts++
+				dbc.Health = ""
+				if !dbc.HealthCheck.Empty() {
+					dbc.Health = db.HealthStarting
+				}
+			default:
+				continue
+			}
+			view.Commit(dbc)
+		}
+		return nil
+	})
+
+	return boot
+}
+
+// shouldRestart decides, based on its restart policy, whether the container `dbc`
+// should be started again now that its docker container has stopped.
+func shouldRestart(dk docker.Client, dbc db.Container) bool {
+	switch dbc.RestartPolicy {
+	case db.RestartNever:
+		return false
+	case db.RestartOnFailure:
+		// A container that no longer exists was removed after failing its
+		// health check.
+		dkc, err := dk.Get(dbc.DockerID)
+		return err != nil || dkc.ExitCode != 0
+	default:
+		return true
+	}
+}
+
+const minBackoff = time.Second
+const maxBackoff = 5 * time.Minute
+
+// restartBackoff tracks when each container was last restarted.
+type restartBackoff struct {
+	sync.Mutex
+	last map[int]time.Time
+}
+
+var backoff = restartBackoff{last: map[int]time.Time{}}
+
+// ready reports whether a container that has been restarted `restarts` times may be
+// restarted again, and if so records that it is.
+func (b *restartBackoff) ready(id, restarts int) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	delay := maxBackoff
+	if restarts < 16 {
+		delay = minBackoff << uint(restarts)
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	now := time.Now()
+	if last, ok := b.last[id]; ok && now.Sub(last) < delay {
+		return false
+	}
+	b.last[id] = now
+	return true
+}
+
+// prune forgets the restarts of containers other than `dbcs`, so that containers that
+// have been removed, or moved to another minion, don't accumulate.
+func (b *restartBackoff) prune(dbcs []db.Container) {
+	b.Lock()
+	defer b.Unlock()
+
+	live := map[int]struct{}{}
+	for _, dbc := range dbcs {
+		live[dbc.ID] = struct{}{}
+	}
+
+	for id := range b.last {
+		if _, ok := live[id]; !ok {
+			delete(b.last, id)
+		}
+	}
+}
+
+// recordVolumes records the volumes of the containers that have run on this minion, so
+// that the scheduler keeps each container with its own data.  Volumes are forgotten
+// once their container has left this minion, unless they're named volumes that docker
+// still reports in `dkVolumes`.
+func recordVolumes(view db.Database, dkVolumes []string) {
+	self, err := view.MinionSelf()
+	if err != nil {
+		return
+	}
+
+	named := map[string]struct{}{}
+	for _, name := range dkVolumes {
+		named[name] = struct{}{}
+	}
+
+	pins := map[string]struct{}{}
+	for _, pin := range self.Volumes {
+		if _, ok := named[strings.SplitN(pin, ":", 2)[1]]; ok {
+			pins[pin] = struct{}{}
+		}
+	}
+
+	for _, dbc := range view.SelectFromContainerBy("Minion", self.PrivateIP, nil) {
+		if dbc.DockerID == "" {
+			continue
+		}
+
+		for _, bind := range dbc.Volumes {
+			pins[volumePin(dbc.StitchID, bind)] = struct{}{}
+		}
+	}
+
+	var volumes []string
+	for pin := range pins {
+		volumes = append(volumes, pin)
+	}
+	sort.Strings(volumes)
+
+	if !util.StrSliceEqual(volumes, self.Volumes) {
+		self.Volumes = volumes
+		view.Commit(self)
+	}
+}
+
+// volumePin identifies the data of the volume in the docker bind specification `bind`
+// of the container with `stitchID`.  It's the StitchID and source of the volume,
+// separated by a colon, as replicas with the same named volume each have their own data.
+func volumePin(stitchID int, bind string) string {
+	return fmt.Sprintf("%d:%s", stitchID, strings.SplitN(bind, ":", 2)[0])
+}
+
+// resolveEnv returns a copy of `env` with each secret reference replaced by its
+// value in `secrets`.  If any secret is unknown, an error is returned along with the
+// environment that omits the variables referencing it.
+func resolveEnv(env, secrets map[string]string) (map[string]string, error) {
+	if len(env) == 0 {
+		return env, nil
+	}
+
+	var err error
+	resolved := map[string]string{}
+	for key, value := range env {
+		if name, ok := stitch.SecretName(value); ok {
+			secret, ok := secrets[name]
+			if !ok {
+				err = fmt.Errorf("unknown secret: %s", name)
+				continue
+			}
+			value = secret
+		}
+		resolved[key] = value
+	}
+	return resolved, err
+}
+
 func doContainers(dk docker.Client, containers []interface{},
 	do func(docker.Client, chan interface{})) {
 
@@ -103,7 +352,12 @@ func dockerRun(dk docker.Client, in chan interface{}) {
 			Image:  dbc.Image,
 			Args:   dbc.Command,
 			Env:    dbc.Env,
+			Binds:  dbc.Volumes,
 			Labels: map[string]string{labelKey: labelValue},
+			DNS:    []string{network.GatewayIP},
+
+			CPUQuota: docker.CPUQuota(dbc.CPU),
+			Memory:   docker.MemoryLimit(dbc.RAM),
 		})
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -148,6 +402,11 @@ func syncJoinScore(left, right interface{}) int {
 	switch {
 	case dbc.Image != dkc.Image:
 		return -1
+	case !util.StrSliceEqual(dbc.Volumes, dkc.Binds):
+		return -1
+	case docker.CPUQuota(dbc.CPU) != dkc.CPUQuota ||
+		docker.MemoryLimit(dbc.RAM) != dkc.Memory:
+		return -1
 	case len(dbcCmd) != 0 &&
 		!util.StrSliceEqual(dbcCmd, cmd1) &&
 		!util.StrSliceEqual(dbcCmd, cmd2):
@@ -1,10 +1,13 @@
 package scheduler
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/minion/docker"
+	"github.com/NetSys/quilt/stitch"
 	"github.com/davecgh/go-spew/spew"
 )
 
@@ -57,7 +60,7 @@ func TestRunWorker(t *testing.T) {
 func runSync(dk docker.Client, dbcs []db.Container,
 	dkcs []docker.Container) []db.Container {
 
-	changes, tdbcs, tdkcs := syncWorker(dbcs, dkcs)
+	changes, tdbcs, tdkcs := syncWorker(dbcs, dkcs, nil)
 	doContainers(dk, tdkcs, dockerKill)
 	doContainers(dk, tdbcs, dockerRun)
 	return changes
@@ -86,7 +89,7 @@ func TestSyncWorker(t *testing.T) {
 
 	runSync(dk, dbcs, nil)
 	dkcs, err := dk.List(nil)
-	changed, _, _ = syncWorker(dbcs, dkcs)
+	changed, _, _ = syncWorker(dbcs, dkcs, nil)
 	if err != nil {
 		t.Errorf("Unexpected err %v", err)
 	}
@@ -157,6 +160,111 @@ func TestSyncWorker(t *testing.T) {
 	}
 }
 
+func TestSyncWorkerSecrets(t *testing.T) {
+	t.Parallel()
+
+	_, dk := docker.NewMock()
+
+	ref := stitch.SecretPrefix + "password"
+	dbcs := []db.Container{
+		{
+			ID:    1,
+			Image: "Image1",
+			Env:   map[string]string{"PASSWORD": ref},
+		},
+	}
+
+	// The secret hasn't been set, so the container can't be started.
+	_, toBoot, _ := syncWorker(dbcs, nil, nil)
+	if len(toBoot) > 0 {
+		t.Error(spew.Sprintf("Unexpected containers to boot: %v", toBoot))
+	}
+
+	secrets := map[string]string{"password": "hunter2"}
+	_, toBoot, _ = syncWorker(dbcs, nil, secrets)
+	doContainers(dk, toBoot, dockerRun)
+
+	dkcs, err := dk.List(nil)
+	if err != nil {
+		t.Errorf("Unexpected err %v", err)
+	}
+	if len(dkcs) != 1 || dkcs[0].Env["PASSWORD"] != "hunter2" {
+		t.Error(spew.Sprintf("Unexpected containers: %v", dkcs))
+	}
+
+	changed, toBoot, toKill := syncWorker(dbcs, dkcs, secrets)
+	if len(toBoot) > 0 || len(toKill) > 0 {
+		t.Error(spew.Sprintf("Unexpected changes: %v %v", toBoot, toKill))
+	}
+
+	// The secret's value must not leak into the database.
+	if len(changed) != 1 || changed[0].Env["PASSWORD"] != ref {
+		t.Error(spew.Sprintf("Unexpected changed containers: %v", changed))
+	}
+
+	// Changing the secret restarts the container with the new value.
+	secrets["password"] = "letmein"
+	_, toBoot, toKill = syncWorker(dbcs, dkcs, secrets)
+	if len(toBoot) != 1 || len(toKill) != 1 {
+		t.Error(spew.Sprintf("Unexpected changes: %v %v", toBoot, toKill))
+	}
+
+	// A running container is left alone if its secret disappears, such as when
+	// the daemon restarts, but it can't be started again until the secret is set.
+	_, toBoot, toKill = syncWorker(dbcs, dkcs, nil)
+	if len(toBoot) > 0 || len(toKill) > 0 {
+		t.Error(spew.Sprintf("Unexpected changes: %v %v", toBoot, toKill))
+	}
+
+	_, toBoot, toKill = syncWorker(dbcs, nil, nil)
+	if len(toBoot) > 0 || len(toKill) > 0 {
+		t.Error(spew.Sprintf("Unexpected changes: %v %v", toBoot, toKill))
+	}
+}
+
+func TestRecordVolumes(t *testing.T) {
+	t.Parallel()
+
+	conn := db.New()
+	conn.Transact(func(view db.Database) error {
+		self := view.InsertMinion()
+		self.Self = true
+		self.PrivateIP = "1.2.3.4"
+		self.Volumes = []string{"3:zookeeper", "4:/var/log", "5:kafka"}
+		view.Commit(self)
+
+		for id, volumes := range map[int][]string{
+			1: {"mysql:/var/lib/mysql", "/etc:/etc"},
+			2: {"mysql:/var/lib/mysql"},
+		} {
+			dbc := view.InsertContainer()
+			dbc.StitchID = id
+			dbc.Minion = "1.2.3.4"
+			dbc.DockerID = "DockerID"
+			dbc.Volumes = volumes
+			view.Commit(dbc)
+		}
+
+		// Containers that haven't started here have no data here yet.
+		dbc := view.InsertContainer()
+		dbc.StitchID = 6
+		dbc.Minion = "1.2.3.4"
+		dbc.Volumes = []string{"mysql:/var/lib/mysql"}
+		view.Commit(dbc)
+
+		// Named volumes that docker no longer reports, and host directories
+		// whose containers have left, are forgotten.
+		recordVolumes(view, []string{"mysql", "zookeeper"})
+		return nil
+	})
+
+	self, _ := conn.MinionSelf()
+	exp := []string{"1:/etc", "1:mysql", "2:mysql", "3:zookeeper"}
+	if !eq(self.Volumes, exp) {
+		t.Error(expLog("Unexpected volumes", self.Volumes, exp))
+	}
+}
+
 func TestSyncJoinScore(t *testing.T) {
 	t.Parallel()
 
@@ -199,6 +307,30 @@ func TestSyncJoinScore(t *testing.T) {
 	}
 	dbc.Env = dkc.Env
 
+	dbc.Volumes = []string{"data:/data"}
+	score = syncJoinScore(dbc, dkc)
+	if score != -1 {
+		t.Errorf("Unexpected score %d", score)
+	}
+	dkc.Binds = dbc.Volumes
+	score = syncJoinScore(dbc, dkc)
+	if score != 0 {
+		t.Errorf("Unexpected score %d", score)
+	}
+
+	dbc.CPU = 1.5
+	dbc.RAM = 0.5
+	score = syncJoinScore(dbc, dkc)
+	if score != -1 {
+		t.Errorf("Unexpected score %d", score)
+	}
+	dkc.CPUQuota = 150000
+	dkc.Memory = 512 * 1024 * 1024
+	score = syncJoinScore(dbc, dkc)
+	if score != 0 {
+		t.Errorf("Unexpected score %d", score)
+	}
+
 	dbc.DockerID = "2"
 	score = syncJoinScore(dbc, dkc)
 	if score != 1 {
@@ -210,3 +342,114 @@ func TestSyncJoinScore(t *testing.T) {
 func expLog(msg string, got, exp interface{}) string {
 	return spew.Sprintf("%s\nGot: %s\nExp: %s\n", msg, got, exp)
 }
+
+func TestRestartPolicy(t *testing.T) {
+	md, dk := docker.NewMock()
+	conn := db.New()
+
+	policies := []string{db.RestartAlways, db.RestartOnFailure, db.RestartNever}
+	conn.Transact(func(view db.Database) error {
+		for _, policy := range policies {
+			dbc := view.InsertContainer()
+			dbc.Image = policy
+			dbc.Minion = "1.2.3.4"
+			dbc.RestartPolicy = policy
+			view.Commit(dbc)
+		}
+		return nil
+	})
+
+	exitAll := func(code int) {
+		dkcs, _ := dk.List(nil)
+		for _, dkc := range dkcs {
+			md.ExitContainer(dkc.ID, code)
+		}
+	}
+
+	runWorker(conn, dk, "1.2.3.4")
+	exitAll(0)
+	runWorker(conn, dk, "1.2.3.4")
+
+	images := func() map[string]struct{} {
+		dkcs, _ := dk.List(nil)
+		res := map[string]struct{}{}
+		for _, dkc := range dkcs {
+			res[dkc.Image] = struct{}{}
+		}
+		return res
+	}
+
+	exp := map[string]struct{}{db.RestartAlways: {}}
+	if got := images(); !reflect.DeepEqual(got, exp) {
+		t.Error(expLog("running containers", got, exp))
+	}
+
+	health := map[string]string{}
+	restarts := map[string]int{}
+	for _, dbc := range conn.SelectFromContainer(nil) {
+		health[dbc.Image] = dbc.Health
+		restarts[dbc.Image] = dbc.Restarts
+	}
+
+	expHealth := map[string]string{
+		db.RestartAlways:    "",
+		db.RestartOnFailure: db.HealthExited,
+		db.RestartNever:     db.HealthExited,
+	}
+	if !reflect.DeepEqual(health, expHealth) {
+		t.Error(expLog("health", health, expHealth))
+	}
+
+	expRestarts := map[string]int{
+		db.RestartAlways:    1,
+		db.RestartOnFailure: 0,
+		db.RestartNever:     0,
+	}
+	if !reflect.DeepEqual(restarts, expRestarts) {
+		t.Error(expLog("restarts", restarts, expRestarts))
+	}
+
+	// The second restart is delayed by the backoff.
+	exitAll(1)
+	runWorker(conn, dk, "1.2.3.4")
+	if got := images(); len(got) != 0 {
+		t.Error(expLog("running containers", got, map[string]struct{}{}))
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	t.Parallel()
+
+	b := restartBackoff{last: map[int]time.Time{}}
+	if !b.ready(1, 0) {
+		t.Error("Expected the first restart to be allowed")
+	}
+
+	if b.ready(1, 0) {
+		t.Error("Expected an immediate second restart to be delayed")
+	}
+
+	b.last[1] = time.Now().Add(-3 * time.Second)
+	if b.ready(1, 2) {
+		t.Error("Expected the third restart to wait four seconds")
+	}
+
+	if !b.ready(1, 1) {
+		t.Error("Expected the second restart to wait two seconds")
+	}
+
+	b.last[1] = time.Now().Add(-maxBackoff)
+	if !b.ready(1, 100) {
+		t.Error("Expected the backoff to be capped")
+	}
+
+	b.ready(2, 0)
+	b.prune([]db.Container{{ID: 2}})
+	if _, ok := b.last[1]; ok {
+		t.Error("Expected the removed container to be forgotten")
+	}
+
+	if _, ok := b.last[2]; !ok {
+		t.Error("Expected the running container to be remembered")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/minion/pb"
 
@@ -16,13 +17,21 @@ import (
 
 type server struct {
 	db.Conn
+
+	creds *certs.Store
 }
 
-func minionServerRun(conn db.Conn) {
+// minionServerRun serves the foreman's requests.  Only Admins, the daemon and its
+// foremen, may make them.
+func minionServerRun(conn db.Conn, creds *certs.Store) {
+	security, err := creds.ServerOption()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load TLS credentials.")
+	}
+
 	var sock net.Listener
-	server := server{conn}
+	server := server{conn, creds}
 	for {
-		var err error
 		sock, err = net.Listen("tcp", ":9999")
 		if err != nil {
 			log.WithError(err).Error("Failed to open socket.")
@@ -33,7 +42,7 @@ func minionServerRun(conn db.Conn) {
 		time.Sleep(30 * time.Second)
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(security, certs.AdminOnly())
 	pb.RegisterMinionServer(s, server)
 	s.Serve(sock)
 }
@@ -50,10 +59,16 @@ func (s server) GetMinionConfig(cts context.Context,
 		cfg.Provider = m.Provider
 		cfg.Size = m.Size
 		cfg.Region = m.Region
+		cfg.Unschedulable = m.Unschedulable
+		cfg.Draining = m.Draining
 	} else {
 		cfg.Role = db.RoleToPB(db.None)
 	}
 
+	if secrets := s.SecretMap(); len(secrets) > 0 {
+		cfg.Secrets = secrets
+	}
+
 	return &cfg, nil
 }
 
@@ -72,15 +87,47 @@ func (s server) SetMinionConfig(ctx context.Context,
 		minion.Provider = msg.Provider
 		minion.Size = msg.Size
 		minion.Region = msg.Region
+		minion.Unschedulable = msg.Unschedulable
+		minion.Draining = msg.Draining
 		minion.Self = true
 		view.Commit(minion)
 
+		updateSecrets(view, msg.Secrets)
 		return nil
 	})
 
 	return &pb.Reply{Success: true}, nil
 }
 
+// updateSecrets makes the secret table match `secrets`.
+func updateSecrets(view db.Database, secrets map[string]string) {
+	seen := map[string]struct{}{}
+	for _, secret := range view.SelectFromSecret(nil) {
+		value, ok := secrets[secret.Name]
+		if !ok {
+			view.Remove(secret)
+			continue
+		}
+
+		seen[secret.Name] = struct{}{}
+		if secret.Value != value {
+			secret.Value = value
+			view.Commit(secret)
+		}
+	}
+
+	for name, value := range secrets {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+
+		secret := view.InsertSecret()
+		secret.Name = name
+		secret.Value = value
+		view.Commit(secret)
+	}
+}
+
 func (s server) BootEtcd(ctx context.Context,
 	members *pb.EtcdMembers) (*pb.Reply, error) {
 	go s.Transact(func(view db.Database) error {
@@ -105,3 +152,16 @@ func (s server) BootEtcd(ctx context.Context,
 
 	return &pb.Reply{Success: true}, nil
 }
+
+// SetCertificate replaces the minion's self-signed certificate with one signed by the
+// cluster's authority.
+func (s server) SetCertificate(ctx context.Context,
+	cert *pb.Certificate) (*pb.Reply, error) {
+
+	if err := s.creds.SetCert([]byte(cert.Cert)); err != nil {
+		return &pb.Reply{Success: false, Error: err.Error()}, nil
+	}
+
+	log.Info("Received signed certificate.")
+	return &pb.Reply{Success: true}, nil
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/NetSys/quilt/api"
 	"github.com/NetSys/quilt/api/client"
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/db"
 )
 
@@ -179,7 +180,7 @@ func scp(host string, source string, target string) error {
 }
 
 func queryMachines() ([]db.Machine, error) {
-	c, err := client.New(api.DefaultSocket)
+	c, err := client.New(api.DefaultSocket, certs.Credentials{})
 	if err != nil {
 		return []db.Machine{}, err
 	}
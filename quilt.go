@@ -11,6 +11,7 @@ import (
 
 	"github.com/NetSys/quilt/api"
 	"github.com/NetSys/quilt/api/server"
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/cluster"
 	"github.com/NetSys/quilt/db"
 	"github.com/NetSys/quilt/minion"
@@ -26,11 +27,14 @@ func main() {
 	flag.Usage = func() {
 		fmt.Println("Usage: quilt " +
 			"[-log-level=<level> | -l=<level>] [-H=<listen_address>] " +
-			"[log-file=<log_output_file>] " +
+			"[log-file=<log_output_file>] [-db-file=<database_file>] " +
+			"[-tls-dir=<tls_directory>] " +
 			"[daemon | inspect <stitch> | run <stitch> | minion | " +
 			"stop <namespace> | get <import_path> | " +
 			"machines | containers | ssh <machine> | " +
-			"exec <container> <command>]")
+			"exec <container> <command> | secret set <name> [value] | " +
+			"rollout status [label] | logs <container | label> | " +
+			"cordon <machine> | drain <machine>]")
 		fmt.Println("\nWhen provided a stitch, quilt takes responsibility\n" +
 			"for deploying it as specified.  Alternatively, quilt may be\n" +
 			"instructed to stop all deployments in a given namespace,\n" +
@@ -45,6 +49,11 @@ func main() {
 	flag.StringVar(logLevel, "l", "info", "level to set logger to")
 	var lAddr = flag.String("H", api.DefaultSocket,
 		"Socket to listen for API requests on.")
+	var dbFile = flag.String("db-file", "",
+		"file in which the daemon persists its database across restarts")
+	var tlsDir = flag.String("tls-dir", certs.DefaultDir(),
+		"directory holding the TLS certificates used to authenticate with "+
+			"remote machines")
 	flag.Parse()
 
 	level, err := parseLogLevel(*logLevel)
@@ -79,20 +88,40 @@ func main() {
 	subcommand := flag.Arg(0)
 	switch {
 	case subcommand == "minion":
-		minion.Run()
+		creds, err := certs.LoadStore(*tlsDir)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to load TLS credentials.")
+		}
+		minion.Run(creds)
 	case subcommand == "daemon":
-		runDaemon(*lAddr)
+		runDaemon(*lAddr, *dbFile, *tlsDir)
 	case quiltctl.HasSubcommand(subcommand):
-		quiltctl.Run(flag.Args())
+		quiltctl.Run(flag.Args(), *tlsDir)
 	default:
 		usage()
 	}
 }
 
-func runDaemon(lAddr string) {
-	conn := db.New()
-	go server.Run(conn, lAddr)
-	cluster.Run(conn)
+func runDaemon(lAddr, dbFile, tlsDir string) {
+	ca, creds, err := certs.Setup(tlsDir)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to set up TLS credentials in %s.",
+			tlsDir)
+	}
+
+	var conn db.Conn
+	if dbFile == "" {
+		conn = db.New()
+	} else {
+		var err error
+		conn, err = db.Open(dbFile)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to open database file %s.",
+				dbFile)
+		}
+	}
+	go server.Run(conn, lAddr, creds)
+	cluster.Run(conn, ca)
 }
 
 func usage() {
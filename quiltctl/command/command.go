@@ -8,6 +8,7 @@ import (
 
 	"github.com/NetSys/quilt/api"
 	"github.com/NetSys/quilt/api/client"
+	"github.com/NetSys/quilt/certs"
 )
 
 // SubCommand defines the conversion between the user CLI flags and
@@ -25,9 +26,22 @@ type SubCommand interface {
 	Usage()
 }
 
+// The ANSI escape sequence that clears the terminal, used when redrawing watched
+// output.
+const clearScreen = "\033[H\033[2J"
+
+// The credentials used to authenticate with remote machines.
+var credentials certs.Credentials
+
+// SetCredentials sets the credentials subcommands use to authenticate with remote
+// machines.
+func SetCredentials(creds certs.Credentials) {
+	credentials = creds
+}
+
 // Stored in a variable so we can mock it out for the unit tests.
 var getClient = func(host string) (client.Client, error) {
-	c, err := client.New(host)
+	c, err := client.New(host, credentials)
 	if err != nil {
 		return nil, DaemonConnectError{
 			host:         host,
@@ -1,16 +1,23 @@
 package command
 
 import (
+	"bytes"
 	"errors"
-	"os/exec"
+	"io"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 
 	"github.com/NetSys/quilt/api"
 	"github.com/NetSys/quilt/api/client"
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/engine"
+	"github.com/NetSys/quilt/minion/docker"
 	"github.com/NetSys/quilt/util"
 )
 
@@ -31,6 +38,12 @@ func TestMachineFlags(t *testing.T) {
 		t.Errorf("Expected machine command to parse arg %s, but got %s",
 			expHost, machineCmd.host)
 	}
+
+	machineCmd = Machine{}
+	machineCmd.Parse([]string{"-w"})
+	if !machineCmd.watch {
+		t.Error("Expected machine command to parse -w")
+	}
 }
 
 func TestMachineOutput(t *testing.T) {
@@ -51,6 +64,41 @@ func TestMachineOutput(t *testing.T) {
 	}
 }
 
+func TestMachineNamespace(t *testing.T) {
+	t.Parallel()
+
+	machines := []db.Machine{
+		{ID: 1, Namespace: "staging"},
+		{ID: 2, Namespace: "production"},
+	}
+
+	res := filterNamespace(machines, "production")
+	if !reflect.DeepEqual(res, []db.Machine{machines[1]}) {
+		t.Errorf("Got %v, expected %v", res, []db.Machine{machines[1]})
+	}
+}
+
+func TestMachineWatch(t *testing.T) {
+	machinesChan := make(chan []db.Machine, 2)
+	machinesChan <- []db.Machine{{ID: 1, Namespace: "staging"}}
+	machinesChan <- []db.Machine{{ID: 2, Namespace: "production"}}
+	close(machinesChan)
+
+	c := &mockClient{machineWatch: machinesChan}
+	getClient = func(host string) (client.Client, error) {
+		return c, nil
+	}
+
+	machineCmd := &Machine{watch: true}
+	if exitCode := machineCmd.Run(); exitCode != 1 {
+		t.Errorf("Expected exit code 1 once the stream ends, got %d", exitCode)
+	}
+
+	if len(machinesChan) != 0 {
+		t.Error("Expected machine command to consume every update")
+	}
+}
+
 func TestContainerFlags(t *testing.T) {
 	t.Parallel()
 
@@ -68,6 +116,12 @@ func TestContainerFlags(t *testing.T) {
 		t.Errorf("Expected container command to parse arg %s, but got %s",
 			expHost, containerCmd.host)
 	}
+
+	containerCmd = Container{}
+	containerCmd.Parse([]string{"-w"})
+	if !containerCmd.watch {
+		t.Error("Expected container command to parse -w")
+	}
 }
 
 func TestContainerOutput(t *testing.T) {
@@ -142,6 +196,12 @@ func TestRunFlags(t *testing.T) {
 	checkRunParsing(t, []string{"-stitch", expStitch}, expStitch, nil)
 	checkRunParsing(t, []string{expStitch}, expStitch, nil)
 	checkRunParsing(t, []string{}, "", errors.New("no spec specified"))
+
+	runCmd := Run{}
+	runCmd.Parse([]string{"-plan", expStitch})
+	if !runCmd.plan || runCmd.stitch != expStitch {
+		t.Errorf("Expected run command to parse -plan, but got %v", runCmd)
+	}
 }
 
 func checkStopParsing(t *testing.T, args []string, expNamespace string, expErr error) {
@@ -216,7 +276,7 @@ func TestSSHFlags(t *testing.T) {
 }
 
 func checkExecParsing(t *testing.T, args []string, expContainer int,
-	expKey string, expCmd string, expErr error) {
+	expCmd []string, expErr error) {
 
 	execCmd := Exec{}
 	err := execCmd.Parse(args)
@@ -239,27 +299,24 @@ func checkExecParsing(t *testing.T, args []string, expContainer int,
 			expContainer, execCmd.targetContainer)
 	}
 
-	if execCmd.command != expCmd {
-		t.Errorf("Expected exec command to parse command %s, but got %s",
+	if !reflect.DeepEqual(execCmd.command, expCmd) {
+		t.Errorf("Expected exec command to parse command %v, but got %v",
 			expCmd, execCmd.command)
 	}
-
-	if execCmd.privateKey != expKey {
-		t.Errorf("Expected exec command to parse private key %s, but got %s",
-			expKey, execCmd.privateKey)
-	}
 }
 
 func TestExecFlags(t *testing.T) {
 	t.Parallel()
 
-	checkExecParsing(t, []string{"1", "sh"}, 1, "", "sh", nil)
-	checkExecParsing(t, []string{"-i", "key", "1", "sh"}, 1, "key", "sh", nil)
-	checkExecParsing(t, []string{"1", "cat /etc/hosts"}, 1, "",
-		"cat /etc/hosts", nil)
-	checkExecParsing(t, []string{"1"}, 0, "", "",
+	checkExecParsing(t, []string{"1", "sh"}, 1, []string{"sh"}, nil)
+	checkExecParsing(t, []string{"-H", "host", "1", "sh"}, 1, []string{"sh"}, nil)
+	checkExecParsing(t, []string{"1", "cat", "/etc/hosts"}, 1,
+		[]string{"cat", "/etc/hosts"}, nil)
+	checkExecParsing(t, []string{"1", "sh", "-c", "ls | wc"}, 1,
+		[]string{"sh", "-c", "ls | wc"}, nil)
+	checkExecParsing(t, []string{"1"}, 0, nil,
 		errors.New("must specify a target container and command"))
-	checkExecParsing(t, []string{}, 0, "", "",
+	checkExecParsing(t, []string{}, 0, nil,
 		errors.New("must specify a target container and command"))
 }
 
@@ -268,6 +325,22 @@ type mockClient struct {
 	containerReturn []db.Container
 	etcdReturn      []db.Etcd
 	runStitchArg    string
+	namespaceArg    string
+	planReturn      engine.Plan
+	planStitchArg   string
+	machineWatch    chan []db.Machine
+	containerWatch  chan []db.Container
+	secrets         map[string]string
+	rolloutReturn   []db.Rollout
+	logs            map[int]string
+	execReturn      map[int]int
+	execOpts        docker.ExecOptions
+	cordonArg       cordonArg
+}
+
+type cordonArg struct {
+	machineID            int
+	unschedulable, drain bool
 }
 
 func (c *mockClient) QueryMachines() ([]db.Machine, error) {
@@ -282,15 +355,94 @@ func (c *mockClient) QueryEtcd() ([]db.Etcd, error) {
 	return c.etcdReturn, nil
 }
 
+func (c *mockClient) QueryLabels() ([]db.Label, error) {
+	return nil, nil
+}
+
+func (c *mockClient) QueryConnections() ([]db.Connection, error) {
+	return nil, nil
+}
+
+func (c *mockClient) QueryPlacements() ([]db.Placement, error) {
+	return nil, nil
+}
+
+func (c *mockClient) QueryMinions() ([]db.Minion, error) {
+	return nil, nil
+}
+
+func (c *mockClient) QueryClusters() ([]db.Cluster, error) {
+	return nil, nil
+}
+
+func (c *mockClient) QueryRollouts() ([]db.Rollout, error) {
+	return c.rolloutReturn, nil
+}
+
+func (c *mockClient) ContainerLogs(stitchID int, follow bool, since time.Time,
+	w io.Writer) error {
+
+	logs, ok := c.logs[stitchID]
+	if !ok {
+		return errors.New("no such container")
+	}
+
+	_, err := io.WriteString(w, logs)
+	return err
+}
+
+func (c *mockClient) Exec(stitchID int, opts docker.ExecOptions) (int, error) {
+	if _, ok := c.execReturn[stitchID]; !ok {
+		return 0, errors.New("no such container")
+	}
+
+	c.execOpts = opts
+	_, err := io.Copy(opts.Stdout, opts.Stdin)
+	return c.execReturn[stitchID], err
+}
+
+func (c *mockClient) WatchMachines() (<-chan []db.Machine, error) {
+	return c.machineWatch, nil
+}
+
+func (c *mockClient) WatchContainers() (<-chan []db.Container, error) {
+	return c.containerWatch, nil
+}
+
 func (c *mockClient) Close() error {
 	return nil
 }
 
-func (c *mockClient) RunStitch(stitch string) error {
+func (c *mockClient) RunStitch(stitch, namespace string) error {
 	c.runStitchArg = stitch
+	c.namespaceArg = namespace
 	return nil
 }
 
+func (c *mockClient) PlanStitch(stitch, namespace string) (engine.Plan, error) {
+	c.planStitchArg = stitch
+	c.namespaceArg = namespace
+	return c.planReturn, nil
+}
+
+func (c *mockClient) SetSecret(name, value string) error {
+	if c.secrets == nil {
+		c.secrets = map[string]string{}
+	}
+	c.secrets[name] = value
+	return nil
+}
+
+func (c *mockClient) Cordon(machineID int, unschedulable, drain bool) error {
+	for _, m := range c.machineReturn {
+		if m.ID == machineID {
+			c.cordonArg = cordonArg{machineID, unschedulable, drain}
+			return nil
+		}
+	}
+	return errors.New("no such machine")
+}
+
 func TestStopNamespace(t *testing.T) {
 	c := &mockClient{}
 	getClient = func(host string) (client.Client, error) {
@@ -312,6 +464,286 @@ func TestStopNamespace(t *testing.T) {
 	}
 }
 
+func TestSecretFlags(t *testing.T) {
+	t.Parallel()
+
+	secretCmd := &Secret{}
+	if err := secretCmd.Parse([]string{"set", "name", "value"}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if secretCmd.name != "name" || secretCmd.value != "value" ||
+		secretCmd.readStdin {
+		t.Errorf("Unexpected parse result: %+v", secretCmd)
+	}
+
+	secretCmd = &Secret{}
+	if err := secretCmd.Parse([]string{"set", "name"}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if secretCmd.name != "name" || !secretCmd.readStdin {
+		t.Errorf("Unexpected parse result: %+v", secretCmd)
+	}
+
+	for _, args := range [][]string{nil, {"get", "name"}, {"set"}} {
+		if err := (&Secret{}).Parse(args); err == nil {
+			t.Errorf("Expected an error parsing %v", args)
+		}
+	}
+}
+
+func TestSecretSet(t *testing.T) {
+	c := &mockClient{}
+	getClient = func(host string) (client.Client, error) {
+		return c, nil
+	}
+
+	secretCmd := &Secret{name: "a", value: "b"}
+	if exitCode := secretCmd.Run(); exitCode != 0 {
+		t.Errorf("Unexpected exit code: %d", exitCode)
+	}
+
+	secretCmd = &Secret{name: "c", readStdin: true,
+		stdin: strings.NewReader("d\n")}
+	if exitCode := secretCmd.Run(); exitCode != 0 {
+		t.Errorf("Unexpected exit code: %d", exitCode)
+	}
+
+	exp := map[string]string{"a": "b", "c": "d"}
+	if !reflect.DeepEqual(c.secrets, exp) {
+		t.Errorf("Expected secrets %v, but got %v", exp, c.secrets)
+	}
+}
+
+func TestRolloutFlags(t *testing.T) {
+	t.Parallel()
+
+	rolloutCmd := &Rollout{}
+	if err := rolloutCmd.Parse([]string{"status", "web"}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if rolloutCmd.label != "web" {
+		t.Errorf("Unexpected parse result: %+v", rolloutCmd)
+	}
+
+	for _, args := range [][]string{nil, {"undo"}, {"status", "a", "b"}} {
+		if err := (&Rollout{}).Parse(args); err == nil {
+			t.Errorf("Expected an error parsing %v", args)
+		}
+	}
+}
+
+func TestRolloutOutput(t *testing.T) {
+	t.Parallel()
+
+	res := rolloutsStr([]db.Rollout{
+		{Label: "web", Replicas: 3, Updated: 2, Ready: 1, Old: 1},
+		{Label: "db", Replicas: 1, Updated: 1, Ready: 1},
+	})
+
+	exp := "LABEL  UPDATED  READY  OUTDATED  STATUS\n" +
+		"web    2/3      1/3    1         in progress\n" +
+		"db     1/1      1/1    0         complete\n"
+	if res != exp {
+		t.Errorf("Expected rollout output:\n%s\nbut got:\n%s", exp, res)
+	}
+
+	rollouts := filterRollouts([]db.Rollout{{Label: "web"}, {Label: "db"}}, "db")
+	if len(rollouts) != 1 || rollouts[0].Label != "db" {
+		t.Errorf("Unexpected filtered rollouts: %v", rollouts)
+	}
+}
+
+func TestLogsFlags(t *testing.T) {
+	t.Parallel()
+
+	logsCmd := &Logs{}
+	err := logsCmd.Parse([]string{"-f", "-since", "10m", "web"})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !logsCmd.follow || logsCmd.since != 10*time.Minute ||
+		logsCmd.target != "web" {
+		t.Errorf("Unexpected parse result: %+v", logsCmd)
+	}
+
+	for _, args := range [][]string{nil, {"1", "2"}} {
+		if err := (&Logs{}).Parse(args); err == nil {
+			t.Errorf("Expected an error parsing %v", args)
+		}
+	}
+}
+
+func TestLogsRun(t *testing.T) {
+	c := &mockClient{
+		machineReturn: []db.Machine{{PublicIP: "8.8.8.8", PrivateIP: "9.9.9.9"}},
+		etcdReturn:    []db.Etcd{{LeaderIP: "9.9.9.9"}},
+		containerReturn: []db.Container{
+			{StitchID: 1, Minion: "9.9.9.9", Labels: []string{"web"}},
+			{StitchID: 2, Minion: "9.9.9.9", Labels: []string{"web"}},
+			{StitchID: 3, Minion: "9.9.9.9", Labels: []string{"db"}},
+		},
+		logs: map[int]string{1: "a\nb", 2: "c\n", 3: "d\n"},
+	}
+	getClient = func(host string) (client.Client, error) {
+		return c, nil
+	}
+
+	var out bytes.Buffer
+	logsCmd := &Logs{target: "3", out: &out}
+	if exitCode := logsCmd.Run(); exitCode != 0 {
+		t.Errorf("Unexpected exit code: %d", exitCode)
+	}
+	if out.String() != "d\n" {
+		t.Errorf("Unexpected logs: %q", out.String())
+	}
+
+	out.Reset()
+	logsCmd = &Logs{target: "web", out: &out}
+	if exitCode := logsCmd.Run(); exitCode != 0 {
+		t.Errorf("Unexpected exit code: %d", exitCode)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	sort.Strings(lines)
+	exp := []string{"[1] a", "[1] b", "[2] c"}
+	if !reflect.DeepEqual(lines, exp) {
+		t.Errorf("Expected logs %v, but got %v", exp, lines)
+	}
+
+	logsCmd = &Logs{target: "cache", out: &out}
+	if exitCode := logsCmd.Run(); exitCode != 1 {
+		t.Errorf("Expected exit code 1 for an unknown label, got %d", exitCode)
+	}
+}
+
+func TestCordonFlags(t *testing.T) {
+	t.Parallel()
+
+	cordonCmd := &Cordon{}
+	if err := cordonCmd.Parse([]string{"-undo", "3"}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !cordonCmd.undo || cordonCmd.machineID != 3 {
+		t.Errorf("Unexpected parse result: %+v", cordonCmd)
+	}
+
+	drainCmd := &Drain{}
+	if err := drainCmd.Parse([]string{"-w", "4"}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !drainCmd.wait || drainCmd.machineID != 4 {
+		t.Errorf("Unexpected parse result: %+v", drainCmd)
+	}
+
+	for _, args := range [][]string{nil, {"a"}, {"1", "2"}} {
+		if err := (&Cordon{}).Parse(args); err == nil {
+			t.Errorf("Expected an error parsing %v", args)
+		}
+		if err := (&Drain{}).Parse(args); err == nil {
+			t.Errorf("Expected an error parsing %v", args)
+		}
+	}
+}
+
+func TestCordonRun(t *testing.T) {
+	c := &mockClient{machineReturn: []db.Machine{{ID: 1}}}
+	getClient = func(host string) (client.Client, error) {
+		return c, nil
+	}
+
+	if exitCode := (&Cordon{machineID: 1}).Run(); exitCode != 0 {
+		t.Errorf("Unexpected exit code: %d", exitCode)
+	}
+	if exp := (cordonArg{1, true, false}); c.cordonArg != exp {
+		t.Errorf("Expected cordon %+v, got %+v", exp, c.cordonArg)
+	}
+
+	if exitCode := (&Cordon{machineID: 1, undo: true}).Run(); exitCode != 0 {
+		t.Errorf("Unexpected exit code: %d", exitCode)
+	}
+	if exp := (cordonArg{1, false, false}); c.cordonArg != exp {
+		t.Errorf("Expected cordon %+v, got %+v", exp, c.cordonArg)
+	}
+
+	if exitCode := (&Cordon{machineID: 2}).Run(); exitCode != 1 {
+		t.Errorf("Expected exit code 1 for an unknown machine, got %d",
+			exitCode)
+	}
+}
+
+func TestDrainRun(t *testing.T) {
+	c := &mockClient{
+		machineReturn: []db.Machine{
+			{ID: 1, PublicIP: "8.8.8.8", PrivateIP: "9.9.9.9"},
+			{ID: 2, PublicIP: "7.7.7.7", PrivateIP: "6.6.6.6"},
+		},
+		etcdReturn: []db.Etcd{{LeaderIP: "9.9.9.9"}},
+		containerReturn: []db.Container{
+			{StitchID: 1, Minion: "9.9.9.9", DockerID: "a"},
+			{StitchID: 2, Minion: "9.9.9.9", DockerID: "b"},
+		},
+	}
+	getClient = func(host string) (client.Client, error) {
+		return c, nil
+	}
+
+	if exitCode := (&Drain{machineID: 1}).Run(); exitCode != 0 {
+		t.Errorf("Unexpected exit code: %d", exitCode)
+	}
+	if exp := (cordonArg{1, true, true}); c.cordonArg != exp {
+		t.Errorf("Expected cordon %+v, got %+v", exp, c.cordonArg)
+	}
+
+	// Machine 2 has no containers, so waiting returns immediately.
+	if exitCode := (&Drain{machineID: 2, wait: true}).Run(); exitCode != 0 {
+		t.Errorf("Unexpected exit code: %d", exitCode)
+	}
+
+	if exitCode := (&Drain{machineID: 3}).Run(); exitCode != 1 {
+		t.Errorf("Expected exit code 1 for an unknown machine, got %d",
+			exitCode)
+	}
+}
+
+func TestDrainStatus(t *testing.T) {
+	t.Parallel()
+
+	test := func(containers []db.Container, expRemaining, expWaiting int) {
+		remaining, waiting := drainStatus(containers, "a")
+		if remaining != expRemaining || waiting != expWaiting {
+			t.Errorf("%v: got %d remaining and %d waiting, expected %d "+
+				"and %d", containers, remaining, waiting, expRemaining,
+				expWaiting)
+		}
+	}
+
+	test([]db.Container{{Minion: "a", DockerID: "1"}, {Minion: "b", DockerID: "2"},
+		{Minion: "a"}}, 2, 0)
+
+	// Containers that haven't been placed, or haven't started on their new
+	// minion, aren't counted as moved.
+	test([]db.Container{{Minion: "b", DockerID: "1"}, {}, {Minion: "b"}}, 0, 2)
+	test([]db.Container{{Minion: "b", DockerID: "1"}}, 0, 0)
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := &prefixWriter{out: &out, lock: &sync.Mutex{}, prefix: "> "}
+	w.Write([]byte("hel"))
+	w.Write([]byte("lo\nwor"))
+	if out.String() != "> hello\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+
+	w.Write([]byte("ld"))
+	w.Flush()
+	if out.String() != "> hello\n> world\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+}
+
 func TestRunSpec(t *testing.T) {
 	c := &mockClient{}
 	getClient = func(host string) (client.Client, error) {
@@ -330,6 +762,83 @@ func TestRunSpec(t *testing.T) {
 	if c.runStitchArg != expStitch {
 		t.Error("run command invoked Quilt with the wrong stitch")
 	}
+
+	runCmd = &Run{stitch: stitchPath, namespace: "staging"}
+	runCmd.Run()
+
+	if c.runStitchArg != expStitch {
+		t.Error("run command invoked Quilt with the wrong stitch")
+	}
+
+	if c.namespaceArg != "staging" {
+		t.Errorf("run command invoked Quilt in namespace %q, expected %q",
+			c.namespaceArg, "staging")
+	}
+}
+
+func TestRunPlan(t *testing.T) {
+	c := &mockClient{
+		planReturn: engine.Plan{
+			BootMachines: []db.Machine{{Role: db.Worker}},
+		},
+	}
+	getClient = func(host string) (client.Client, error) {
+		return c, nil
+	}
+	util.AppFs = afero.NewMemMapFs()
+
+	stitchPath := "test.spec"
+	testSpec := `new Container("nginx");`
+	util.WriteFile(stitchPath, []byte(testSpec), 0644)
+
+	runCmd := &Run{stitch: stitchPath, plan: true}
+	if exitCode := runCmd.Run(); exitCode != 0 {
+		t.Errorf("Expected plan to succeed, but got exit code %d", exitCode)
+	}
+
+	expStitch := `importSources = {};` + testSpec
+	if c.planStitchArg != expStitch {
+		t.Error("run command planned the wrong stitch")
+	}
+
+	if c.runStitchArg != "" {
+		t.Error("run command with -plan should not run the stitch")
+	}
+}
+
+func TestPlanStr(t *testing.T) {
+	t.Parallel()
+
+	if str := planStr(engine.Plan{}, nil); str != "No changes.\n" {
+		t.Errorf("Expected no changes, but got %q", str)
+	}
+
+	plan := engine.Plan{
+		BootMachines:      []db.Machine{{Role: db.Worker}},
+		TerminateMachines: []db.Machine{{ID: 1, PrivateIP: "1.1.1.1"}},
+		StartContainers:   []db.Container{{Image: "new"}},
+		StopContainers:    []db.Container{{ID: 2, Image: "old"}},
+	}
+	containers := []db.Container{
+		{ID: 2, Image: "old", Minion: "1.1.1.1"},
+		{ID: 3, Image: "moved", Minion: "1.1.1.1"},
+		{ID: 4, Image: "stays", Minion: "2.2.2.2"},
+	}
+
+	moves := movedContainers(plan, containers)
+	if !reflect.DeepEqual(moves, containers[1:2]) {
+		t.Errorf("Expected container 3 to move, but got %v", moves)
+	}
+
+	exp := "Machines to boot:\n" + machinesStr(plan.BootMachines) +
+		"Machines to terminate:\n" + machinesStr(plan.TerminateMachines) +
+		"Containers to start:\n" + containersStr(plan.StartContainers) +
+		"Containers to stop:\n" + containersStr(plan.StopContainers) +
+		"Containers to move:\n" + containersStr(moves) +
+		rolloutNote
+	if str := planStr(plan, moves); str != exp {
+		t.Errorf("Expected plan:\n%s\ngot:\n%s", exp, str)
+	}
 }
 
 func TestGetLeaderClient(t *testing.T) {
@@ -435,13 +944,21 @@ func TestSSHCommandCreation(t *testing.T) {
 
 func TestExec(t *testing.T) {
 	targetContainer := 1
+	var out bytes.Buffer
 	execCmd := Exec{
-		privateKey:      "key",
-		command:         "cat /etc/hosts",
+		command:         []string{"cat"},
 		targetContainer: targetContainer,
 		host:            api.DefaultSocket,
+		in:              strings.NewReader("input"),
+		out:             &out,
 	}
+
+	rawTerminal = func() (func(), error) {
+		return nil, errors.New("not a terminal")
+	}
+
 	workerHost := "worker"
+	workerClient := &mockClient{execReturn: map[int]int{targetContainer: 3}}
 	getClient = func(host string) (client.Client, error) {
 		switch host {
 		// The local client. Used by getLeaderClient to figure out machine
@@ -478,14 +995,7 @@ func TestExec(t *testing.T) {
 				},
 			}, nil
 		case api.RemoteAddress(workerHost):
-			return &mockClient{
-				containerReturn: []db.Container{
-					{
-						StitchID: targetContainer,
-						DockerID: "foo",
-					},
-				},
-			}, nil
+			return workerClient, nil
 		default:
 			t.Errorf("Unexpected call to getClient with host %s", host)
 			t.Fail()
@@ -493,23 +1003,22 @@ func TestExec(t *testing.T) {
 		panic("unreached")
 	}
 
-	expArgs := []string{"-t", "-i", "key", "docker exec -it foo cat /etc/hosts"}
-	var sshCalled bool
-	ssh = func(host string, args []string) *exec.Cmd {
-		sshCalled = true
-		if host != workerHost {
-			t.Errorf("Bad ssh host: expected %s, but got %s",
-				workerHost, host)
-		}
-		if !reflect.DeepEqual(args, expArgs) {
-			t.Errorf("Bad ssh args: expected %v, but got %v", expArgs, args)
-		}
-		return &exec.Cmd{}
+	if exitCode := execCmd.Run(); exitCode != 3 {
+		t.Errorf("Expected the command's exit code 3, but got %d", exitCode)
 	}
 
-	execCmd.Run()
+	if out.String() != "input" {
+		t.Errorf("Expected the command's output, but got %q", out.String())
+	}
+
+	opts := workerClient.execOpts
+	if !reflect.DeepEqual(opts.Cmd, []string{"cat"}) || opts.TTY {
+		t.Errorf("Bad exec options: %+v", opts)
+	}
 
-	if !sshCalled {
-		t.Errorf("Never tried SSHing")
+	execCmd.targetContainer = 5
+	if exitCode := execCmd.Run(); exitCode != 1 {
+		t.Errorf("Expected exit code 1 for an unscheduled container, got %d",
+			exitCode)
 	}
 }
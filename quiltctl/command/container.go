@@ -12,7 +12,8 @@ import (
 
 // Container contains the options for querying containers.
 type Container struct {
-	host string
+	host  string
+	watch bool
 
 	flags *flag.FlagSet
 }
@@ -20,6 +21,8 @@ type Container struct {
 func (cCmd *Container) createFlagSet() {
 	flags := flag.NewFlagSet("containers", flag.ExitOnError)
 	flags.StringVar(&cCmd.host, "H", api.DefaultSocket, "the host to connect to")
+	flags.BoolVar(&cCmd.watch, "w", false,
+		"keep running, and reprint the containers whenever they change")
 	cCmd.flags = flags
 }
 
@@ -45,6 +48,21 @@ func (cCmd *Container) Run() int {
 	}
 	defer c.Close()
 
+	if cCmd.watch {
+		containersChan, err := c.WatchContainers()
+		if err != nil {
+			log.WithError(err).Error("Unable to watch containers.")
+			return 1
+		}
+
+		for containers := range containersChan {
+			fmt.Print(clearScreen + containersStr(containers))
+		}
+
+		log.Error("Lost connection to the lead minion.")
+		return 1
+	}
+
 	containers, err := c.QueryContainers()
 	if err != nil {
 		log.WithError(err).Error("Unable to query containers.")
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/NetSys/quilt/api"
+)
+
+// Cordon contains the options for marking a machine as unschedulable.
+type Cordon struct {
+	host      string
+	undo      bool
+	machineID int
+
+	flags *flag.FlagSet
+}
+
+func (cCmd *Cordon) createFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("cordon", flag.ExitOnError)
+
+	flags.StringVar(&cCmd.host, "H", api.DefaultSocket, "the host to connect to")
+	flags.BoolVar(&cCmd.undo, "undo", false,
+		"allow containers to be placed on the machine again")
+
+	flags.Usage = func() {
+		fmt.Println("usage: quilt cordon [-H=<daemon_host>] [-undo] <machine_id>")
+		fmt.Println("`cordon` stops new containers from being placed on the " +
+			"given machine, while leaving the containers already on it. " +
+			"If the stitch asks for fewer machines, cordoned ones are " +
+			"stopped first.")
+		cCmd.flags.PrintDefaults()
+	}
+
+	cCmd.flags = flags
+	return flags
+}
+
+// Parse parses the command line arguments for the cordon command.
+func (cCmd *Cordon) Parse(args []string) error {
+	flags := cCmd.createFlagSet()
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := parseMachineID(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	cCmd.machineID = id
+	return nil
+}
+
+// Run marks the machine as unschedulable, or schedulable again if undoing.
+func (cCmd *Cordon) Run() int {
+	c, err := getClient(cCmd.host)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	defer c.Close()
+
+	if err := c.Cordon(cCmd.machineID, !cCmd.undo, false); err != nil {
+		log.WithError(err).Error("Unable to cordon machine.")
+		return 1
+	}
+
+	if cCmd.undo {
+		fmt.Printf("Machine %d is schedulable.\n", cCmd.machineID)
+	} else {
+		fmt.Printf("Machine %d is cordoned.\n", cCmd.machineID)
+	}
+	return 0
+}
+
+// Usage prints the usage for the cordon command.
+func (cCmd *Cordon) Usage() {
+	cCmd.flags.Usage()
+}
+
+func parseMachineID(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("must specify a machine ID")
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("malformed machine ID: %s", args[0])
+	}
+	return id, nil
+}
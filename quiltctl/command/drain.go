@@ -0,0 +1,163 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/NetSys/quilt/api"
+	"github.com/NetSys/quilt/api/client"
+	"github.com/NetSys/quilt/db"
+)
+
+// How often `drain -w` checks whether the machine is drained.  A variable so that it can
+// be mocked out by the unit tests.
+var drainPollInterval = 5 * time.Second
+
+// Drain contains the options for moving the containers off of a machine.
+type Drain struct {
+	host      string
+	wait      bool
+	machineID int
+
+	flags *flag.FlagSet
+}
+
+func (dCmd *Drain) createFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("drain", flag.ExitOnError)
+
+	flags.StringVar(&dCmd.host, "H", api.DefaultSocket, "the host to connect to")
+	flags.BoolVar(&dCmd.wait, "w", false,
+		"wait until the machine is drained")
+
+	flags.Usage = func() {
+		fmt.Println("usage: quilt drain [-H=<daemon_host>] [-w] <machine_id>")
+		fmt.Println("`drain` cordons the given machine, and has the scheduler " +
+			"move its containers to other machines that satisfy their " +
+			"placement rules.  The machine is drained, and so safe to " +
+			"stop, once it has no containers left and every container " +
+			"in the deployment is running on another machine.  Use " +
+			"`quilt cordon -undo` to allow containers on the machine " +
+			"again.")
+		dCmd.flags.PrintDefaults()
+	}
+
+	dCmd.flags = flags
+	return flags
+}
+
+// Parse parses the command line arguments for the drain command.
+func (dCmd *Drain) Parse(args []string) error {
+	flags := dCmd.createFlagSet()
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := parseMachineID(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	dCmd.machineID = id
+	return nil
+}
+
+// Run drains the machine, and reports how many containers remain on it, or are yet
+// to start elsewhere.
+func (dCmd *Drain) Run() int {
+	localClient, err := getClient(dCmd.host)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	defer localClient.Close()
+
+	if err := localClient.Cordon(dCmd.machineID, true, true); err != nil {
+		log.WithError(err).Error("Unable to drain machine.")
+		return 1
+	}
+
+	privateIP, err := machinePrivateIP(localClient, dCmd.machineID)
+	if err != nil {
+		log.WithError(err).Error("Unable to query machines.")
+		return 1
+	}
+
+	// A machine without an IP can't have been assigned any containers.
+	if privateIP == "" {
+		fmt.Printf("Machine %d is drained, and safe to stop.\n", dCmd.machineID)
+		return 0
+	}
+
+	leaderClient, err := getLeaderClient(localClient)
+	if err != nil {
+		log.WithError(err).Error("Error connecting to leader.")
+		return 1
+	}
+	defer leaderClient.Close()
+
+	for {
+		containers, err := leaderClient.QueryContainers()
+		if err != nil {
+			log.WithError(err).Error("Unable to query containers.")
+			return 1
+		}
+
+		remaining, waiting := drainStatus(containers, privateIP)
+		if remaining == 0 && waiting == 0 {
+			fmt.Printf("Machine %d is drained, and safe to stop.\n",
+				dCmd.machineID)
+			return 0
+		}
+
+		if !dCmd.wait {
+			fmt.Printf("Machine %d is draining, %d containers remain, and "+
+				"%d are waiting to start elsewhere.\n", dCmd.machineID,
+				remaining, waiting)
+			return 0
+		}
+
+		log.Debugf("Waiting for %d containers to leave machine %d, and %d "+
+			"to start elsewhere.", remaining, dCmd.machineID, waiting)
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// Usage prints the usage for the drain command.
+func (dCmd *Drain) Usage() {
+	dCmd.flags.Usage()
+}
+
+func machinePrivateIP(c client.Client, id int) (string, error) {
+	machines, err := c.QueryMachines()
+	if err != nil {
+		return "", err
+	}
+
+	for _, m := range machines {
+		if m.ID == id {
+			return m.PrivateIP, nil
+		}
+	}
+	return "", fmt.Errorf("no machine with ID %d", id)
+}
+
+// drainStatus returns the number of `containers` still assigned to the minion at
+// `privateIP`, and the number of the others that aren't yet placed or running.  Once
+// they've left the minion, its containers can't be told apart from the rest, so none
+// may be waiting for the minion to be drained.
+func drainStatus(containers []db.Container, privateIP string) (
+	remaining, waiting int) {
+
+	for _, dbc := range containers {
+		switch {
+		case dbc.Minion == privateIP:
+			remaining++
+		case dbc.Minion == "", dbc.DockerID == "":
+			waiting++
+		}
+	}
+	return remaining, waiting
+}
@@ -4,22 +4,28 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
-	"strings"
 
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/NetSys/quilt/api"
 	"github.com/NetSys/quilt/api/client"
 	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/minion/docker"
 )
 
 // Exec contains the options for running commands in containers.
 type Exec struct {
 	host            string
-	privateKey      string
 	targetContainer int
-	command         string
+	command         []string
+
+	// Stored in fields so they can be mocked out by the unit tests.
+	in     io.Reader
+	out    io.Writer
+	errOut io.Writer
 
 	flags *flag.FlagSet
 }
@@ -29,17 +35,17 @@ func (eCmd *Exec) createFlagSet() *flag.FlagSet {
 
 	flags.StringVar(&eCmd.host, "H", api.DefaultSocket,
 		"the host to query for machine information")
-	flags.StringVar(&eCmd.privateKey, "i", "",
-		"the private key to use to connect to the host")
 
 	flags.Usage = func() {
-		fmt.Println("usage: quilt exec [-H=<daemon_host>] [-i=<private_key>] " +
-			"<stitch_id> <command>")
+		fmt.Println("usage: quilt exec [-H=<daemon_host>] <stitch_id> " +
+			"<command> [args...]")
 		fmt.Println("`exec` runs a command within the specified container. " +
 			"The container is identified by the stitch ID produced by " +
-			"`quilt containers`.")
-		fmt.Println("For example, to get a shell in container 5 with a " +
-			"specific private key: quilt exec -i ~/.ssh/quilt 5 sh")
+			"`quilt containers`. The command isn't run in a shell, and " +
+			"gets a pseudo-terminal if standard input is a terminal. " +
+			"`exec` exits with the command's exit code.")
+		fmt.Println("For example, to get a shell in container 5: " +
+			"quilt exec 5 sh")
 		eCmd.flags.PrintDefaults()
 	}
 
@@ -66,7 +72,7 @@ func (eCmd *Exec) Parse(rawArgs []string) error {
 	}
 
 	eCmd.targetContainer = targetContainer
-	eCmd.command = strings.Join(parsedArgs[1:], " ")
+	eCmd.command = parsedArgs[1:]
 	return nil
 }
 
@@ -95,28 +101,50 @@ func (eCmd *Exec) Run() int {
 	}
 	defer containerClient.Close()
 
-	container, err := getContainer(containerClient, eCmd.targetContainer)
+	exitCode, err := eCmd.exec(containerClient)
 	if err != nil {
-		log.WithError(err).Error("Error retrieving the container information " +
-			"from the container host.")
+		log.WithError(err).Error("Error running the exec command.")
 		return 1
 	}
+	return exitCode
+}
 
-	// -t allows the docker command to receive input over SSH.
-	sshArgs := []string{"-t"}
-	if eCmd.privateKey != "" {
-		sshArgs = append(sshArgs, "-i", eCmd.privateKey)
+// exec runs the command through `c`, which must be connected to the minion running the
+// container.  The terminal is restored before it returns.
+func (eCmd *Exec) exec(c client.Client) (int, error) {
+	opts := docker.ExecOptions{
+		Cmd:    eCmd.command,
+		Stdin:  eCmd.in,
+		Stdout: eCmd.out,
+		Stderr: eCmd.errOut,
 	}
-	sshArgs = append(sshArgs,
-		fmt.Sprintf("docker exec -it %s %s", container.DockerID, eCmd.command))
-	if err = ssh(containerHost, sshArgs).Run(); err != nil {
-		log.WithError(err).Error("Error running the exec command.")
-		return 1
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
 	}
-	return 0
+
+	if restore, err := rawTerminal(); err == nil {
+		defer restore()
+
+		done := make(chan struct{})
+		defer close(done)
+
+		resize := make(chan docker.TermSize)
+		go watchTerminalSize(resize, done)
+
+		opts.TTY = true
+		opts.Resize = resize
+	}
+
+	return c.Exec(eCmd.targetContainer, opts)
 }
 
-// Usage prints the usage for the ssh command.
+// Usage prints the usage for the exec command.
 func (eCmd *Exec) Usage() {
 	eCmd.flags.Usage()
 }
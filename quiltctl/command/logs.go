@@ -0,0 +1,227 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/NetSys/quilt/api"
+	"github.com/NetSys/quilt/db"
+)
+
+// Logs contains the options for fetching container logs.
+type Logs struct {
+	host   string
+	follow bool
+	since  time.Duration
+	target string
+
+	// Stored in a field so it can be mocked out by the unit tests.
+	out io.Writer
+
+	flags *flag.FlagSet
+}
+
+func (lCmd *Logs) createFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("logs", flag.ExitOnError)
+
+	flags.StringVar(&lCmd.host, "H", api.DefaultSocket,
+		"the host to query for machine information")
+	flags.BoolVar(&lCmd.follow, "f", false, "keep streaming new output")
+	flags.DurationVar(&lCmd.since, "since", 0,
+		"only show output from this long ago onwards, e.g. 10m")
+
+	flags.Usage = func() {
+		fmt.Println("usage: quilt logs [-H=<daemon_host>] [-f] " +
+			"[-since=<duration>] <stitch_id | label>")
+		fmt.Println("`logs` prints the output of the container with the " +
+			"given stitch ID, or of every container with the given " +
+			"label, with each line prefixed by its container's stitch ID.")
+		lCmd.flags.PrintDefaults()
+	}
+
+	lCmd.flags = flags
+	return flags
+}
+
+// Parse parses the command line arguments for the logs command.
+func (lCmd *Logs) Parse(args []string) error {
+	flags := lCmd.createFlagSet()
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	parsedArgs := flags.Args()
+	if len(parsedArgs) != 1 {
+		return errors.New("must specify a target container or label")
+	}
+
+	lCmd.target = parsedArgs[0]
+	return nil
+}
+
+// Run fetches the logs of the target containers from the minions running them.
+func (lCmd *Logs) Run() int {
+	localClient, leaderClient, err := getClients(lCmd.host)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	defer localClient.Close()
+	defer leaderClient.Close()
+
+	containers, err := leaderClient.QueryContainers()
+	if err != nil {
+		log.WithError(err).Error("Unable to query containers.")
+		return 1
+	}
+
+	targets, prefix := logTargets(containers, lCmd.target)
+	if len(targets) == 0 {
+		log.Errorf("No containers match %s.", lCmd.target)
+		return 1
+	}
+
+	var since time.Time
+	if lCmd.since != 0 {
+		since = time.Now().Add(-lCmd.since)
+	}
+
+	out := lCmd.out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	var outLock sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(chan error, len(targets))
+	for _, dbc := range targets {
+		w := &prefixWriter{out: out, lock: &outLock}
+		if prefix {
+			w.prefix = fmt.Sprintf("[%d] ", dbc.StitchID)
+		}
+
+		wg.Add(1)
+		go func(dbc db.Container) {
+			defer wg.Done()
+			if err := lCmd.streamLogs(dbc, since, w); err != nil {
+				errs <- fmt.Errorf("container %d: %s", dbc.StitchID, err)
+			}
+			w.Flush()
+		}(dbc)
+	}
+	wg.Wait()
+	close(errs)
+
+	exitCode := 0
+	for err := range errs {
+		log.WithError(err).Error("Unable to fetch logs.")
+		exitCode = 1
+	}
+	return exitCode
+}
+
+func (lCmd *Logs) streamLogs(dbc db.Container, since time.Time, w io.Writer) error {
+	if dbc.Minion == "" {
+		return errors.New("container hasn't been scheduled yet")
+	}
+
+	localClient, err := getClient(lCmd.host)
+	if err != nil {
+		return err
+	}
+	defer localClient.Close()
+
+	host, err := getPublicIP(localClient, dbc.Minion)
+	if err != nil {
+		return err
+	}
+
+	containerClient, err := getClient(api.RemoteAddress(host))
+	if err != nil {
+		return err
+	}
+	defer containerClient.Close()
+
+	return containerClient.ContainerLogs(dbc.StitchID, lCmd.follow, since, w)
+}
+
+// logTargets returns the containers identified by `target`, either a stitch ID or a
+// label, and whether their output should be prefixed to tell it apart.
+func logTargets(containers []db.Container, target string) ([]db.Container, bool) {
+	if stitchID, err := strconv.Atoi(target); err == nil {
+		for _, dbc := range containers {
+			if dbc.StitchID == stitchID {
+				return []db.Container{dbc}, false
+			}
+		}
+		return nil, false
+	}
+
+	var targets []db.Container
+	for _, dbc := range containers {
+		for _, label := range dbc.Labels {
+			if label == target {
+				targets = append(targets, dbc)
+				break
+			}
+		}
+	}
+	return targets, true
+}
+
+// prefixWriter writes each complete line written to it to `out` with `prefix`
+// prepended.  Writers sharing `lock` never interleave their lines.
+type prefixWriter struct {
+	out    io.Writer
+	lock   *sync.Mutex
+	prefix string
+
+	partial []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		if err := w.writeLine(w.partial[:i+1]); err != nil {
+			return 0, err
+		}
+		w.partial = w.partial[i+1:]
+	}
+}
+
+// Flush writes any incomplete line that remains.
+func (w *prefixWriter) Flush() error {
+	if len(w.partial) == 0 {
+		return nil
+	}
+
+	err := w.writeLine(append(w.partial, '\n'))
+	w.partial = nil
+	return err
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return err
+}
+
+// Usage prints the usage for the logs command.
+func (lCmd *Logs) Usage() {
+	lCmd.flags.Usage()
+}
@@ -12,7 +12,9 @@ import (
 
 // Machine contains the options for querying machines.
 type Machine struct {
-	host string
+	host      string
+	namespace string
+	watch     bool
 
 	flags *flag.FlagSet
 }
@@ -20,6 +22,10 @@ type Machine struct {
 func (mCmd *Machine) createFlagSet() {
 	flags := flag.NewFlagSet("machines", flag.ExitOnError)
 	flags.StringVar(&mCmd.host, "H", api.DefaultSocket, "the host to connect to")
+	flags.StringVar(&mCmd.namespace, "namespace", "",
+		"only show machines in this namespace")
+	flags.BoolVar(&mCmd.watch, "w", false,
+		"keep running, and reprint the machines whenever they change")
 	mCmd.flags = flags
 }
 
@@ -38,18 +44,38 @@ func (mCmd *Machine) Run() int {
 	}
 	defer c.Close()
 
+	if mCmd.watch {
+		machinesChan, err := c.WatchMachines()
+		if err != nil {
+			log.WithError(err).Error("Unable to watch machines.")
+			return 1
+		}
+
+		for machines := range machinesChan {
+			fmt.Print(clearScreen + mCmd.filteredMachinesStr(machines))
+		}
+
+		log.Error("Lost connection to the Quilt daemon.")
+		return 1
+	}
+
 	machines, err := c.QueryMachines()
 	if err != nil {
 		log.WithError(err).Error("Unable to query machines.")
 		return 1
 	}
 
-	str := machinesStr(machines)
-	fmt.Print(str)
-
+	fmt.Print(mCmd.filteredMachinesStr(machines))
 	return 0
 }
 
+func (mCmd *Machine) filteredMachinesStr(machines []db.Machine) string {
+	if mCmd.namespace != "" {
+		machines = filterNamespace(machines, mCmd.namespace)
+	}
+	return machinesStr(machines)
+}
+
 func machinesStr(machines []db.Machine) string {
 	var machinesStr string
 	for _, m := range db.SortMachines(machines) {
@@ -59,6 +85,16 @@ func machinesStr(machines []db.Machine) string {
 	return machinesStr
 }
 
+func filterNamespace(machines []db.Machine, namespace string) []db.Machine {
+	var filtered []db.Machine
+	for _, m := range machines {
+		if m.Namespace == namespace {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
 // Usage prints the usage for the machine command.
 func (mCmd *Machine) Usage() {
 	mCmd.Usage()
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/NetSys/quilt/api"
+	"github.com/NetSys/quilt/db"
+)
+
+// Rollout contains the options for inspecting rolling updates.
+type Rollout struct {
+	host  string
+	label string
+
+	flags *flag.FlagSet
+}
+
+func (rCmd *Rollout) createFlagSet() {
+	flags := flag.NewFlagSet("rollout", flag.ExitOnError)
+	flags.StringVar(&rCmd.host, "H", api.DefaultSocket, "the host to connect to")
+
+	flags.Usage = func() {
+		fmt.Println("usage: quilt rollout [-H=<daemon_host>] status [label]")
+		fmt.Println("`rollout status` shows how far each label, or just the " +
+			"given label, has gotten in replacing its containers " +
+			"with the current version.")
+		rCmd.flags.PrintDefaults()
+	}
+
+	rCmd.flags = flags
+}
+
+// Parse parses the command line arguments for the rollout command.
+func (rCmd *Rollout) Parse(args []string) error {
+	rCmd.createFlagSet()
+	if err := rCmd.flags.Parse(args); err != nil {
+		return err
+	}
+
+	parsedArgs := rCmd.flags.Args()
+	if len(parsedArgs) == 0 || parsedArgs[0] != "status" {
+		return errors.New("unrecognized rollout subcommand")
+	}
+
+	switch len(parsedArgs) {
+	case 1:
+	case 2:
+		rCmd.label = parsedArgs[1]
+	default:
+		return errors.New("too many arguments")
+	}
+	return nil
+}
+
+// Run prints the progress of the requested rollouts.
+func (rCmd *Rollout) Run() int {
+	localClient, err := getClient(rCmd.host)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+
+	c, err := getLeaderClient(localClient)
+	localClient.Close()
+	if err != nil {
+		log.WithError(err).Error("Error connecting to leader.")
+		return 1
+	}
+	defer c.Close()
+
+	rollouts, err := c.QueryRollouts()
+	if err != nil {
+		log.WithError(err).Error("Unable to query rollouts.")
+		return 1
+	}
+
+	if rCmd.label != "" {
+		rollouts = filterRollouts(rollouts, rCmd.label)
+		if len(rollouts) == 0 {
+			log.Errorf("No rollout for label %s.", rCmd.label)
+			return 1
+		}
+	}
+
+	fmt.Print(rolloutsStr(rollouts))
+	return 0
+}
+
+func filterRollouts(rollouts []db.Rollout, label string) []db.Rollout {
+	var res []db.Rollout
+	for _, r := range rollouts {
+		if r.Label == label {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+func rolloutsStr(rollouts []db.Rollout) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LABEL\tUPDATED\tREADY\tOUTDATED\tSTATUS")
+	for _, r := range rollouts {
+		status := "in progress"
+		if r.Done() {
+			status = "complete"
+		}
+		fmt.Fprintf(w, "%s\t%d/%d\t%d/%d\t%d\t%s\n", r.Label, r.Updated,
+			r.Replicas, r.Ready, r.Replicas, r.Old, status)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// Usage prints the usage for the rollout command.
+func (rCmd *Rollout) Usage() {
+	rCmd.flags.Usage()
+}
@@ -9,13 +9,18 @@ import (
 	"github.com/robertkrimen/otto"
 
 	"github.com/NetSys/quilt/api"
+	"github.com/NetSys/quilt/api/client"
+	"github.com/NetSys/quilt/db"
+	"github.com/NetSys/quilt/engine"
 	"github.com/NetSys/quilt/stitch"
 )
 
 // Run contains the options for running Stitches.
 type Run struct {
-	stitch string
-	host   string
+	stitch    string
+	host      string
+	namespace string
+	plan      bool
 
 	flags *flag.FlagSet
 }
@@ -26,12 +31,21 @@ func (rCmd *Run) createFlagSet() *flag.FlagSet {
 	flags.StringVar(&rCmd.stitch, "stitch", "", "the stitch to run")
 	flags.StringVar(&rCmd.host, "H", api.DefaultSocket,
 		"the host to connect to")
+	flags.StringVar(&rCmd.namespace, "namespace", "",
+		"the namespace to deploy into, overriding the stitch's namespace")
+	flags.BoolVar(&rCmd.plan, "plan", false,
+		"print the changes the stitch would make, without running it")
 
 	flags.Usage = func() {
-		fmt.Println("usage: quilt run [-H=<daemon_host>] " +
-			"[-stitch=<stitch>] <stitch>")
+		fmt.Println("usage: quilt run [-H=<daemon_host>] [-plan] " +
+			"[-namespace=<namespace>] [-stitch=<stitch>] <stitch>")
 		fmt.Println("`run` compiles the provided stitch, and sends the " +
 			"result to the Quilt daemon to be executed.")
+		fmt.Println("Stitches in different namespaces are deployed " +
+			"independently of each other.")
+		fmt.Println("With -plan, the machines and containers that would be " +
+			"booted, terminated, started, stopped or moved are printed " +
+			"instead.")
 		rCmd.flags.PrintDefaults()
 	}
 
@@ -78,7 +92,11 @@ func (rCmd *Run) Run() int {
 		return 1
 	}
 
-	err = c.RunStitch(compiled)
+	if rCmd.plan {
+		return runPlan(c, compiled, rCmd.namespace)
+	}
+
+	err = c.RunStitch(compiled, rCmd.namespace)
 	if err != nil {
 		log.WithError(err).Error("Unable to start run.")
 		return 1
@@ -88,6 +106,102 @@ func (rCmd *Run) Run() int {
 	return 0
 }
 
+// runPlan prints the changes that running `compiled` would make.  Machine changes
+// are planned by the local daemon, and container changes by the lead minion.
+func runPlan(localClient client.Client, compiled, namespace string) int {
+	plan, err := localClient.PlanStitch(compiled, namespace)
+	if err != nil {
+		log.WithError(err).Error("Unable to plan run.")
+		return 1
+	}
+
+	var moves []db.Container
+	leaderClient, err := getLeaderClient(localClient)
+	if err != nil {
+		log.WithError(err).Warn("Unable to connect to leader. " +
+			"Container changes will not be shown.")
+	} else {
+		defer leaderClient.Close()
+
+		containerPlan, err := leaderClient.PlanStitch(compiled, namespace)
+		if err != nil {
+			log.WithError(err).Error("Unable to plan containers.")
+			return 1
+		}
+		plan.StartContainers = containerPlan.StartContainers
+		plan.StopContainers = containerPlan.StopContainers
+
+		containers, err := leaderClient.QueryContainers()
+		if err != nil {
+			log.WithError(err).Error("Unable to query containers.")
+			return 1
+		}
+		moves = movedContainers(plan, containers)
+	}
+
+	fmt.Print(planStr(plan, moves))
+	return 0
+}
+
+// movedContainers returns the containers that will survive the run, but must be
+// rescheduled because the machine they're on will be terminated.
+func movedContainers(plan engine.Plan, containers []db.Container) []db.Container {
+	terminating := map[string]struct{}{}
+	for _, m := range plan.TerminateMachines {
+		if m.PrivateIP != "" {
+			terminating[m.PrivateIP] = struct{}{}
+		}
+	}
+
+	stopping := map[int]struct{}{}
+	for _, dbc := range plan.StopContainers {
+		stopping[dbc.ID] = struct{}{}
+	}
+
+	var moves []db.Container
+	for _, dbc := range containers {
+		_, stop := stopping[dbc.ID]
+		if _, ok := terminating[dbc.Minion]; ok && !stop {
+			moves = append(moves, dbc)
+		}
+	}
+	return moves
+}
+
+// Container changes aren't made all at once, so the plan shows their final state.
+const rolloutNote = "Container changes are the final state of the deployment.  " +
+	"They are rolled out gradually, according to the update strategy of each " +
+	"label.  Use `quilt rollout status` to follow their progress.\n"
+
+func planStr(plan engine.Plan, moves []db.Container) string {
+	var str string
+	machineSection := func(title string, machines []db.Machine) {
+		if len(machines) > 0 {
+			str += title + ":\n" + machinesStr(machines)
+		}
+	}
+	containerSection := func(title string, containers []db.Container) {
+		if len(containers) > 0 {
+			str += title + ":\n" + containersStr(containers)
+		}
+	}
+
+	machineSection("Machines to boot", plan.BootMachines)
+	machineSection("Machines to terminate", plan.TerminateMachines)
+	containerSection("Containers to start", plan.StartContainers)
+	containerSection("Containers to stop", plan.StopContainers)
+	containerSection("Containers to move", moves)
+
+	if str == "" {
+		return "No changes.\n"
+	}
+
+	if len(plan.StartContainers) > 0 || len(plan.StopContainers) > 0 {
+		str += rolloutNote
+	}
+	return str
+}
+
 // Usage prints the usage for the run command.
 func (rCmd *Run) Usage() {
 	rCmd.flags.Usage()
@@ -0,0 +1,114 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/NetSys/quilt/api"
+)
+
+// Secret contains the options for managing secrets.
+type Secret struct {
+	host  string
+	name  string
+	value string
+
+	// The value is read from `stdin` if it isn't given on the command line, so that
+	// it doesn't end up in the shell's history.
+	readStdin bool
+	stdin     io.Reader
+
+	flags *flag.FlagSet
+}
+
+func (sCmd *Secret) createFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("secret", flag.ExitOnError)
+
+	flags.StringVar(&sCmd.host, "H", api.DefaultSocket,
+		"the host to connect to")
+
+	flags.Usage = func() {
+		fmt.Println("usage: quilt secret [-H=<daemon_host>] set <name> [value]")
+		fmt.Println("`secret set` stores the value of a secret in the " +
+			"Quilt daemon.  Containers reference it with " +
+			"`Secret(\"<name>\")`, and it is only resolved on the " +
+			"worker that starts them.  Secrets are kept in memory " +
+			"only, so they must be set again if the daemon restarts.")
+		fmt.Println("If no value is given, it is read from standard input.")
+		sCmd.flags.PrintDefaults()
+	}
+
+	sCmd.flags = flags
+	return flags
+}
+
+// Parse parses the command line arguments for the secret command.
+func (sCmd *Secret) Parse(args []string) error {
+	flags := sCmd.createFlagSet()
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	parsedArgs := flags.Args()
+	if len(parsedArgs) == 0 || parsedArgs[0] != "set" {
+		return errors.New("unrecognized secret subcommand")
+	}
+
+	switch len(parsedArgs) {
+	case 2:
+		sCmd.name = parsedArgs[1]
+		sCmd.readStdin = true
+	case 3:
+		sCmd.name = parsedArgs[1]
+		sCmd.value = parsedArgs[2]
+	default:
+		return errors.New("must specify a secret name, and optionally its value")
+	}
+	return nil
+}
+
+// Run stores the secret in the Quilt daemon.
+func (sCmd *Secret) Run() int {
+	value := sCmd.value
+	if sCmd.readStdin {
+		stdin := sCmd.stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+
+		contents, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			log.WithError(err).Error("Unable to read secret value.")
+			return 1
+		}
+		value = strings.TrimSuffix(string(contents), "\n")
+	}
+
+	c, err := getClient(sCmd.host)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	defer c.Close()
+
+	if err := c.SetSecret(sCmd.name, value); err != nil {
+		log.WithError(err).Error("Unable to set secret.")
+		return 1
+	}
+
+	fmt.Printf("Successfully set secret `%s`.\n", sCmd.name)
+	return 0
+}
+
+// Usage prints the usage for the secret command.
+func (sCmd *Secret) Usage() {
+	sCmd.flags.Usage()
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/NetSys/quilt/minion/docker"
+)
+
+// rawTerminal puts the terminal on standard input into raw mode, so that keystrokes
+// are passed to the container as they're typed.  It returns a function that restores
+// the terminal's previous mode, or an error if standard input isn't a terminal.
+//
+// Stored in a variable so it can be mocked out by the unit tests.
+var rawTerminal = func() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	return func() { stty(state) }, nil
+}
+
+// watchTerminalSize sends the size of the terminal on standard input to `sizes`, and
+// again whenever it changes, until `done` is closed.
+func watchTerminalSize(sizes chan<- docker.TermSize, done <-chan struct{}) {
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	for {
+		if size, err := terminalSize(); err == nil {
+			select {
+			case sizes <- size:
+			case <-done:
+				return
+			}
+		}
+
+		select {
+		case <-winch:
+		case <-done:
+			return
+		}
+	}
+}
+
+func terminalSize() (docker.TermSize, error) {
+	out, err := stty("size")
+	if err != nil {
+		return docker.TermSize{}, err
+	}
+
+	var size docker.TermSize
+	_, err = fmt.Sscanf(out, "%d %d", &size.Height, &size.Width)
+	return size, err
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
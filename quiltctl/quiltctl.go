@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/NetSys/quilt/certs"
 	"github.com/NetSys/quilt/quiltctl/command"
 
 	log "github.com/Sirupsen/logrus"
@@ -19,14 +20,26 @@ var commands = map[string]command.SubCommand{
 	"stop":       &command.Stop{},
 	"ssh":        &command.SSH{},
 	"exec":       &command.Exec{},
+	"secret":     &command.Secret{},
+	"rollout":    &command.Rollout{},
+	"logs":       &command.Logs{},
+	"cordon":     &command.Cordon{},
+	"drain":      &command.Drain{},
 }
 
 // Run parses and runs the quiltctl subcommand given the command line arguments.
-func Run(args []string) {
+// Connections to remote machines authenticate with the credentials in `tlsDir`.
+func Run(args []string, tlsDir string) {
 	if len(args) == 0 {
 		usage()
 	}
 
+	creds, err := certs.Load(tlsDir)
+	if err != nil {
+		log.WithError(err).Debug("Unable to load TLS credentials.")
+	}
+	command.SetCredentials(creds)
+
 	cmd, err := parseSubcommand(args[0], args[1:])
 	if err != nil {
 		log.WithError(err).Error("Unable to parse subcommand.")
@@ -7,6 +7,15 @@ deployment = new Deployment({});
 
 var publicInternetName = "public";
 
+// Must match SecretPrefix in stitch.go.
+var secretPrefix = "__quilt_secret:";
+
+// Must match the protocols in stitch.go.
+var protocols = ["tcp", "udp", "icmp"];
+
+// Must match the spread domains in stitch.go.
+var spreadDomains = ["minion", "region", "provider"];
+
 function getDeployment() {
     deployment.vet();
 
@@ -28,6 +37,11 @@ function getDeployment() {
                 provider: placement.provider || "",
                 size: placement.size || "",
                 region: placement.region || "",
+
+                spread: placement.spread || "",
+                maxSkew: placement.maxSkew || 0,
+
+                rebalance: placement.rebalance || false,
             });
         }
 
@@ -38,26 +52,29 @@ function getDeployment() {
                 to: conn.to.name,
                 minPort: conn.minPort,
                 maxPort: conn.maxPort,
+                protocol: conn.protocol,
             });
         }
 
         for (var j = 0  ; j < label.outgoingPublic.length ; j++) {
-            var rng = label.outgoingPublic[j];
+            var conn = label.outgoingPublic[j];
             connections.push({
                 from: label.name,
                 to: publicInternetName,
-                minPort: rng.min,
-                maxPort: rng.max,
+                minPort: conn.minPort,
+                maxPort: conn.maxPort,
+                protocol: conn.protocol,
             });
         }
 
         for (var j = 0  ; j < label.incomingPublic.length ; j++) {
-            var rng = label.incomingPublic[j];
+            var conn = label.incomingPublic[j];
             connections.push({
                 from: publicInternetName,
                 to: label.name,
-                minPort: rng.min,
-                maxPort: rng.max,
+                minPort: conn.minPort,
+                maxPort: conn.maxPort,
+                protocol: conn.protocol,
             });
         }
 
@@ -65,13 +82,24 @@ function getDeployment() {
         for (var j = 0 ; j < label.containers.length ; j++) {
             var container = label.containers[j];
             ids.push(container.id);
-            containers[container.id] = container;
+            containers[container.id] = {
+                id: container.id,
+                image: container.image,
+                command: container.command,
+                env: envRepresentation(container.env),
+                volumes: container.volumes,
+                cpu: container.cpu,
+                ram: container.ram,
+                healthCheck: container.healthCheck,
+                restartPolicy: container.restartPolicy,
+            };
         }
 
         labels.push({
             name: label.name,
             ids: ids,
             annotations: label.annotations,
+            updateStrategy: label.updateStrategy,
         });
     }
 
@@ -167,28 +195,25 @@ function boxRange(x) {
 }
 
 // XXX: Better name.
-Label.prototype.connectToPublic = function(range) {
+Label.prototype.connectToPublic = function(range, protocol) {
     range = boxRange(range);
-    if (range.min != range.max) {
-        throw "public internet cannot connect on port ranges";
-    }
-    this.outgoingPublic.push(range);
+    this.outgoingPublic.push(new Connection(range, null, protocol));
     return this;
 }
 
 // XXX: Better name.
-Label.prototype.connectFromPublic = function(range) {
+Label.prototype.connectFromPublic = function(range, protocol) {
     range = boxRange(range);
-    if (range.min != range.max) {
-        throw "public internet cannot connect on port ranges";
-    }
-    this.incomingPublic.push(range);
+    this.incomingPublic.push(new Connection(range, null, protocol));
     return this;
 }
 
-Label.prototype.connect = function(range, to) {
+// The optional protocol restricts the connection to "tcp", "udp", or "icmp".  Without
+// it, the connection allows TCP and UDP on the given ports, and ICMP.  The ports of
+// ICMP connections are ignored.
+Label.prototype.connect = function(range, to, protocol) {
     range = boxRange(range);
-    this.connections.push(new Connection(range, to));
+    this.connections.push(new Connection(range, to, protocol));
     return this;
 }
 
@@ -265,11 +290,19 @@ function Container(image, command) {
     this.image = image;
     this.command = command || [];
     this.env = {};
+    this.volumes = [];
+    this.cpu = 0;
+    this.ram = 0;
 }
 
 Container.prototype.clone = function() {
     var cloned = new Container(this.image, _.clone(this.command));
     cloned.env = _.clone(this.env);
+    cloned.volumes = _.clone(this.volumes);
+    cloned.cpu = this.cpu;
+    cloned.ram = this.ram;
+    cloned.healthCheck = _.clone(this.healthCheck);
+    cloned.restartPolicy = this.restartPolicy;
     return cloned;
 }
 
@@ -286,6 +319,106 @@ Container.prototype.withEnv = function(env) {
     return this;
 }
 
+Container.prototype.withVolumes = function(volumes) {
+    this.volumes = volumes;
+    return this;
+}
+
+// The resources a container needs, in cores and gigabytes of memory.  The scheduler
+// only places it on a minion with enough spare capacity, and it's limited to them.
+// Docker can't limit a container to less than 0.01 cores or 4MB of memory.
+Container.prototype.withResources = function(resources) {
+    var cpu = resources.cpu || 0;
+    var ram = resources.ram || 0;
+    if (typeof cpu !== "number" || typeof ram !== "number") {
+        throw "cpu and ram must be numbers";
+    }
+    if (cpu < 0 || ram < 0) {
+        throw "cpu and ram must not be negative";
+    }
+    if (cpu !== 0 && cpu < 0.01) {
+        throw "cpu must be at least 0.01 cores";
+    }
+    if (ram !== 0 && ram < 4 / 1024) {
+        throw "ram must be at least 4MB";
+    }
+
+    this.cpu = cpu;
+    this.ram = ram;
+    return this;
+}
+
+// A health check probes a container every interval seconds, by running a command in
+// it, connecting to a TCP port, or requesting an HTTP path.  Once it fails retries
+// times in a row, the container is considered unhealthy, and is restarted according
+// to its restart policy.
+Container.prototype.withHealthCheck = function(check) {
+    var kinds = 0;
+    var probes = ["exec", "tcp", "http"];
+    for (var i = 0 ; i < probes.length ; i++) {
+        if (check[probes[i]] !== undefined) {
+            kinds++;
+        }
+    }
+    if (kinds !== 1) {
+        throw "health checks must have exactly one of exec, tcp, or http";
+    }
+
+    this.healthCheck = {
+        exec: check.exec,
+        tcp: check.tcp || 0,
+        http: check.http || 0,
+        path: check.path || "",
+        interval: check.interval || 10,
+        retries: check.retries || 3,
+    };
+    return this;
+}
+
+// The restart policy decides whether a container that exits or becomes unhealthy is
+// restarted: "always" (the default), "on-failure", or "never".
+Container.prototype.withRestartPolicy = function(policy) {
+    if (["always", "on-failure", "never"].indexOf(policy) === -1) {
+        throw "unknown restart policy: " + policy;
+    }
+    this.restartPolicy = policy;
+    return this;
+}
+
+// A Volume is storage that outlives the containers it's mounted in.  It's either a
+// directory on the host, or a named docker volume, mounted at its mountPoint.
+function Volume(opts) {
+    if (!opts.name === !opts.hostPath) {
+        throw "volumes must have exactly one of name or hostPath";
+    }
+    if (!opts.mountPoint) {
+        throw "volumes must have a mountPoint";
+    }
+
+    this.name = opts.name || "";
+    this.hostPath = opts.hostPath || "";
+    this.mountPoint = opts.mountPoint;
+}
+
+// A Secret is an environment variable value that's looked up in the daemon's secret
+// store when its container starts, so that it never appears in the deployment.
+function Secret(name) {
+    this.name = name;
+}
+
+// Secrets are represented as references to the secret store.
+function envRepresentation(env) {
+    var res = {};
+    for (var key in env) {
+        var value = env[key];
+        if (value instanceof Secret) {
+            value = secretPrefix + value.name;
+        }
+        res[key] = value;
+    }
+    return res;
+}
+
 var labelNameCount = {};
 function uniqueLabelName(name) {
     if (!(name in labelNameCount)) {
@@ -326,6 +459,29 @@ Label.prototype.annotate = function(annotation) {
     return this;
 };
 
+// The update strategy controls how the label's containers are replaced when their
+// image, command, or environment changes.  At most maxUnavailable of them are down,
+// and at most maxSurge extra are running, at any point in the update.  A new container
+// counts as available once it's running, or, if waitHealthy is set, once it passes its
+// health check.
+Label.prototype.withUpdateStrategy = function(strategy) {
+    var maxUnavailable = strategy.maxUnavailable || 0;
+    var maxSurge = strategy.maxSurge || 0;
+    if (maxUnavailable < 0 || maxSurge < 0) {
+        throw "maxUnavailable and maxSurge must not be negative";
+    }
+    if (maxUnavailable === 0 && maxSurge === 0) {
+        throw "maxUnavailable and maxSurge must not both be zero";
+    }
+
+    this.updateStrategy = {
+        maxUnavailable: maxUnavailable,
+        maxSurge: maxSurge,
+        waitHealthy: strategy.waitHealthy || false,
+    };
+    return this;
+};
+
 Label.prototype.canReach = function(target) {
     return reachable(this.name, target.name);
 };
@@ -368,10 +524,42 @@ function MachineRule(exclusive, optionalArgs) {
     }
 }
 
-function Connection(ports, to) {
+// A SpreadRule limits how unevenly a label's containers are placed across the given
+// domain, one of "minion", "region", or "provider".  No domain may hold more than
+// maxSkew, which defaults to 1, containers beyond the number in the emptiest.
+function SpreadRule(domain, maxSkew) {
+    if (spreadDomains.indexOf(domain) < 0) {
+        throw "unknown spread domain: " + domain;
+    }
+
+    if (maxSkew === undefined) {
+        maxSkew = 1;
+    }
+    if (maxSkew < 1 || maxSkew % 1 !== 0) {
+        throw "maxSkew must be a positive integer";
+    }
+
+    this.exclusive = false;
+    this.spread = domain;
+    this.maxSkew = maxSkew;
+}
+
+// A RebalanceRule allows the scheduler to move a label's containers from busy minions
+// to idle ones, such as workers that were just added.  Moved containers are restarted.
+function RebalanceRule() {
+    this.exclusive = false;
+    this.rebalance = true;
+}
+
+function Connection(ports, to, protocol) {
+    if (protocol !== undefined && protocols.indexOf(protocol) < 0) {
+        throw "unknown protocol: " + protocol;
+    }
+
     this.minPort = ports.min;
     this.maxPort = ports.max;
     this.to = to;
+    this.protocol = protocol || "";
 }
 
 function invariantType(form) {
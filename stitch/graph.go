@@ -28,6 +28,11 @@ type Graph struct {
 	// Constraints on which containers can be placed together.
 	Placement map[string][]string
 	Machines  []Machine
+
+	// Rules spreading labels across machines, and limiting the machines labels may
+	// run on.
+	Spreads      []Placement
+	MachineRules []Placement
 }
 
 // InitializeGraph queries the Stitch to fill in the Graph structure.
@@ -59,6 +64,13 @@ func InitializeGraph(spec Stitch) (Graph, error) {
 		if err != nil {
 			return Graph{}, err
 		}
+
+		switch {
+		case pl.Spread != "":
+			g.Spreads = append(g.Spreads, pl)
+		case pl.OtherLabel == "":
+			g.MachineRules = append(g.MachineRules, pl)
+		}
 	}
 
 	for _, m := range spec.QueryMachines() {
@@ -213,8 +213,70 @@ pathsAny:
 func schedulabilityImpl(graph Graph, inv invariant) bool {
 	machines := graph.Machines
 	avSets := graph.Availability
+	if !graph.spreadable() {
+		return false
+	}
+
 	if _, ok := graph.Nodes["public"]; ok {
 		return len(machines) >= (len(avSets) - 1)
 	}
 	return len(machines) >= len(avSets)
 }
+
+// spreadable returns true if the worker machines offer at least two domains to each
+// spread label with more than one container, so that the failure of a single machine,
+// region, or provider can't take out all of them.
+func (g Graph) spreadable() bool {
+	for _, spread := range g.Spreads {
+		replicas := 0
+		for _, node := range g.Nodes {
+			if node.Label == spread.TargetLabel {
+				replicas++
+			}
+		}
+
+		if replicas < 2 {
+			continue
+		}
+
+		domains := map[string]struct{}{}
+		for i, m := range g.Machines {
+			if m.Role == "Master" || !g.mayRun(m, spread.TargetLabel) {
+				continue
+			}
+
+			switch spread.Spread {
+			case SpreadProvider:
+				domains[m.Provider] = struct{}{}
+			case SpreadRegion:
+				domains[m.Provider+"/"+m.Region] = struct{}{}
+			default:
+				domains[fmt.Sprint(i)] = struct{}{}
+			}
+		}
+
+		if len(domains) < 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// mayRun returns true if the machine rules allow `label` to run on `m`.
+func (g Graph) mayRun(m Machine, label string) bool {
+	for _, rule := range g.MachineRules {
+		if rule.TargetLabel != label {
+			continue
+		}
+
+		onProvider := rule.Provider == m.Provider
+		onRegion := rule.Region == m.Region
+		onSize := rule.Size == m.Size
+		if (rule.Provider != "" && rule.Exclusive == onProvider) ||
+			(rule.Region != "" && rule.Exclusive == onRegion) ||
+			(rule.Size != "" && rule.Exclusive == onSize) {
+			return false
+		}
+	}
+	return true
+}
@@ -150,6 +150,45 @@ func TestNoConnect(t *testing.T) {
 	}
 }
 
+func TestSpreadInvariant(t *testing.T) {
+	pre := `var zk = new Label("zk", new Container("zookeeper").replicate(3));
+	deployment.deploy(zk);
+	deployment.assert(enough, true);
+	var base = new Machine({provider: "Amazon", region: "us-west-1"});
+	deployment.deploy(base.asMaster());`
+
+	// Two workers are enough to spread across minions, but not regions.
+	spec := pre + `deployment.deploy(base.asWorker().replicate(2));`
+	if _, err := initSpec(spec + `zk.place(new SpreadRule("minion"));`); err != nil {
+		t.Error(err)
+	}
+
+	_, err := initSpec(spec + `zk.place(new SpreadRule("region"));`)
+	if _, ok := err.(invariantError); !ok {
+		t.Errorf("Expected an invariant error, got %v", err)
+	}
+
+	spec += `deployment.deploy(new Machine({provider: "Amazon",
+		region: "us-west-2"}).asWorker());`
+	if _, err := initSpec(spec + `zk.place(new SpreadRule("region"));`); err != nil {
+		t.Error(err)
+	}
+
+	// Machines the label may not run on don't count.
+	_, err = initSpec(spec + `zk.place(new SpreadRule("region"));
+	zk.place(new MachineRule(true, {region: "us-west-2"}));`)
+	if _, ok := err.(invariantError); !ok {
+		t.Errorf("Expected an invariant error, got %v", err)
+	}
+
+	// A single worker can't spread a label with multiple containers.
+	_, err = initSpec(pre + `deployment.deploy(base.asWorker());
+	zk.place(new SpreadRule("minion", 3));`)
+	if _, ok := err.(invariantError); !ok {
+		t.Errorf("Expected an invariant error, got %v", err)
+	}
+}
+
 func TestNested(t *testing.T) {
 	t.Skip("needs hierarchical labeling to pass")
 	stc := `(label "a" (docker "ubuntu"))
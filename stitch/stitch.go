@@ -4,6 +4,7 @@ package stitch
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/robertkrimen/otto"
 
@@ -21,7 +22,8 @@ type Stitch struct {
 }
 
 // A Placement constraint guides where containers may be scheduled, either relative to
-// the labels of other containers, or the machine the container will run on.
+// the labels of other containers, the machine the container will run on, or how the
+// label's containers are spread across machines.
 type Placement struct {
 	TargetLabel string
 
@@ -34,32 +36,99 @@ type Placement struct {
 	Provider string
 	Size     string
 	Region   string
+
+	// Spread Constraint.  The number of the label's containers in any one Spread
+	// domain may exceed the number in the emptiest by at most MaxSkew.
+	Spread  string
+	MaxSkew int
+
+	// Whether the label's containers may be moved to even out the load on minions.
+	Rebalance bool
 }
 
+// The domains across which a label's containers may be spread.  They must match
+// spreadDomains in bindings.js.
+const (
+	SpreadMinion   = "minion"
+	SpreadRegion   = "region"
+	SpreadProvider = "provider"
+)
+
 // A Container may be instantiated in the stitch and queried by users.
 type Container struct {
 	ID      int
 	Image   string
 	Command []string
 	Env     map[string]string
+	Volumes []Volume
+
+	// The number of cores and gigabytes of memory the container requires.
+	CPU float64
+	RAM float64
+
+	HealthCheck   HealthCheck
+	RestartPolicy string
+}
+
+// A HealthCheck probes a container every Interval seconds.  Exactly one of Exec, a
+// command run in the container, TCP, a port to connect to, or HTTP, a port to request
+// Path from, is set.
+type HealthCheck struct {
+	Exec []string
+	TCP  int
+	HTTP int
+	Path string
+
+	Interval int
+	Retries  int
+}
+
+// A Volume is persistent storage mounted into a container at MountPoint.  Exactly one
+// of Name, a docker volume, or HostPath, a directory on the minion, is set.
+type Volume struct {
+	Name       string
+	HostPath   string
+	MountPoint string
 }
 
 // A Label represents a logical group of containers.
 type Label struct {
-	Name        string
-	IDs         []int
-	Annotations []string
+	Name           string
+	IDs            []int
+	Annotations    []string
+	UpdateStrategy UpdateStrategy
+}
+
+// An UpdateStrategy describes how a label's containers are replaced when they change.
+// At most MaxUnavailable of them are down, and at most MaxSurge extra are running,
+// while the update is in progress.  If WaitHealthy is set, new containers with a
+// health check aren't available until they pass it.  The zero UpdateStrategy replaces
+// one container at a time.
+type UpdateStrategy struct {
+	MaxUnavailable int
+	MaxSurge       int
+	WaitHealthy    bool
 }
 
 // A Connection allows containers implementing the From label to speak to containers
-// implementing the To label in ports in the range [MinPort, MaxPort]
+// implementing the To label in ports in the range [MinPort, MaxPort].  If Protocol is
+// set, only that protocol is allowed, otherwise TCP, UDP, and ICMP all are.
 type Connection struct {
-	From    string
-	To      string
-	MinPort int
-	MaxPort int
+	From     string
+	To       string
+	MinPort  int
+	MaxPort  int
+	Protocol string
 }
 
+// The protocols that connections may be restricted to.  They must match protocols in
+// bindings.js.
+const (
+	ProtocolTCP  = "tcp"
+	ProtocolUDP  = "udp"
+	ProtocolICMP = "icmp"
+)
+
 // A ConnectionSlice allows for slices of Collections to be used in joins
 type ConnectionSlice []Connection
 
@@ -85,6 +154,19 @@ type Range struct {
 // network.
 const PublicInternetLabel = "public"
 
+// SecretPrefix marks environment values that reference a secret, rather than hold a
+// literal value.  It must match secretPrefix in bindings.js.
+const SecretPrefix = "__quilt_secret:"
+
+// SecretName returns the name of the secret that the environment value `value`
+// references, if it references one.
+func SecretName(value string) (string, bool) {
+	if !strings.HasPrefix(value, SecretPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, SecretPrefix), true
+}
+
 // Accepts returns true if `x` is within the range specified by `stitchr` (include),
 // or if no max is specified and `x` is larger than `stitchr.min`.
 func (stitchr Range) Accepts(x float64) bool {
@@ -197,36 +279,43 @@ func New(specStr string, getter ImportGetter) (Stitch, error) {
 	return spec, nil
 }
 
+// createPortRules prevents containers that accept connections from the public
+// internet on overlapping ports from sharing a machine, as each is forwarded the
+// ports of the machine it runs on.
 func (ctx *evalCtx) createPortRules() {
-	ports := make(map[int][]string)
+	var public []Connection
 	for _, c := range ctx.Connections {
-		if c.From != PublicInternetLabel && c.To != PublicInternetLabel {
-			continue
+		if c.From == PublicInternetLabel && c.Protocol != ProtocolICMP {
+			public = append(public, c)
 		}
+	}
 
-		target := c.From
-		if c.From == PublicInternetLabel {
-			target = c.To
+	exclusive := map[Placement]struct{}{}
+	exclude := func(target, other string) {
+		plcm := Placement{Exclusive: true, TargetLabel: target, OtherLabel: other}
+		if _, ok := exclusive[plcm]; !ok {
+			exclusive[plcm] = struct{}{}
+			ctx.Placements = append(ctx.Placements, plcm)
 		}
-
-		min := c.MinPort
-		ports[min] = append(ports[min], target)
 	}
 
-	for _, labels := range ports {
-		for _, tgt := range labels {
-			for _, other := range labels {
-				ctx.Placements = append(ctx.Placements,
-					Placement{
-						Exclusive:   true,
-						TargetLabel: tgt,
-						OtherLabel:  other,
-					})
+	for i, c := range public {
+		for _, other := range public[i:] {
+			if c.hostPortsOverlap(other) {
+				exclude(c.To, other.To)
+				exclude(other.To, c.To)
 			}
 		}
 	}
 }
 
+// hostPortsOverlap returns true if `c` and `other` may need the same host port.
+func (c Connection) hostPortsOverlap(other Connection) bool {
+	return (c.Protocol == "" || other.Protocol == "" ||
+		c.Protocol == other.Protocol) &&
+		c.MinPort <= other.MaxPort && other.MinPort <= c.MaxPort
+}
+
 // QueryLabels retrieves all labels declared in the Stitch.
 func (stitch Stitch) QueryLabels() []Label {
 	return stitch.ctx.Labels
@@ -261,6 +350,14 @@ func (stitch Stitch) QueryMaxPrice() float64 {
 	return stitch.ctx.MaxPrice
 }
 
+// WithNamespace returns a copy of the stitch that's deployed to `namespace`, rather
+// than to the namespace it declares.
+func (stitch Stitch) WithNamespace(namespace string) Stitch {
+	ctx := *stitch.ctx
+	ctx.Namespace = namespace
+	return Stitch{stitch.code, &ctx}
+}
+
 // QueryNamespace returns the namespace declared in the stitch.
 func (stitch Stitch) QueryNamespace() string {
 	return stitch.ctx.Namespace
@@ -82,10 +82,27 @@ func TestContainer(t *testing.T) {
 				ID:      1,
 				Image:   "image",
 				Command: []string{"arg1", "arg2"},
+				Volumes: []Volume{},
 				Env:     map[string]string{"foo": "bar"},
 			},
 		})
 
+	checkContainers(t, `deployment.deploy(new Label("foo", [
+	new Container("image").withEnv({"foo": "bar", "pass": new Secret("db")})
+	]));`,
+		map[int]Container{
+			1: {
+				ID:      1,
+				Image:   "image",
+				Command: []string{},
+				Volumes: []Volume{},
+				Env: map[string]string{
+					"foo":  "bar",
+					"pass": SecretPrefix + "db",
+				},
+			},
+		})
+
 	checkContainers(t, `deployment.deploy(new Label("foo", [
 	new Container("image", ["arg1", "arg2"])
 	]));`,
@@ -94,6 +111,7 @@ func TestContainer(t *testing.T) {
 				ID:      1,
 				Image:   "image",
 				Command: []string{"arg1", "arg2"},
+				Volumes: []Volume{},
 				Env:     map[string]string{},
 			},
 		})
@@ -108,6 +126,7 @@ func TestContainer(t *testing.T) {
 				ID:      1,
 				Image:   "image",
 				Command: []string{},
+				Volumes: []Volume{},
 				Env:     map[string]string{},
 			},
 		})
@@ -120,6 +139,7 @@ func TestContainer(t *testing.T) {
 				ID:      1,
 				Image:   "image",
 				Command: []string{},
+				Volumes: []Volume{},
 				Env:     map[string]string{"foo": "bar"},
 			},
 		})
@@ -133,12 +153,14 @@ func TestContainer(t *testing.T) {
 				ID:      2,
 				Image:   "image",
 				Command: []string{"arg"},
+				Volumes: []Volume{},
 				Env:     map[string]string{},
 			},
 			3: {
 				ID:      3,
 				Image:   "image",
 				Command: []string{"arg"},
+				Volumes: []Volume{},
 				Env:     map[string]string{},
 			},
 		})
@@ -156,6 +178,7 @@ func TestContainer(t *testing.T) {
 				ID:      2,
 				Image:   "image",
 				Command: []string{"arg", "changed"},
+				Volumes: []Volume{},
 				Env: map[string]string{
 					"foo": "bar",
 				},
@@ -164,9 +187,99 @@ func TestContainer(t *testing.T) {
 				ID:      3,
 				Image:   "image",
 				Command: []string{"arg"},
+				Volumes: []Volume{},
+				Env:     map[string]string{},
+			},
+		})
+
+	checkContainers(t, `deployment.deploy(new Label("foo", [
+	new Container("image").withVolumes([
+		new Volume({name: "data", mountPoint: "/var/lib/mysql"}),
+		new Volume({hostPath: "/etc", mountPoint: "/etc"})
+	])]));`,
+		map[int]Container{
+			1: {
+				ID:      1,
+				Image:   "image",
+				Command: []string{},
+				Env:     map[string]string{},
+				Volumes: []Volume{
+					{Name: "data", MountPoint: "/var/lib/mysql"},
+					{HostPath: "/etc", MountPoint: "/etc"},
+				},
+			},
+		})
+
+	checkContainers(t, `deployment.deploy(new Label("foo", [
+	new Container("image").withResources({cpu: 2, ram: 0.5})
+	]));`,
+		map[int]Container{
+			1: {
+				ID:      1,
+				Image:   "image",
+				Command: []string{},
+				Env:     map[string]string{},
+				Volumes: []Volume{},
+				CPU:     2,
+				RAM:     0.5,
+			},
+		})
+
+	checkContainers(t, `deployment.deploy(new Label("foo", [
+	new Container("image").withHealthCheck({http: 80, path: "/healthz"})
+		.withRestartPolicy("on-failure")
+	]));`,
+		map[int]Container{
+			1: {
+				ID:      1,
+				Image:   "image",
+				Command: []string{},
 				Env:     map[string]string{},
+				Volumes: []Volume{},
+				HealthCheck: HealthCheck{
+					HTTP:     80,
+					Path:     "/healthz",
+					Interval: 10,
+					Retries:  3,
+				},
+				RestartPolicy: "on-failure",
 			},
 		})
+
+	checkContainers(t, `deployment.deploy(new Label("foo", [
+	new Container("image").withHealthCheck(
+		{exec: ["pg_isready"], interval: 5, retries: 1})
+	]));`,
+		map[int]Container{
+			1: {
+				ID:      1,
+				Image:   "image",
+				Command: []string{},
+				Env:     map[string]string{},
+				Volumes: []Volume{},
+				HealthCheck: HealthCheck{
+					Exec:     []string{"pg_isready"},
+					Interval: 5,
+					Retries:  1,
+				},
+			},
+		})
+
+	checkError(t, `new Container("image").withHealthCheck({tcp: 80, http: 80});`,
+		"health checks must have exactly one of exec, tcp, or http")
+	checkError(t, `new Container("image").withRestartPolicy("sometimes");`,
+		"unknown restart policy: sometimes")
+	checkError(t, `new Volume({mountPoint: "/data"});`,
+		"volumes must have exactly one of name or hostPath")
+	checkError(t, `new Volume({name: "data"});`, "volumes must have a mountPoint")
+	checkError(t, `new Container("image").withResources({cpu: -1});`,
+		"cpu and ram must not be negative")
+	checkError(t, `new Container("image").withResources({cpu: 0.001});`,
+		"cpu must be at least 0.01 cores")
+	checkError(t, `new Container("image").withResources({ram: 0.001});`,
+		"ram must be at least 4MB")
+	checkError(t, `new Container("image").withResources({ram: "1GB"});`,
+		"cpu and ram must be numbers")
 }
 
 func TestPlacement(t *testing.T) {
@@ -210,6 +323,59 @@ func TestPlacement(t *testing.T) {
 				Size:        "m4.large",
 			},
 		})
+
+	checkPlacements(t, pre+`target.place(new SpreadRule("region"));
+	other.place(new SpreadRule("minion", 2));`+post,
+		[]Placement{
+			{TargetLabel: "target", Spread: SpreadRegion, MaxSkew: 1},
+			{TargetLabel: "other", Spread: SpreadMinion, MaxSkew: 2},
+		})
+
+	checkPlacements(t, pre+`target.place(new RebalanceRule());`+post,
+		[]Placement{{TargetLabel: "target", Rebalance: true}})
+
+	checkError(t, pre+`target.place(new SpreadRule("rack"));`,
+		"unknown spread domain: rack")
+	checkError(t, pre+`target.place(new SpreadRule("minion", 0));`,
+		"maxSkew must be a positive integer")
+}
+
+func TestPortPlacement(t *testing.T) {
+	t.Parallel()
+
+	pre := `var foo = new Label("foo", []);
+	var bar = new Label("bar", []);
+	deployment.deploy([foo, bar]);`
+
+	exclusive := func(target, other string) Placement {
+		return Placement{Exclusive: true, TargetLabel: target, OtherLabel: other}
+	}
+
+	// Containers that accept the same public ports can't share a machine.
+	checkPlacements(t, pre+`foo.connectFromPublic(80);`,
+		[]Placement{exclusive("foo", "foo")})
+
+	checkPlacements(t, pre+`foo.connectFromPublic(new PortRange(8000, 8100));
+	bar.connectFromPublic(8050);`,
+		[]Placement{
+			exclusive("foo", "foo"),
+			exclusive("foo", "bar"),
+			exclusive("bar", "foo"),
+			exclusive("bar", "bar"),
+		})
+
+	// Disjoint ranges, different protocols, and outgoing connections don't
+	// conflict.
+	checkPlacements(t, pre+`foo.connectFromPublic(new PortRange(8000, 8100));
+	bar.connectFromPublic(new PortRange(8101, 8200));`,
+		[]Placement{exclusive("foo", "foo"), exclusive("bar", "bar")})
+
+	checkPlacements(t, pre+`foo.connectFromPublic(53, "udp");
+	bar.connectFromPublic(53, "tcp");`,
+		[]Placement{exclusive("foo", "foo"), exclusive("bar", "bar")})
+
+	checkPlacements(t, pre+`foo.connectToPublic(80);
+	bar.connectToPublic(80);`, []Placement{})
 }
 
 func TestLabel(t *testing.T) {
@@ -256,6 +422,25 @@ func TestLabel(t *testing.T) {
 			},
 		})
 
+	checkLabels(t, `deployment.deploy(new Label("foo", []).withUpdateStrategy(
+		{maxSurge: 2, waitHealthy: true}));`,
+		map[string]Label{
+			"foo": {
+				Name:        "foo",
+				IDs:         []int{},
+				Annotations: []string{},
+				UpdateStrategy: UpdateStrategy{
+					MaxSurge:    2,
+					WaitHealthy: true,
+				},
+			},
+		})
+
+	checkError(t, `new Label("foo", []).withUpdateStrategy({maxUnavailable: 0});`,
+		"maxUnavailable and maxSurge must not both be zero")
+	checkError(t, `new Label("foo", []).withUpdateStrategy({maxSurge: -1});`,
+		"maxUnavailable and maxSurge must not be negative")
+
 	expHostname := "foo.q"
 	checkJavascript(t, `var foo = new Label("foo", []);
 	return foo.hostname();`, expHostname)
@@ -324,10 +509,50 @@ func TestConnect(t *testing.T) {
 			},
 		})
 
-	checkError(t, pre+`foo.connectToPublic(new PortRange(80, 81));`,
-		"public internet cannot connect on port ranges")
-	checkError(t, pre+`foo.connectFromPublic(new PortRange(80, 81));`,
-		"public internet cannot connect on port ranges")
+	checkConnections(t, pre+`foo.connectFromPublic(new PortRange(80, 81));`,
+		[]Connection{
+			{
+				From:    "public",
+				To:      "foo",
+				MinPort: 80,
+				MaxPort: 81,
+			},
+		})
+
+	checkConnections(t, pre+`foo.connect(53, bar, "udp");`,
+		[]Connection{
+			{
+				From:     "foo",
+				To:       "bar",
+				MinPort:  53,
+				MaxPort:  53,
+				Protocol: "udp",
+			},
+		})
+
+	checkConnections(t, pre+`foo.connectToPublic(443, "tcp");`,
+		[]Connection{
+			{
+				From:     "foo",
+				To:       "public",
+				MinPort:  443,
+				MaxPort:  443,
+				Protocol: "tcp",
+			},
+		})
+
+	checkConnections(t, pre+`foo.connectFromPublic(0, "icmp");`,
+		[]Connection{
+			{
+				From:     "public",
+				To:       "foo",
+				MinPort:  0,
+				MaxPort:  0,
+				Protocol: "icmp",
+			},
+		})
+
+	checkError(t, pre+`foo.connect(80, bar, "sctp");`, "unknown protocol: sctp")
 }
 
 func TestVet(t *testing.T) {
@@ -545,3 +770,15 @@ var checkLabels = queryChecker(func(s Stitch) interface{} {
 var checkConnections = queryChecker(func(s Stitch) interface{} {
 	return s.QueryConnections()
 })
+
+func TestSecretName(t *testing.T) {
+	t.Parallel()
+
+	if name, ok := SecretName(SecretPrefix + "db"); !ok || name != "db" {
+		t.Errorf("Expected secret db, got %s (%v)", name, ok)
+	}
+
+	if _, ok := SecretName("plain"); ok {
+		t.Error("Expected plain value to not reference a secret")
+	}
+}